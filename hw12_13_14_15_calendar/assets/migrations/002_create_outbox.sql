@@ -0,0 +1,20 @@
+CREATE TABLE outbox
+(
+    id              VARCHAR(255) PRIMARY KEY,
+    idempotency_key VARCHAR(255)             NOT NULL,
+    payload         BYTEA                    NOT NULL,
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    sent_at         TIMESTAMP WITH TIME ZONE,
+    attempts        INTEGER                  NOT NULL DEFAULT 0,
+    last_error      TEXT                     NOT NULL DEFAULT ''
+);
+
+-- Prevent the same notification from being enqueued twice
+CREATE UNIQUE INDEX idx_outbox_idempotency_key ON outbox (idempotency_key);
+
+-- Create a partial index for the relay to pick up pending messages
+CREATE INDEX idx_outbox_pending ON outbox (created_at) WHERE sent_at IS NULL;
+
+---- create above / drop below ----
+
+drop table outbox;
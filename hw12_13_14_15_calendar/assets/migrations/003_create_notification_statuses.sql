@@ -0,0 +1,16 @@
+CREATE TABLE notification_statuses
+(
+    notification_id VARCHAR(255) PRIMARY KEY,
+    event_id        VARCHAR(255)             NOT NULL,
+    state           VARCHAR(32)              NOT NULL,
+    attempts        INTEGER                  NOT NULL DEFAULT 1,
+    error           TEXT                     NOT NULL DEFAULT '',
+    updated_at      TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Create an index on event_id to list notifications of an event
+CREATE INDEX idx_notification_statuses_event_id ON notification_statuses (event_id);
+
+---- create above / drop below ----
+
+drop table notification_statuses;
@@ -0,0 +1,20 @@
+CREATE TABLE reminders
+(
+    event_id       VARCHAR(255) NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    offset_seconds BIGINT       NOT NULL,
+    channel        VARCHAR(64)  NOT NULL DEFAULT '',
+    message        TEXT         NOT NULL DEFAULT ''
+);
+
+-- Create an index on event_id to load reminders of events
+CREATE INDEX idx_reminders_event_id ON reminders (event_id);
+
+-- Move single reminders, notify_delta is in minutes
+INSERT INTO reminders (event_id, offset_seconds)
+SELECT id, notify_delta * 60
+FROM events
+WHERE notify_delta > 0;
+
+---- create above / drop below ----
+
+drop table reminders;
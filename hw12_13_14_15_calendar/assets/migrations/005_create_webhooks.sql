@@ -0,0 +1,33 @@
+CREATE TABLE webhook_subscriptions
+(
+    id          VARCHAR(255) PRIMARY KEY,
+    user_id     VARCHAR(255)             NOT NULL DEFAULT '',
+    url         TEXT                     NOT NULL,
+    secret      TEXT                     NOT NULL DEFAULT '',
+    event_types TEXT[]                   NOT NULL DEFAULT '{}',
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_webhook_subscriptions_user_id ON webhook_subscriptions (user_id);
+
+CREATE TABLE webhook_deliveries
+(
+    id              VARCHAR(255) PRIMARY KEY,
+    subscription_id VARCHAR(255)             NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+    event_type      VARCHAR(64)              NOT NULL,
+    payload         BYTEA                    NOT NULL,
+    state           VARCHAR(32)              NOT NULL,
+    attempts        INTEGER                  NOT NULL DEFAULT 0,
+    status_code     INTEGER                  NOT NULL DEFAULT 0,
+    error           TEXT                     NOT NULL DEFAULT '',
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    updated_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+-- Create an index to show the delivery log of a subscription
+CREATE INDEX idx_webhook_deliveries_subscription ON webhook_deliveries (subscription_id, created_at DESC);
+
+---- create above / drop below ----
+
+drop table webhook_deliveries;
+drop table webhook_subscriptions;
@@ -0,0 +1,34 @@
+-- Start of the event is copied to reminders, so the notify time can be a generated column
+ALTER TABLE reminders ADD COLUMN event_start TIMESTAMP WITH TIME ZONE;
+
+UPDATE reminders r
+SET event_start = e.start_time
+FROM events e
+WHERE e.id = r.event_id;
+
+ALTER TABLE reminders ALTER COLUMN event_start SET NOT NULL;
+
+-- Subtracting an interval from timestamptz is only stable in general, because of days and months
+-- depending on the time zone. An interval of seconds does not, so the function is immutable.
+CREATE FUNCTION reminder_notify_at(event_start TIMESTAMP WITH TIME ZONE, offset_seconds BIGINT)
+    RETURNS TIMESTAMP WITH TIME ZONE
+    LANGUAGE sql
+    IMMUTABLE PARALLEL SAFE
+AS
+$$
+SELECT event_start - offset_seconds * INTERVAL '1 second'
+$$;
+
+ALTER TABLE reminders
+    ADD COLUMN notify_at TIMESTAMP WITH TIME ZONE
+        GENERATED ALWAYS AS (reminder_notify_at(event_start, offset_seconds)) STORED;
+
+-- Create an index on notify_at to find due reminders
+CREATE INDEX idx_reminders_notify_at ON reminders (notify_at);
+
+---- create above / drop below ----
+
+drop index idx_reminders_notify_at;
+alter table reminders drop column notify_at;
+drop function reminder_notify_at;
+alter table reminders drop column event_start;
@@ -0,0 +1,10 @@
+CREATE TABLE scheduler_state
+(
+    name       VARCHAR(64) PRIMARY KEY,
+    watermark  TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+---- create above / drop below ----
+
+drop table scheduler_state;
@@ -0,0 +1,19 @@
+CREATE TABLE bus_messages
+(
+    id           BIGSERIAL PRIMARY KEY,
+    topic        VARCHAR(255)             NOT NULL,
+    message_id   VARCHAR(255)             NOT NULL DEFAULT '',
+    body         BYTEA                    NOT NULL,
+    attempt      INTEGER                  NOT NULL DEFAULT 1,
+    available_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    dead         BOOLEAN                  NOT NULL DEFAULT false,
+    last_error   TEXT                     NOT NULL DEFAULT '',
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+-- Create a partial index for consumers to pick up messages that are due
+CREATE INDEX idx_bus_messages_available ON bus_messages (topic, available_at, id) WHERE NOT dead;
+
+---- create above / drop below ----
+
+drop table bus_messages;
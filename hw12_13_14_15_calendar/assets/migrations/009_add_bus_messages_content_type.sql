@@ -0,0 +1,5 @@
+ALTER TABLE bus_messages ADD COLUMN content_type VARCHAR(64) NOT NULL DEFAULT 'application/json';
+
+---- create above / drop below ----
+
+ALTER TABLE bus_messages DROP COLUMN content_type;
@@ -0,0 +1,19 @@
+CREATE TABLE events_archive
+(
+    id           VARCHAR(255) PRIMARY KEY,
+    title        VARCHAR(255)             NOT NULL,
+    start_time   TIMESTAMP WITH TIME ZONE NOT NULL,
+    end_time     TIMESTAMP WITH TIME ZONE NOT NULL,
+    user_id      VARCHAR(255)             NOT NULL,
+    notify_delta INTEGER                  NOT NULL DEFAULT 0,
+    -- Reminders as [{"offsetSeconds": 600, "channel": "email", "message": ""}]
+    reminders    JSONB                    NOT NULL DEFAULT '[]',
+    archived_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+-- Create an index to search archived events of a user
+CREATE INDEX idx_events_archive_user_start ON events_archive (user_id, start_time);
+
+---- create above / drop below ----
+
+drop table events_archive;
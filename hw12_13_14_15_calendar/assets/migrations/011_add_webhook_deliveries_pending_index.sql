@@ -0,0 +1,6 @@
+-- Create a partial index to resume the pending deliveries after a restart
+CREATE INDEX idx_webhook_deliveries_pending ON webhook_deliveries (created_at) WHERE state = 'pending';
+
+---- create above / drop below ----
+
+drop index idx_webhook_deliveries_pending;
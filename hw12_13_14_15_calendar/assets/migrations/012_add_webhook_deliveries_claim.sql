@@ -0,0 +1,8 @@
+-- Add the claim of a pending delivery, so a single dispatcher delivers or resumes it
+ALTER TABLE webhook_deliveries
+    ADD COLUMN claimed_by    VARCHAR(255)             NOT NULL DEFAULT '',
+    ADD COLUMN claimed_until TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now();
+
+---- create above / drop below ----
+
+ALTER TABLE webhook_deliveries DROP COLUMN claimed_until, DROP COLUMN claimed_by;
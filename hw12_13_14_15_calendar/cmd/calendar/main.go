@@ -11,15 +11,28 @@ import (
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/app"
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
 	appGrpc "github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/grpc"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/health"
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/logger"
 	internalhttp "github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/server/http"
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/tracing"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/webhook"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 )
 
-var configFile string
+var (
+	configFile  string
+	watchConfig time.Duration
+	config      = conf.NewConfig()
+	overrides   conf.Overrides
+)
 
 func init() {
-	flag.StringVar(&configFile, "config", "/etc/calendar/config.toml", "Path to configuration file")
+	flag.StringVar(&configFile, "config", "/etc/calendar/config.toml",
+		"Path to configuration file, empty to configure by environment variables and flags only")
+	flag.DurationVar(&watchConfig, "watch-config", 0,
+		"How often to check the configuration file for changes, 0 reloads it on SIGHUP only")
+	overrides = conf.RegisterFlags(flag.CommandLine, &config)
 }
 
 func main() {
@@ -30,18 +43,32 @@ func main() {
 		return
 	}
 	// load config
-	config := conf.NewConfig()
-	if err := config.LoadFromFile(configFile); err != nil {
+	if err := config.Load(configFile, overrides); err != nil {
 		fmt.Println("failed to load config: " + err.Error())
 		return
 	}
 
 	// create logger
-	logg, err := logger.New(config.Logger.Level)
+	logg, err := logger.NewFromConfig(&config.Logger)
 	if err != nil {
 		fmt.Println("failed to create logger: " + err.Error())
 		return
 	}
+	defer logg.Close()
+
+	// set up tracing
+	shutdownTracing, err := tracing.Setup(context.Background(), &config.Tracing)
+	if err != nil {
+		logg.Error("failed to set up tracing: " + err.Error())
+		return
+	}
+	defer func() {
+		timeout, cancelFunc := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancelFunc()
+		if err := shutdownTracing(timeout); err != nil {
+			logg.Error(err.Error())
+		}
+	}()
 
 	// create storage
 	s, closeFunc, err := storage.NewFromConfig(&config.Storage)
@@ -60,13 +87,33 @@ func main() {
 	}
 
 	// create app
-	calendar := app.New(logg, s)
+	calendar := app.New(logg.Named("calendar"), s)
+
+	// start webhook dispatcher
+	calendar.Webhooks = webhook.NewDispatcher(s, logg.Named("webhook"), &config.Webhooks)
+	calendar.Webhooks.Start()
+	defer calendar.Webhooks.Stop()
 
 	// create context
 	ctx, cancel := signal.NotifyContext(context.Background(),
-		syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+		syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
+	// reload the config on SIGHUP, only the log levels are applied without a restart
+	reloader := conf.NewAPIReloader(&config, configFile, overrides)
+	reloader.Subscribe(func(c *conf.APIConfig) {
+		if err := logg.Configure(&c.Logger); err != nil {
+			logg.Error("failed to configure logger: " + err.Error())
+		}
+	})
+	reloader.Start(ctx, watchConfig, func(err error) {
+		if err != nil {
+			logg.Error(err.Error())
+			return
+		}
+		logg.Info("config reloaded")
+	})
+
 	// create gRPC server
 	grpcServer, err := appGrpc.NewServer(calendar, &config.GRPC)
 	if err != nil {
@@ -74,6 +121,11 @@ func main() {
 		return
 	}
 
+	// check readiness, the storage must be reachable
+	checker := health.NewChecker(health.CheckTimeout)
+	checker.Add("storage", s.Ping)
+	go grpcServer.WatchHealth(ctx, checker, health.WatchInterval)
+
 	// run gRPC server
 	go func() {
 		if err := grpcServer.Serve(); err != nil {
@@ -83,13 +135,13 @@ func main() {
 	}()
 
 	// create http server
-	gwmux, err := grpcServer.CreateGatewayMux(ctx)
+	gwmux, err := grpcServer.CreateGatewayMux(ctx, runtime.WithMetadata(internalhttp.GatewayRoute))
 	if err != nil {
 		logg.Error("failed to register gateway: " + err.Error())
 		return
 	}
 
-	httpServer := internalhttp.NewServer(logg, gwmux.ServeHTTP, calendar, config.HTTP.BindAddr)
+	httpServer := internalhttp.NewServer(logg.Named("http"), gwmux.ServeHTTP, calendar, checker, config.HTTP.BindAddr)
 
 	// signal handling
 	go func() {
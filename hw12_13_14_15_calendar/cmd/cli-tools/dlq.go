@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/amqp"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
+)
+
+// deadLetters inspects and replays notifications that the sender failed to deliver.
+func deadLetters(config *conf.AMQPConfig, args []string) {
+	if len(args) == 0 {
+		log.Fatal("usage: dlq list [-limit n] | dlq replay [id ...]")
+	}
+	dl, err := amqp.NewDeadLetters(config)
+	if err != nil {
+		log.Fatal("failed to open dead-letter queue: " + err.Error())
+	}
+	defer func() {
+		if err := dl.Close(); err != nil {
+			log.Println("failed to close dead-letter queue: " + err.Error())
+		}
+	}()
+
+	switch args[0] {
+	case "list":
+		fs := flag.NewFlagSet("list", flag.ExitOnError)
+		limit := fs.Int("limit", 100, "Maximum number of messages to show, 0 shows all")
+		_ = fs.Parse(args[1:])
+		letters, err := dl.List(*limit)
+		if err != nil {
+			log.Println("failed to list dead letters: " + err.Error())
+			return
+		}
+		for _, l := range letters {
+			fmt.Printf("%s\t%s\tattempts=%d\tkey=%s\terror=%q\n%s\n\n",
+				l.ID, l.Timestamp.Format(time.RFC3339), l.Attempts, l.RoutingKey, l.Error, l.Body)
+		}
+		log.Printf("%d dead letters\n", len(letters))
+	case "replay":
+		n, err := dl.Replay(args[1:]...)
+		if err != nil {
+			log.Println("failed to replay dead letters: " + err.Error())
+		}
+		log.Printf("replayed %d dead letters\n", n)
+	default:
+		log.Fatal("unknown dlq command " + args[0])
+	}
+}
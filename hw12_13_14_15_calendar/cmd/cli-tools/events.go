@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/gen/events/pb"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/eventscli"
+)
+
+// events manages calendar events through the gRPC API of the calendar service.
+func events(args []string) {
+	fs := flag.NewFlagSet("events", flag.ExitOnError)
+	addr := fs.String("addr", "", "Address of the calendar gRPC API, defaults to grpc.BindAddr of the config")
+	timeout := fs.Duration("timeout", 10*time.Second, "Timeout of the command")
+	_ = fs.Parse(args)
+	if fs.NArg() == 0 {
+		log.Fatal(eventscli.Usage)
+	}
+
+	if *addr == "" {
+		*addr = localAddr(loadAPIConfig().GRPC.BindAddr)
+	}
+	if err := runEvents(*addr, *timeout, fs.Args()); err != nil {
+		log.Fatal(err.Error())
+	}
+}
+
+func runEvents(addr string, timeout time.Duration, args []string) error {
+	conn, err := eventscli.Dial(addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return eventscli.New(pb.NewEventServiceClient(conn), os.Stdout).Run(ctx, args)
+}
+
+func loadAPIConfig() *conf.APIConfig {
+	config := conf.NewConfig()
+	if err := config.Load(configPath("/etc/calendar/config.toml"), nil); err != nil {
+		log.Fatal("failed to load config: " + err.Error())
+	}
+	return &config
+}
+
+// localAddr turns a bind address into one to connect to, the server binding
+// to all interfaces is reached through the local one.
+func localAddr(bindAddr string) string {
+	if strings.HasPrefix(bindAddr, ":") {
+		return "localhost" + bindAddr
+	}
+	return bindAddr
+}
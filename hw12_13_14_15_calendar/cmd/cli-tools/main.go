@@ -1,58 +1,46 @@
 package main
 
 import (
-	"context"
 	"flag"
 	"log"
-	"time"
 
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
-	sqlstorage "github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/sql"
 )
 
 var configFile string
 
 func init() {
-	flag.StringVar(&configFile, "config", "/etc/calendar/config.toml", "Path to configuration file")
+	flag.StringVar(&configFile, "config", "", "Path to configuration file "+
+		"(default /etc/calendar/config.toml, /etc/calendar/sender_config.toml for dlq)")
 }
 
 func main() {
 	flag.Parse()
-	config := conf.NewConfig()
-	if err := config.LoadFromFile(configFile); err != nil {
-		log.Fatal("failed to load config: " + err.Error())
-	}
-
-	if flag.Arg(0) == "migrate" {
-		migrate(&config)
-	}
-}
 
-func migrate(config *conf.APIConfig) {
-	if config.Storage.Type != "sql" {
-		log.Fatal("migrate is only supported for sql storage")
-	}
-	log.Println("Migration started")
-	ctx := context.Background()
-	s := sqlstorage.New(config.Storage.DSN)
-	defer func() {
-		if err := s.Close(ctx); err != nil {
-			log.Fatal("failed to close storage: " + err.Error())
+	switch flag.Arg(0) {
+	case "migrate":
+		migrations(flag.Args()[1:])
+	case "dlq":
+		config := conf.NewSenderConfig()
+		if err := config.Load(configPath("/etc/calendar/sender_config.toml"), nil); err != nil {
+			log.Fatal("failed to load config: " + err.Error())
 		}
-	}()
-	if err := s.Connect(ctx); err != nil {
-		log.Printf("failed to connect to storage: %s\n", err)
-		return
-	}
-	migrationCallback := func(_ int32, name, direction, sql string) {
-		log.Printf(
-			"%s executing %s %s\n%s\n\n", time.Now().Format("2006-01-02 15:04:05"), name, direction, sql,
-		)
+		deadLetters(&config.AMQP, flag.Args()[1:])
+	case "events":
+		events(flag.Args()[1:])
+	case "seed":
+		seed(flag.Args()[1:])
+	case "load":
+		load(flag.Args()[1:])
+	default:
+		log.Fatal("usage: cli-tools [-config path] migrate [...] | dlq [...] | events [...] | seed [...] | load [...], " +
+			"see migrate -help, dlq, events, seed -help and load -help")
 	}
+}
 
-	if err := s.Migrate(ctx, migrationCallback); err != nil {
-		log.Printf("failed to migrate: %s\n", err)
-		return
+func configPath(defaultPath string) string {
+	if configFile == "" {
+		return defaultPath
 	}
-	log.Println("Migration finished")
+	return configFile
 }
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
+	sqlstorage "github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/sql"
+)
+
+const migrateUsage = `usage: cli-tools [-config path] migrate [-dry-run] [-dir path] [command]
+
+commands:
+  up [N]         apply N migrations, all pending ones by default (the default command)
+  down N         revert N migrations
+  goto VERSION   migrate up or down to VERSION, 0 reverts everything
+  status         show the current and the available versions
+  create NAME    scaffold the next migration file in -dir
+  validate       check the migration files in -dir, the built-in ones by default
+`
+
+// migrations manages the database schema. Status, up, down and goto connect to the database,
+// create and validate work with the files only.
+func migrations(args []string) {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, migrateUsage) }
+	dryRun := fs.Bool("dry-run", false, "print the SQL without executing it")
+	dir := fs.String("dir", "", "migrations directory for create and validate")
+	_ = fs.Parse(args)
+
+	command, rest := "up", []string(nil)
+	if fs.NArg() > 0 {
+		command, rest = fs.Arg(0), fs.Args()[1:]
+	}
+	ctx := context.Background()
+
+	switch command {
+	case "create":
+		if len(rest) != 1 {
+			log.Fatal("usage: migrate [-dir path] create NAME")
+		}
+		if *dir == "" {
+			*dir = "assets/migrations"
+		}
+		path, err := sqlstorage.CreateMigration(*dir, rest[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("created %s\n", path)
+		return
+	case "validate":
+		validateMigrations(ctx, *dir)
+		return
+	}
+
+	s := connectStorage(ctx)
+	defer func() {
+		if err := s.Close(ctx); err != nil {
+			log.Println("failed to close storage: " + err.Error())
+		}
+	}()
+
+	current, available, err := s.MigrationStatus(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var target int32
+	switch command {
+	case "status":
+		printMigrationStatus(current, available)
+		return
+	case "up":
+		target = int32(len(available))
+		if len(rest) > 0 {
+			target = current + int32(parseNumber(rest[0]))
+		}
+	case "down":
+		if len(rest) != 1 {
+			log.Fatal("usage: migrate down N")
+		}
+		target = current - int32(parseNumber(rest[0]))
+	case "goto":
+		if len(rest) != 1 {
+			log.Fatal("usage: migrate goto VERSION")
+		}
+		target = int32(parseNumber(rest[0]))
+	default:
+		log.Fatal(migrateUsage)
+	}
+
+	if *dryRun {
+		steps, err := s.MigrationPlan(ctx, target)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, step := range steps {
+			fmt.Printf("-- %s %s\n%s\n\n", step.Name, step.Direction, step.SQL)
+		}
+		log.Printf("dry run: %d migrations from version %d to %d\n", len(steps), current, target)
+		return
+	}
+
+	log.Printf("Migration from version %d to %d started\n", current, target)
+	migrationCallback := func(_ int32, name, direction, sql string) {
+		log.Printf(
+			"%s executing %s %s\n%s\n\n", time.Now().Format("2006-01-02 15:04:05"), name, direction, sql,
+		)
+	}
+	if err := s.MigrateTo(ctx, target, migrationCallback); err != nil {
+		log.Printf("failed to migrate: %s\n", err)
+		return
+	}
+	log.Println("Migration finished")
+}
+
+func connectStorage(ctx context.Context) *sqlstorage.Storage {
+	config := conf.NewConfig()
+	if err := config.Load(configPath("/etc/calendar/config.toml"), nil); err != nil {
+		log.Fatal("failed to load config: " + err.Error())
+	}
+	if config.Storage.Type != "sql" {
+		log.Fatal("migrate is only supported for sql storage")
+	}
+	s := sqlstorage.New(config.Storage.DSN)
+	if err := s.Connect(ctx); err != nil {
+		log.Fatalf("failed to connect to storage: %s\n", err)
+	}
+	return s
+}
+
+func printMigrationStatus(current int32, available []sqlstorage.Migration) {
+	fmt.Printf("current version: %d of %d\n", current, len(available))
+	for _, m := range available {
+		state := "pending"
+		if m.Version <= current {
+			state = "applied"
+		}
+		fmt.Printf("%4d  %-8s %s\n", m.Version, state, m.Name)
+	}
+}
+
+func validateMigrations(ctx context.Context, dir string) {
+	fsys, err := sqlstorage.EmbeddedMigrations()
+	if dir != "" {
+		fsys, err = os.DirFS(dir), nil
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	migrations, err := sqlstorage.ValidateMigrations(ctx, fsys)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("%d migrations are valid\n", len(migrations))
+}
+
+func parseNumber(s string) int {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		log.Fatalf("expected a non-negative number, got %q", s)
+	}
+	return n
+}
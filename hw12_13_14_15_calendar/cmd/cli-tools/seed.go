@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/gen/events/pb"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/eventscli"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/loadgen"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage"
+)
+
+// seed fills the calendar with synthetic users and events, directly into the storage
+// of the config or through the gRPC API.
+func seed(args []string) {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	cfg := loadgen.SeedConfig{From: time.Now()}
+	fs.IntVar(&cfg.Users, "users", 100, "Number of users")
+	fs.IntVar(&cfg.Events, "events", 10000, "Number of events")
+	fs.StringVar(&cfg.Distribution, "distribution", loadgen.DistributionUniform,
+		"Distribution of the events among the users: uniform or zipf")
+	fs.IntVar(&cfg.Days, "days", 90, "Events start within this number of days from today")
+	fs.BoolVar(&cfg.WorkHours, "work-hours", false, "Place the events on weekdays between 9:00 and 18:00")
+	fs.Float64Var(&cfg.RecurringRate, "recurring", 0.1, "Share of the events that are weekly series")
+	fs.IntVar(&cfg.Occurrences, "occurrences", 8, "Number of events in a weekly series")
+	fs.Float64Var(&cfg.OverlapRate, "overlap", 0.05, "Share of the events overlapping the previous event of the user")
+	fs.Float64Var(&cfg.ReminderRate, "reminders", 0.5, "Share of the events having a reminder")
+	fs.Int64Var(&cfg.Seed, "seed", 1, "Seed of the generator, the same seed produces the same events")
+	api := fs.Bool("api", false, "Create the events through the gRPC API instead of the storage")
+	addr := fs.String("addr", "", "Address of the calendar gRPC API, defaults to grpc.BindAddr of the config")
+	_ = fs.Parse(args)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	config := loadAPIConfig()
+
+	var sink loadgen.Sink
+	if *api {
+		if *addr == "" {
+			*addr = localAddr(config.GRPC.BindAddr)
+		}
+		conn, err := eventscli.Dial(*addr)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		defer conn.Close()
+		sink = loadgen.NewAPISink(pb.NewEventServiceClient(conn))
+	} else {
+		s, closeStorage, err := storage.NewFromConfig(&config.Storage)
+		if err != nil {
+			log.Fatal("failed to create storage: " + err.Error())
+		}
+		if closeStorage != nil {
+			defer func() {
+				if err := closeStorage(ctx); err != nil {
+					log.Println("failed to close storage: " + err.Error())
+				}
+			}()
+		}
+		sink = s
+	}
+
+	started := time.Now()
+	n, err := loadgen.Seed(ctx, cfg, sink, func(n int) {
+		log.Printf("created %d of %d events\n", n, cfg.Events)
+	})
+	if err != nil {
+		log.Println("failed to seed: " + err.Error())
+	}
+	log.Printf("created %d events in %s\n", n, time.Since(started).Round(time.Millisecond))
+}
+
+// load sends a mix of gRPC and HTTP requests at the target rate and reports latency percentiles.
+func load(args []string) {
+	fs := flag.NewFlagSet("load", flag.ExitOnError)
+	cfg := loadgen.LoadConfig{From: time.Now()}
+	fs.IntVar(&cfg.RPS, "rps", 100, "Target number of requests per second")
+	fs.DurationVar(&cfg.Duration, "duration", 30*time.Second, "Duration of the test")
+	fs.IntVar(&cfg.Workers, "workers", 16, "Maximum number of concurrent requests")
+	mix := fs.String("mix", loadgen.DefaultMix, "Weights of the operations as op=weight,..., operations: "+
+		"grpc.create, grpc.get, grpc.day, grpc.week, grpc.month, http.get, http.day, http.week, http.month")
+	fs.IntVar(&cfg.Days, "days", 90, "Queried dates are within this number of days from today, as seeded")
+	fs.IntVar(&cfg.Users, "users", 100, "Number of users owning the created events")
+	fs.Int64Var(&cfg.Seed, "seed", 1, "Seed of the generator")
+	grpcAddr := fs.String("addr", "", "Address of the calendar gRPC API, defaults to grpc.BindAddr of the config")
+	httpAddr := fs.String("http", "", "Base URL of the HTTP API, defaults to http.BindAddr of the config")
+	_ = fs.Parse(args)
+
+	var err error
+	if cfg.Mix, err = loadgen.ParseMix(*mix); err != nil {
+		log.Fatal(err.Error())
+	}
+	if *grpcAddr == "" || *httpAddr == "" {
+		config := loadAPIConfig()
+		if *grpcAddr == "" {
+			*grpcAddr = localAddr(config.GRPC.BindAddr)
+		}
+		if *httpAddr == "" {
+			*httpAddr = "http://" + localAddr(config.HTTP.BindAddr)
+		}
+	}
+	conn, err := eventscli.Dial(*grpcAddr)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	defer conn.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	log.Printf("sending %d rps for %s\n", cfg.RPS, cfg.Duration)
+	report, err := loadgen.Load(ctx, cfg, loadgen.Target{GRPC: pb.NewEventServiceClient(conn), HTTP: *httpAddr})
+	if err != nil {
+		log.Println("failed to run load test: " + err.Error())
+		return
+	}
+	if err := report.Write(os.Stdout); err != nil {
+		log.Println("failed to write report: " + err.Error())
+	}
+}
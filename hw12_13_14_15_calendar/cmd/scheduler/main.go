@@ -6,39 +6,66 @@ import (
 	"fmt"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/logger"
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/scheduler"
 	_ "github.com/streadway/amqp"
 )
 
-var configFile string
+var (
+	configFile  string
+	watchConfig time.Duration
+	config      = conf.NewSchedulerConfig()
+	overrides   conf.Overrides
+)
 
 func init() {
-	flag.StringVar(&configFile, "config", "/etc/calendar/scheduler_config.toml", "Path to configuration file")
+	flag.StringVar(&configFile, "config", "/etc/calendar/scheduler_config.toml",
+		"Path to configuration file, empty to configure by environment variables and flags only")
+	flag.DurationVar(&watchConfig, "watch-config", 0,
+		"How often to check the configuration file for changes, 0 reloads it on SIGHUP only")
+	overrides = conf.RegisterFlags(flag.CommandLine, &config)
 }
 
 func main() {
 	flag.Parse()
-	config := conf.NewSchedulerConfig()
-	if err := config.LoadFromFile(configFile); err != nil {
+	if err := config.Load(configFile, overrides); err != nil {
 		fmt.Printf("failed to load config: %s", err)
 		return
 	}
 
-	app := scheduler.New(&config)
+	// create logger
+	logg, err := logger.NewFromConfig(&config.Logger)
+	if err != nil {
+		fmt.Println("failed to create logger: " + err.Error())
+		return
+	}
+	defer logg.Close()
+
+	app := scheduler.New(logg, &config)
 	// create context
 	ctx, cancel := signal.NotifyContext(context.Background(),
-		syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+		syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
+
+	// reload the config on SIGHUP
+	reloader := conf.NewSchedulerReloader(&config, configFile, overrides)
+	reloader.Subscribe(app.Reload)
+	reloader.Start(ctx, watchConfig, func(err error) {
+		if err != nil {
+			logg.Error(err.Error())
+		}
+	})
 	go func() {
 		<-ctx.Done()
 		if err := app.Stop(); err != nil {
-			fmt.Printf("failed to stop app: %s", err)
+			logg.Error("failed to stop app: " + err.Error())
 		}
 	}()
 	if err := app.Run(ctx); err != nil {
-		fmt.Printf("failed to run app: %s", err)
+		logg.Error("failed to run app: " + err.Error())
 		return
 	}
 }
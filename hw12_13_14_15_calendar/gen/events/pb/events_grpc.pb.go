@@ -25,9 +25,12 @@ type EventServiceClient interface {
 	CreateEvent(ctx context.Context, in *CreateEventRequest, opts ...grpc.CallOption) (*CreateEventResponse, error)
 	UpdateEvent(ctx context.Context, in *UpdateEventRequest, opts ...grpc.CallOption) (*UpdateEventResponse, error)
 	RemoveEvent(ctx context.Context, in *RemoveEventRequest, opts ...grpc.CallOption) (*RemoveEventResponse, error)
+	GetEvent(ctx context.Context, in *GetEventRequest, opts ...grpc.CallOption) (*GetEventResponse, error)
 	FilterEventsByDay(ctx context.Context, in *FilterEventsByDayRequest, opts ...grpc.CallOption) (*FilterEventsByDayResponse, error)
 	FilterEventsByWeek(ctx context.Context, in *FilterEventsByWeekRequest, opts ...grpc.CallOption) (*FilterEventsByWeekResponse, error)
 	FilterEventsByMonth(ctx context.Context, in *FilterEventsByMonthRequest, opts ...grpc.CallOption) (*FilterEventsByMonthResponse, error)
+	GetNotificationStatus(ctx context.Context, in *GetNotificationStatusRequest, opts ...grpc.CallOption) (*GetNotificationStatusResponse, error)
+	ListNotificationStatuses(ctx context.Context, in *ListNotificationStatusesRequest, opts ...grpc.CallOption) (*ListNotificationStatusesResponse, error)
 }
 
 type eventServiceClient struct {
@@ -65,6 +68,15 @@ func (c *eventServiceClient) RemoveEvent(ctx context.Context, in *RemoveEventReq
 	return out, nil
 }
 
+func (c *eventServiceClient) GetEvent(ctx context.Context, in *GetEventRequest, opts ...grpc.CallOption) (*GetEventResponse, error) {
+	out := new(GetEventResponse)
+	err := c.cc.Invoke(ctx, "/api.events.v1.EventService/GetEvent", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *eventServiceClient) FilterEventsByDay(ctx context.Context, in *FilterEventsByDayRequest, opts ...grpc.CallOption) (*FilterEventsByDayResponse, error) {
 	out := new(FilterEventsByDayResponse)
 	err := c.cc.Invoke(ctx, "/api.events.v1.EventService/FilterEventsByDay", in, out, opts...)
@@ -92,6 +104,24 @@ func (c *eventServiceClient) FilterEventsByMonth(ctx context.Context, in *Filter
 	return out, nil
 }
 
+func (c *eventServiceClient) GetNotificationStatus(ctx context.Context, in *GetNotificationStatusRequest, opts ...grpc.CallOption) (*GetNotificationStatusResponse, error) {
+	out := new(GetNotificationStatusResponse)
+	err := c.cc.Invoke(ctx, "/api.events.v1.EventService/GetNotificationStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) ListNotificationStatuses(ctx context.Context, in *ListNotificationStatusesRequest, opts ...grpc.CallOption) (*ListNotificationStatusesResponse, error) {
+	out := new(ListNotificationStatusesResponse)
+	err := c.cc.Invoke(ctx, "/api.events.v1.EventService/ListNotificationStatuses", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // EventServiceServer is the server API for EventService service.
 // All implementations must embed UnimplementedEventServiceServer
 // for forward compatibility
@@ -99,9 +129,12 @@ type EventServiceServer interface {
 	CreateEvent(context.Context, *CreateEventRequest) (*CreateEventResponse, error)
 	UpdateEvent(context.Context, *UpdateEventRequest) (*UpdateEventResponse, error)
 	RemoveEvent(context.Context, *RemoveEventRequest) (*RemoveEventResponse, error)
+	GetEvent(context.Context, *GetEventRequest) (*GetEventResponse, error)
 	FilterEventsByDay(context.Context, *FilterEventsByDayRequest) (*FilterEventsByDayResponse, error)
 	FilterEventsByWeek(context.Context, *FilterEventsByWeekRequest) (*FilterEventsByWeekResponse, error)
 	FilterEventsByMonth(context.Context, *FilterEventsByMonthRequest) (*FilterEventsByMonthResponse, error)
+	GetNotificationStatus(context.Context, *GetNotificationStatusRequest) (*GetNotificationStatusResponse, error)
+	ListNotificationStatuses(context.Context, *ListNotificationStatusesRequest) (*ListNotificationStatusesResponse, error)
 	mustEmbedUnimplementedEventServiceServer()
 }
 
@@ -118,6 +151,9 @@ func (UnimplementedEventServiceServer) UpdateEvent(context.Context, *UpdateEvent
 func (UnimplementedEventServiceServer) RemoveEvent(context.Context, *RemoveEventRequest) (*RemoveEventResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RemoveEvent not implemented")
 }
+func (UnimplementedEventServiceServer) GetEvent(context.Context, *GetEventRequest) (*GetEventResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEvent not implemented")
+}
 func (UnimplementedEventServiceServer) FilterEventsByDay(context.Context, *FilterEventsByDayRequest) (*FilterEventsByDayResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method FilterEventsByDay not implemented")
 }
@@ -127,6 +163,12 @@ func (UnimplementedEventServiceServer) FilterEventsByWeek(context.Context, *Filt
 func (UnimplementedEventServiceServer) FilterEventsByMonth(context.Context, *FilterEventsByMonthRequest) (*FilterEventsByMonthResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method FilterEventsByMonth not implemented")
 }
+func (UnimplementedEventServiceServer) GetNotificationStatus(context.Context, *GetNotificationStatusRequest) (*GetNotificationStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNotificationStatus not implemented")
+}
+func (UnimplementedEventServiceServer) ListNotificationStatuses(context.Context, *ListNotificationStatusesRequest) (*ListNotificationStatusesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListNotificationStatuses not implemented")
+}
 func (UnimplementedEventServiceServer) mustEmbedUnimplementedEventServiceServer() {}
 
 // UnsafeEventServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -194,6 +236,24 @@ func _EventService_RemoveEvent_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _EventService_GetEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).GetEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.events.v1.EventService/GetEvent",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).GetEvent(ctx, req.(*GetEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _EventService_FilterEventsByDay_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(FilterEventsByDayRequest)
 	if err := dec(in); err != nil {
@@ -248,6 +308,42 @@ func _EventService_FilterEventsByMonth_Handler(srv interface{}, ctx context.Cont
 	return interceptor(ctx, in, info, handler)
 }
 
+func _EventService_GetNotificationStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetNotificationStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).GetNotificationStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.events.v1.EventService/GetNotificationStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).GetNotificationStatus(ctx, req.(*GetNotificationStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_ListNotificationStatuses_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListNotificationStatusesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).ListNotificationStatuses(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.events.v1.EventService/ListNotificationStatuses",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).ListNotificationStatuses(ctx, req.(*ListNotificationStatusesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // EventService_ServiceDesc is the grpc.ServiceDesc for EventService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -267,6 +363,10 @@ var EventService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "RemoveEvent",
 			Handler:    _EventService_RemoveEvent_Handler,
 		},
+		{
+			MethodName: "GetEvent",
+			Handler:    _EventService_GetEvent_Handler,
+		},
 		{
 			MethodName: "FilterEventsByDay",
 			Handler:    _EventService_FilterEventsByDay_Handler,
@@ -279,6 +379,14 @@ var EventService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "FilterEventsByMonth",
 			Handler:    _EventService_FilterEventsByMonth_Handler,
 		},
+		{
+			MethodName: "GetNotificationStatus",
+			Handler:    _EventService_GetNotificationStatus_Handler,
+		},
+		{
+			MethodName: "ListNotificationStatuses",
+			Handler:    _EventService_ListNotificationStatuses_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "events/events.proto",
@@ -7,6 +7,17 @@ import (
 	"github.com/streadway/amqp"
 )
 
+const defaultExchangeType = "direct"
+
+// exchangeType returns the configured type of the exchange, direct when it is not set.
+func exchangeType(config *conf.AMQPConfig) string {
+	if config.ExchangeType == "" {
+		return defaultExchangeType
+	}
+	return config.ExchangeType
+}
+
+// NewChannel connects to the broker and declares the exchange of the configured type.
 func NewChannel(config *conf.AMQPConfig) (*amqp.Connection, *amqp.Channel, error) {
 	connection, err := amqp.Dial(config.URI)
 	if err != nil {
@@ -20,7 +31,7 @@ func NewChannel(config *conf.AMQPConfig) (*amqp.Connection, *amqp.Channel, error
 
 	if err = channel.ExchangeDeclare(
 		config.Exchange,
-		"direct",
+		exchangeType(config),
 		true,  // durable
 		false, // auto-deleted
 		false, // internal
@@ -32,3 +43,26 @@ func NewChannel(config *conf.AMQPConfig) (*amqp.Connection, *amqp.Channel, error
 
 	return connection, channel, nil
 }
+
+// table converts message headers to an AMQP table, nil stays nil.
+func table(headers map[string]string) amqp.Table {
+	if headers == nil {
+		return nil
+	}
+	t := make(amqp.Table, len(headers))
+	for k, v := range headers {
+		t[k] = v
+	}
+	return t
+}
+
+// stringHeaders returns the headers with string values, the others are used by the retry topology.
+func stringHeaders(t amqp.Table) map[string]string {
+	headers := make(map[string]string, len(t))
+	for k, v := range t {
+		if s, ok := v.(string); ok {
+			headers[k] = s
+		}
+	}
+	return headers
+}
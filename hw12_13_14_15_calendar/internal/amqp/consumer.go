@@ -2,113 +2,296 @@ package amqp
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
-	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/logger"
 	"github.com/streadway/amqp"
 )
 
-type ConsumeHandler func([]byte) error
+// Message is a delivery passed to the ConsumeHandler.
+type Message struct {
+	ID          string
+	ContentType string
+	Body        []byte
+	Headers     map[string]string // the string headers, e.g. the trace context
+	Redelivered bool
+	Attempt     int
+	MaxAttempts int
+}
+
+// FinalAttempt reports whether the message is dead-lettered if the handler fails.
+func (m Message) FinalAttempt() bool {
+	return m.Attempt >= m.MaxAttempts
+}
+
+// deadLetter reports whether the message failed with the error is dead-lettered rather than retried.
+func (m Message) deadLetter(err error) bool {
+	return m.FinalAttempt() || errors.Is(err, ErrPermanent)
+}
+
+type ConsumeHandler func(Message) error
+
+// publisher publishes the failed messages for a retry, it is implemented by *amqp.Channel.
+type publisher interface {
+	Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+}
 
+// Consumer passes messages from the configured queue to the handler. It reconnects and
+// resumes consuming when the broker goes away.
 type Consumer struct {
-	conn    *amqp.Connection
+	session *session
 	tag     string
-	channel *amqp.Channel
 	handler ConsumeHandler
 	config  *conf.AMQPConfig
-	logger  *logger.Logger
+	policy  RetryPolicy
+	logger  Logger
+	// rescheduleFailures counts the messages in a row that could not be rescheduled,
+	// the consumer backs off before requeueing them, so they are not redelivered in a hot loop
+	rescheduleFailures int
+	// the channel is in confirm mode, a failed delivery is acknowledged only after the broker
+	// has confirmed its copy published for a retry, publishTag is the delivery tag of the last copy
+	confirmed  *amqp.Channel
+	confirms   <-chan amqp.Confirmation
+	publishTag uint64
 }
 
-func NewConsumer(logger *logger.Logger, config *conf.AMQPConfig, handler ConsumeHandler) (*Consumer, error) {
+func NewConsumer(logger Logger, config *conf.AMQPConfig, handler ConsumeHandler) (*Consumer, error) {
 	c := &Consumer{
 		config:  config,
+		policy:  NewRetryPolicy(&config.Retry),
 		tag:     "consumer",
 		logger:  logger,
-		conn:    nil,
-		channel: nil,
 		handler: handler,
 	}
 
-	var err error
-
-	c.conn, c.channel, err = NewChannel(config)
+	s, err := newSession(config, logger, c.declare)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create amqp channel: %w", err)
 	}
+	c.session = s
+	return c, nil
+}
 
-	logger.Info(fmt.Sprintf("declared Exchange, declaring Queue %q", config.Queue))
-	queue, err := c.channel.QueueDeclare(
-		config.Queue, // name of the queue
-		true,         // durable
-		false,        // delete when unused
-		false,        // exclusive
-		false,        // noWait
-		nil,          // arguments
+// declare declares the queue, its binding and the retry topology, it runs on every (re)connection.
+func (c *Consumer) declare(channel *amqp.Channel) error {
+	ctx := context.Background()
+	c.logger.InfoContext(ctx, "declared exchange, declaring queue", "queue", c.config.Queue)
+	queue, err := channel.QueueDeclare(
+		c.config.Queue, // name of the queue
+		true,           // durable
+		false,          // delete when unused
+		false,          // exclusive
+		false,          // noWait
+		nil,            // arguments
 	)
 	if err != nil {
-		return nil, fmt.Errorf("queue Declare: %w", err)
+		return fmt.Errorf("queue Declare: %w", err)
 	}
 
-	logger.Info(fmt.Sprintf("declared Queue (%q %d messages, %d consumers), binding to Exchange (key %q)",
-		queue.Name, queue.Messages, queue.Consumers, config.RoutingKey))
+	c.logger.InfoContext(ctx, "declared queue, binding to exchange", "queue", queue.Name,
+		"messages", queue.Messages, "consumers", queue.Consumers, "routing_key", c.config.RoutingKey)
 
-	if err = c.channel.QueueBind(
-		queue.Name,        // name of the queue
-		config.RoutingKey, // bindingKey
-		config.Exchange,   // sourceExchange
-		false,             // noWait
-		nil,               // arguments
+	if err = channel.QueueBind(
+		queue.Name,          // name of the queue
+		c.config.RoutingKey, // bindingKey
+		c.config.Exchange,   // sourceExchange
+		false,               // noWait
+		nil,                 // arguments
 	); err != nil {
-		return nil, fmt.Errorf("queue Bind: %w", err)
+		return fmt.Errorf("queue Bind: %w", err)
 	}
-	return c, nil
+
+	return declareRetryTopology(channel, c.config, c.policy)
 }
 
+// Consume handles messages until ctx is done or the consumer is stopped.
+// When the connection is lost it waits for the session to reconnect and starts over.
 func (c *Consumer) Consume(ctx context.Context) error {
-	deliveries, err := c.channel.Consume(
-		c.config.Queue, // queue
-		c.tag,          // consumer
-		false,          // auto-ack
-		false,          // exclusive
-		false,          // no-local
-		false,          // no-wait
-		nil,            // args
-	)
-	if err != nil {
-		return fmt.Errorf("queue Consume: %w", err)
+	var deliveries <-chan amqp.Delivery
+	for {
+		// stopping or canceling the consumer ends it without an error
+		if c.session.waitConnected(ctx) != nil {
+			return nil
+		}
+		channel, err := c.session.Channel()
+		if err == nil {
+			err = c.confirm(channel)
+		}
+		if err == nil {
+			deliveries, err = channel.Consume(
+				c.config.Queue, // queue
+				c.tag,          // consumer
+				false,          // auto-ack
+				false,          // exclusive
+				false,          // no-local
+				false,          // no-wait
+				nil,            // args
+			)
+		}
+		if err != nil {
+			// the channel may have been closed before the session noticed, try again shortly
+			c.logger.ErrorContext(ctx, "failed to consume queue", "queue", c.config.Queue, "error", err)
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(minReconnectDelay):
+			}
+			continue
+		}
+		c.logger.InfoContext(ctx, "start consuming", "queue", c.config.Queue)
+
+		if done := c.consume(ctx, channel, deliveries); done {
+			return nil
+		}
+		c.logger.InfoContext(ctx, "delivery channel closed, waiting for the connection to be restored")
 	}
-	c.logger.Info("start consuming")
+}
 
+// confirm puts a new channel into confirm mode.
+func (c *Consumer) confirm(channel *amqp.Channel) error {
+	if channel == c.confirmed {
+		return nil
+	}
+	if err := channel.Confirm(false); err != nil {
+		return fmt.Errorf("channel Confirm: %w", err)
+	}
+	c.confirms = channel.NotifyPublish(make(chan amqp.Confirmation, confirmEventsSize))
+	c.confirmed, c.publishTag = channel, 0
+	return nil
+}
+
+// consume handles deliveries until the channel is closed, it reports whether to stop consuming.
+func (c *Consumer) consume(ctx context.Context, channel *amqp.Channel, deliveries <-chan amqp.Delivery) bool {
 	for {
 		select {
 		case <-ctx.Done():
-			return nil
+			return true
 		case d, ok := <-deliveries:
 			if !ok {
-				return nil
+				return c.session.State() == StateClosed
 			}
-			err := c.handler(d.Body)
-			if err != nil {
-				c.logger.Error(fmt.Sprintf("failed to handle message: %s", err))
-			}
-			if err = d.Ack(false); err != nil {
-				c.logger.Error(fmt.Sprintf("failed to acknowledge message: %s", err))
+			if stop := c.handle(ctx, channel, d); stop {
+				return true
 			}
 		}
 	}
 }
 
-func (c *Consumer) Stop() error {
-	if c.channel != nil {
-		if err := c.channel.Cancel(c.tag, true); err != nil {
-			return fmt.Errorf("failed to cancel consumer: %w", err)
+// handle passes the delivery to the handler. A failed message is published to the delay queue
+// for the next attempt or, after the final attempt or a permanent failure, to the dead-letter exchange,
+// and acknowledged once the broker confirms the copy. If that fails too, the message is requeued
+// after a growing pause. It reports whether ctx was done during the pause.
+func (c *Consumer) handle(ctx context.Context, channel publisher, d amqp.Delivery) bool {
+	msg := Message{
+		ID:          d.MessageId,
+		ContentType: d.ContentType,
+		Body:        d.Body,
+		Headers:     stringHeaders(d.Headers),
+		Redelivered: d.Redelivered,
+		Attempt:     attempt(d.Headers),
+		MaxAttempts: c.policy.MaxAttempts,
+	}
+	result := consumeOK
+	if handleErr := c.handler(msg); handleErr != nil {
+		c.logger.ErrorContext(ctx, "failed to handle message", "message_id", msg.ID,
+			"attempt", msg.Attempt, "max_attempts", msg.MaxAttempts, "error", handleErr)
+		if err := c.retry(ctx, channel, d, msg, handleErr); err != nil {
+			// keep the message in the queue rather than lose it
+			consumedTotal.WithLabelValues(consumeRequeued).Inc()
+			c.rescheduleFailures++
+			delay := c.policy.Delay(c.rescheduleFailures)
+			c.logger.ErrorContext(ctx, "failed to reschedule message, requeueing it", "message_id", msg.ID, "delay", delay, "error", err)
+			stop := false
+			select {
+			case <-ctx.Done():
+				stop = true
+			case <-time.After(delay):
+			}
+			if err = d.Nack(false, true); err != nil {
+				c.logger.ErrorContext(ctx, "failed to requeue message", "message_id", msg.ID, "error", err)
+			}
+			return stop
+		}
+		c.rescheduleFailures = 0
+		result = consumeRetried
+		if msg.deadLetter(handleErr) {
+			result = consumeDeadLettered
 		}
 	}
-	if c.conn != nil {
-		if err := c.conn.Close(); err != nil {
-			return fmt.Errorf("failed to close connection: %w", err)
+	consumedTotal.WithLabelValues(result).Inc()
+	if err := d.Ack(false); err != nil {
+		c.logger.ErrorContext(ctx, "failed to acknowledge message", "message_id", msg.ID, "error", err)
+	}
+	return false
+}
+
+func (c *Consumer) retry(ctx context.Context, channel publisher, d amqp.Delivery, msg Message, handleErr error) error {
+	if msg.deadLetter(handleErr) {
+		c.logger.InfoContext(ctx, "dead-lettering message", "message_id", msg.ID, "attempt", msg.Attempt)
+		return c.publish(ctx, channel,
+			DeadLetterExchangeName(c.config),
+			d.RoutingKey,
+			republishing(d, amqp.Table{
+				AttemptHeader:    int32(msg.Attempt),
+				ErrorHeader:      handleErr.Error(),
+				RoutingKeyHeader: d.RoutingKey,
+			}),
+		)
+	}
+	delay := c.policy.Delay(msg.Attempt)
+	c.logger.InfoContext(ctx, "retrying message", "message_id", msg.ID, "attempt", msg.Attempt, "delay", delay)
+	return c.publish(ctx, channel,
+		"", // the default exchange routes by queue name
+		DelayQueueName(c.config, delay),
+		republishing(d, amqp.Table{
+			AttemptHeader: int32(msg.Attempt + 1),
+			ErrorHeader:   handleErr.Error(),
+		}),
+	)
+}
+
+// publish publishes the copy of a failed message and waits for the broker to confirm it.
+func (c *Consumer) publish(ctx context.Context, channel publisher, exchange, key string, msg amqp.Publishing) error {
+	if err := channel.Publish(exchange, key, false, false, msg); err != nil {
+		return err
+	}
+	c.publishTag++
+	return c.waitConfirm(ctx, c.publishTag)
+}
+
+// waitConfirm waits for the confirmation with the delivery tag, earlier ones are late
+// confirmations of copies that have timed out.
+func (c *Consumer) waitConfirm(ctx context.Context, tag uint64) error {
+	timer := time.NewTimer(confirmTimeout(c.config))
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			return ErrConfirmTimeout
+		case confirm, ok := <-c.confirms:
+			switch {
+			case !ok:
+				return ErrDisconnected
+			case confirm.DeliveryTag < tag:
+			case !confirm.Ack:
+				return ErrNacked
+			default:
+				return nil
+			}
 		}
 	}
-	return nil
+}
+
+// State returns the state of the connection to the broker.
+func (c *Consumer) State() State {
+	return c.session.State()
+}
+
+// Stop closes the connection, which cancels the consumer and ends Consume.
+func (c *Consumer) Stop() error {
+	return c.session.Close()
 }
@@ -0,0 +1,138 @@
+package amqp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/logger"
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/require"
+)
+
+type failingPublisher struct{}
+
+func (failingPublisher) Publish(string, string, bool, bool, amqp.Publishing) error {
+	return errors.New("channel is blocked")
+}
+
+// recordingPublisher records the exchanges and the routing keys of the published messages,
+// the broker confirms them unless nack is set.
+type recordingPublisher struct {
+	published []string
+	confirms  chan amqp.Confirmation
+	nack      bool
+}
+
+func newRecordingPublisher() *recordingPublisher {
+	return &recordingPublisher{confirms: make(chan amqp.Confirmation, 10)}
+}
+
+func (p *recordingPublisher) Publish(exchange, key string, _, _ bool, _ amqp.Publishing) error {
+	p.published = append(p.published, exchange+"/"+key)
+	p.confirms <- amqp.Confirmation{DeliveryTag: uint64(len(p.published)), Ack: !p.nack}
+	return nil
+}
+
+// recordingAcknowledger records when the delivery is acknowledged or requeued.
+type recordingAcknowledger struct {
+	acked      int
+	requeuedAt []time.Time
+}
+
+func (a *recordingAcknowledger) Ack(uint64, bool) error {
+	a.acked++
+	return nil
+}
+
+func (a *recordingAcknowledger) Nack(_ uint64, _ bool, requeue bool) error {
+	if requeue {
+		a.requeuedAt = append(a.requeuedAt, time.Now())
+	}
+	return nil
+}
+
+func (a *recordingAcknowledger) Reject(uint64, bool) error {
+	return nil
+}
+
+func TestHandleBacksOffWhenRescheduleFails(t *testing.T) {
+	logg, err := logger.New("ERROR")
+	require.NoError(t, err)
+	c := &Consumer{
+		config:  &conf.AMQPConfig{Queue: "notifications"},
+		policy:  RetryPolicy{MaxAttempts: 3, InitialDelay: 20 * time.Millisecond, MaxDelay: time.Second},
+		logger:  logg,
+		handler: func(Message) error { return errors.New("smtp is down") },
+	}
+	ack := &recordingAcknowledger{}
+	d := amqp.Delivery{Acknowledger: ack, MessageId: "1"}
+
+	start := time.Now()
+	require.False(t, c.handle(context.Background(), failingPublisher{}, d))
+	require.False(t, c.handle(context.Background(), failingPublisher{}, d))
+	require.Len(t, ack.requeuedAt, 2)
+	// the pause grows with every failure in a row
+	require.GreaterOrEqual(t, ack.requeuedAt[0].Sub(start), 20*time.Millisecond)
+	require.GreaterOrEqual(t, ack.requeuedAt[1].Sub(ack.requeuedAt[0]), 40*time.Millisecond)
+
+	// stopping the consumer interrupts the pause, the message is requeued anyway
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.True(t, c.handle(ctx, failingPublisher{}, d))
+	require.Len(t, ack.requeuedAt, 3)
+}
+
+func TestHandleDeadLettersPermanentFailures(t *testing.T) {
+	logg, err := logger.New("ERROR")
+	require.NoError(t, err)
+	config := &conf.AMQPConfig{Exchange: "calendar", Queue: "notifications"}
+	c := &Consumer{
+		config: config,
+		policy: RetryPolicy{MaxAttempts: 3, InitialDelay: time.Second, MaxDelay: time.Minute},
+		logger: logg,
+		handler: func(Message) error {
+			return fmt.Errorf("%w: unsupported schema version", ErrPermanent)
+		},
+	}
+	channel := newRecordingPublisher()
+	c.confirms = channel.confirms
+	ack := &recordingAcknowledger{}
+	d := amqp.Delivery{Acknowledger: ack, MessageId: "1", RoutingKey: "notify"}
+
+	require.False(t, c.handle(context.Background(), channel, d))
+	// the first attempt goes to the dead letters, not to a delay queue
+	require.Equal(t, []string{DeadLetterExchangeName(config) + "/notify"}, channel.published)
+	require.Equal(t, 1, ack.acked)
+}
+
+func TestHandleAcknowledgesConfirmedRetries(t *testing.T) {
+	logg, err := logger.New("ERROR")
+	require.NoError(t, err)
+	c := &Consumer{
+		config:  &conf.AMQPConfig{Queue: "notifications"},
+		policy:  RetryPolicy{MaxAttempts: 3, InitialDelay: 10 * time.Millisecond, MaxDelay: time.Second},
+		logger:  logg,
+		handler: func(Message) error { return errors.New("smtp is down") },
+	}
+	channel := newRecordingPublisher()
+	c.confirms = channel.confirms
+	ack := &recordingAcknowledger{}
+	d := amqp.Delivery{Acknowledger: ack, MessageId: "1"}
+
+	// the delivery is acknowledged once the broker has confirmed its copy in the delay queue
+	require.False(t, c.handle(context.Background(), channel, d))
+	require.Len(t, channel.published, 1)
+	require.Equal(t, 1, ack.acked)
+	require.Empty(t, ack.requeuedAt)
+
+	// a copy refused by the broker would be lost, the delivery is requeued instead
+	channel.nack = true
+	require.False(t, c.handle(context.Background(), channel, d))
+	require.Len(t, channel.published, 2)
+	require.Equal(t, 1, ack.acked)
+	require.Len(t, ack.requeuedAt, 1)
+}
@@ -0,0 +1,146 @@
+package amqp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
+	"github.com/streadway/amqp"
+)
+
+// DeadLetter is a message that failed all delivery attempts.
+type DeadLetter struct {
+	ID         string
+	RoutingKey string
+	Attempts   int
+	Error      string
+	Timestamp  time.Time
+	Body       []byte
+}
+
+// DeadLetters inspects and replays the dead-letter queue of the consumer configured by config.
+type DeadLetters struct {
+	conn    *amqp.Connection
+	channel *amqp.Channel
+	config  *conf.AMQPConfig
+}
+
+func NewDeadLetters(config *conf.AMQPConfig) (*DeadLetters, error) {
+	conn, channel, err := NewChannel(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create amqp channel: %w", err)
+	}
+	if err = declareRetryTopology(channel, config, NewRetryPolicy(&config.Retry)); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return &DeadLetters{conn: conn, channel: channel, config: config}, nil
+}
+
+// List returns up to limit dead letters, the messages stay in the queue.
+func (l *DeadLetters) List(limit int) ([]DeadLetter, error) {
+	var letters []DeadLetter
+	err := l.each(func(d amqp.Delivery) (bool, error) {
+		if limit > 0 && len(letters) >= limit {
+			return false, nil
+		}
+		letters = append(letters, newDeadLetter(d))
+		return false, nil
+	})
+	return letters, err
+}
+
+// Replay publishes the dead letters with the given IDs, or all of them if no IDs are given,
+// back to the exchange with a fresh attempt count. It returns the number of replayed messages.
+func (l *DeadLetters) Replay(ids ...string) (int, error) {
+	wanted := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		wanted[id] = struct{}{}
+	}
+	replayed := 0
+	err := l.each(func(d amqp.Delivery) (bool, error) {
+		if _, ok := wanted[d.MessageId]; len(wanted) > 0 && !ok {
+			return false, nil
+		}
+		routingKey := l.config.RoutingKey
+		if key, ok := d.Headers[RoutingKeyHeader].(string); ok && key != "" {
+			routingKey = key
+		}
+		headers := amqp.Table{}
+		for k, v := range d.Headers {
+			if k != AttemptHeader && k != ErrorHeader && k != RoutingKeyHeader {
+				headers[k] = v
+			}
+		}
+		msg := republishing(d, nil)
+		msg.Headers = headers
+		if err := l.channel.Publish(l.config.Exchange, routingKey, false, false, msg); err != nil {
+			return false, fmt.Errorf("failed to publish message: %w", err)
+		}
+		replayed++
+		return true, nil
+	})
+	return replayed, err
+}
+
+// each passes every message currently in the dead-letter queue to fn. Messages for which fn
+// returns true are removed from the queue, the others are returned to it once all are visited.
+func (l *DeadLetters) each(fn func(d amqp.Delivery) (bool, error)) error {
+	queue, err := l.channel.QueueInspect(DeadLetterQueueName(l.config))
+	if err != nil {
+		return fmt.Errorf("failed to inspect dead-letter queue: %w", err)
+	}
+	var kept []amqp.Delivery
+	defer func() {
+		// requeue after the scan, otherwise Get would return the same messages again
+		for _, d := range kept {
+			_ = d.Nack(false, true)
+		}
+	}()
+	for i := 0; i < queue.Messages; i++ {
+		d, ok, err := l.channel.Get(queue.Name, false)
+		if err != nil {
+			return fmt.Errorf("failed to get message: %w", err)
+		}
+		if !ok {
+			break
+		}
+		remove, err := fn(d)
+		if err != nil {
+			kept = append(kept, d)
+			return err
+		}
+		if !remove {
+			kept = append(kept, d)
+			continue
+		}
+		if err = d.Ack(false); err != nil {
+			return fmt.Errorf("failed to acknowledge message: %w", err)
+		}
+	}
+	return nil
+}
+
+func (l *DeadLetters) Close() error {
+	if err := l.conn.Close(); err != nil {
+		return fmt.Errorf("failed to close connection: %w", err)
+	}
+	return nil
+}
+
+func newDeadLetter(d amqp.Delivery) DeadLetter {
+	letter := DeadLetter{
+		ID:         d.MessageId,
+		RoutingKey: d.RoutingKey,
+		Attempts:   attempt(d.Headers),
+		Timestamp:  d.Timestamp,
+		Body:       d.Body,
+	}
+	if key, ok := d.Headers[RoutingKeyHeader].(string); ok {
+		letter.RoutingKey = key
+	}
+	if msg, ok := d.Headers[ErrorHeader].(string); ok {
+		letter.Error = msg
+	}
+	return letter
+}
@@ -0,0 +1,49 @@
+package amqp
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Results of consumed messages.
+const (
+	consumeOK           = "ok"
+	consumeRetried      = "retried"
+	consumeDeadLettered = "dead_lettered"
+	consumeRequeued     = "requeued"
+)
+
+var (
+	publishedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "calendar",
+		Subsystem: "amqp",
+		Name:      "published_total",
+		Help:      "Number of published messages by result: ok, disconnected, nacked, unroutable, timeout or error.",
+	}, []string{"result"})
+	consumedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "calendar",
+		Subsystem: "amqp",
+		Name:      "consumed_total",
+		Help: "Number of consumed messages by result: ok, retried (the handler failed), " +
+			"dead_lettered (the final attempt failed) or requeued (the failure could not be rescheduled).",
+	}, []string{"result"})
+)
+
+func publishResult(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, ErrDisconnected):
+		return "disconnected"
+	case errors.Is(err, ErrNacked):
+		return "nacked"
+	case errors.Is(err, ErrUnroutable):
+		return "unroutable"
+	case errors.Is(err, ErrConfirmTimeout):
+		return "timeout"
+	default:
+		return "error"
+	}
+}
@@ -1,29 +1,173 @@
 package amqp
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
 	"github.com/streadway/amqp"
 )
 
+var (
+	// ErrNacked is returned when the broker has refused to take the message.
+	ErrNacked = errors.New("message was not acknowledged by the broker")
+	// ErrUnroutable is returned when the message matched no queue and was returned by the broker.
+	ErrUnroutable = errors.New("message was not routed to any queue")
+	// ErrConfirmTimeout is returned when the broker has not confirmed the message in time,
+	// the message may still have been delivered.
+	ErrConfirmTimeout = errors.New("timed out waiting for the broker to confirm the message")
+)
+
+const (
+	defaultConfirmTimeout = 5 * time.Second
+	confirmEventsSize     = 100
+)
+
+// confirmEvent is a confirmation or a returned message, returns arrive before the confirmation.
+type confirmEvent struct {
+	confirm  *amqp.Confirmation
+	returned *amqp.Return
+}
+
+// confirmWaiter is the message a publisher is waiting the confirmation for.
+type confirmWaiter struct {
+	tag       uint64
+	messageID string
+}
+
+// Producer publishes persistent messages to the configured exchange. The channel is in confirm
+// mode and messages are published as mandatory, so losses are reported to the publisher.
+// It reconnects when the broker goes away, publishing fails with ErrDisconnected until
+// the connection is restored.
 type Producer struct {
-	connection *amqp.Connection
-	channel    *amqp.Channel
-	config     *conf.AMQPConfig
+	session *session
+	config  *conf.AMQPConfig
+	logger  Logger
+
+	// mu serializes publishes, so delivery tags follow the publish order
+	mu sync.Mutex
+	// tag is the delivery tag of the last message published on the channel
+	tag     uint64
+	channel *amqp.Channel
+	events  chan confirmEvent
+
+	// waiter is set while a publisher waits, only its events are forwarded to it
+	waiterMu sync.Mutex
+	waiter   *confirmWaiter
 }
 
-func NewProducer(config *conf.AMQPConfig) (*Producer, error) {
-	connection, channel, err := NewChannel(config)
+func NewProducer(logger Logger, config *conf.AMQPConfig) (*Producer, error) {
+	p := &Producer{
+		config: config,
+		logger: logger,
+	}
+	s, err := newSession(config, logger, p.setup)
 	if err != nil {
 		return nil, err
 	}
-	return &Producer{
-		connection: connection,
-		channel:    channel,
-		config:     config,
-	}, nil
+	p.session = s
+	return p, nil
+}
+
+// setup puts the channel into confirm mode, it runs on every (re)connection.
+func (p *Producer) setup(channel *amqp.Channel) error {
+	if err := channel.Confirm(false); err != nil {
+		return fmt.Errorf("channel Confirm: %w", err)
+	}
+	confirms := channel.NotifyPublish(make(chan amqp.Confirmation, confirmEventsSize))
+	returns := channel.NotifyReturn(make(chan amqp.Return, confirmEventsSize))
+	events := make(chan confirmEvent, confirmEventsSize)
+	go p.track(confirms, returns, events)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.channel, p.tag, p.events = channel, 0, events
+	return nil
+}
+
+// track forwards confirmations and returns of a channel until it is closed. Only the events
+// of the message a publisher waits for are forwarded, failures of the others are only logged.
+func (p *Producer) track(confirms <-chan amqp.Confirmation, returns <-chan amqp.Return, events chan<- confirmEvent) {
+	for confirms != nil || returns != nil {
+		select {
+		case c, ok := <-confirms:
+			if !ok {
+				confirms = nil
+				continue
+			}
+			// the broker sends the return of a message before its confirmation, but select picks
+			// a ready channel at random, so the returns received so far are forwarded first
+			returns = p.drainReturns(returns, events)
+			p.forward(confirmEvent{confirm: &c}, events)
+		case r, ok := <-returns:
+			if !ok {
+				returns = nil
+				continue
+			}
+			p.forward(confirmEvent{returned: &r}, events)
+		}
+	}
+}
+
+// drainReturns forwards the returns received so far, it returns nil once returns is closed.
+func (p *Producer) drainReturns(returns <-chan amqp.Return, events chan<- confirmEvent) <-chan amqp.Return {
+	for returns != nil {
+		select {
+		case r, ok := <-returns:
+			if !ok {
+				return nil
+			}
+			p.forward(confirmEvent{returned: &r}, events)
+		default:
+			return returns
+		}
+	}
+	return nil
+}
+
+func (p *Producer) forward(event confirmEvent, events chan<- confirmEvent) {
+	if !p.awaited(event) {
+		p.logUnconfirmed(event)
+		return
+	}
+	select {
+	case events <- event:
+	default:
+		// nobody is waiting, the publisher has timed out
+	}
+}
+
+// awaited reports whether a publisher waits for the event.
+func (p *Producer) awaited(event confirmEvent) bool {
+	p.waiterMu.Lock()
+	defer p.waiterMu.Unlock()
+	if p.waiter == nil {
+		return false
+	}
+	if event.returned != nil {
+		return event.returned.MessageId == p.waiter.messageID
+	}
+	return event.confirm.DeliveryTag == p.waiter.tag
+}
+
+func (p *Producer) setWaiter(waiter *confirmWaiter) {
+	p.waiterMu.Lock()
+	defer p.waiterMu.Unlock()
+	p.waiter = waiter
+}
+
+func (p *Producer) logUnconfirmed(event confirmEvent) {
+	switch {
+	case event.returned != nil:
+		p.logger.ErrorContext(context.Background(), "message was returned",
+			"message_id", event.returned.MessageId, "reason", event.returned.ReplyText)
+	case !event.confirm.Ack:
+		p.logger.ErrorContext(context.Background(), "message was not acknowledged", "delivery_tag", event.confirm.DeliveryTag)
+	}
 }
 
 func (p *Producer) Publish(object interface{}) error {
@@ -31,32 +175,109 @@ func (p *Producer) Publish(object interface{}) error {
 	if err != nil {
 		return fmt.Errorf("failed to create amqp channel: %w", err)
 	}
-	err = p.channel.Publish(
+	return p.PublishRaw("", serializer)
+}
+
+// PublishRaw publishes an already serialized JSON message. The messageID is passed
+// as the AMQP message id, so consumers can use it to drop duplicates.
+// It waits for the broker to confirm the message if Confirm.Wait is set.
+func (p *Producer) PublishRaw(messageID string, body []byte) error {
+	return p.publish(messageID, "application/json", nil, body, p.config.Confirm.Wait)
+}
+
+// PublishConfirmed publishes the message with the content type and the headers, e.g. the trace
+// context, and waits for the broker to confirm it regardless of the configuration.
+func (p *Producer) PublishConfirmed(messageID, contentType string, headers map[string]string, body []byte) error {
+	return p.publish(messageID, contentType, headers, body, true)
+}
+
+func (p *Producer) publish(
+	messageID, contentType string, headers map[string]string, body []byte, wait bool,
+) (err error) {
+	defer func() {
+		publishedTotal.WithLabelValues(publishResult(err)).Inc()
+	}()
+	channel, err := p.session.Channel()
+	if err != nil {
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if channel != p.channel {
+		// the session has reconnected, but setup has not run yet
+		return fmt.Errorf("failed to publish message: %w", ErrDisconnected)
+	}
+	if wait {
+		// the waiter is set before publishing, the confirmation may arrive before Publish returns
+		p.setWaiter(&confirmWaiter{tag: p.tag + 1, messageID: messageID})
+		defer p.setWaiter(nil)
+	}
+	err = channel.Publish(
 		p.config.Exchange,
 		p.config.RoutingKey,
-		false,
-		false,
+		true,  // mandatory
+		false, // immediate
 		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        serializer,
+			Headers:      table(headers),
+			ContentType:  contentType,
+			DeliveryMode: amqp.Persistent,
+			MessageId:    messageID,
+			Body:         body,
 		},
 	)
 	if err != nil {
 		return fmt.Errorf("failed to publish message: %w", err)
 	}
+	p.tag++
+	if !wait {
+		return nil
+	}
+	if err = p.waitConfirm(messageID, p.tag); err != nil {
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
 	return nil
 }
 
-func (p *Producer) Close() error {
-	if p.channel != nil {
-		if err := p.channel.Close(); err != nil {
-			return fmt.Errorf("failed to close channel: %w", err)
+// waitConfirm waits for the confirmation of the message with the delivery tag.
+func (p *Producer) waitConfirm(messageID string, tag uint64) error {
+	timer := time.NewTimer(confirmTimeout(p.config))
+	defer timer.Stop()
+
+	returned := false
+	for {
+		select {
+		case <-timer.C:
+			return ErrConfirmTimeout
+		case event := <-p.events:
+			switch {
+			case event.returned != nil:
+				returned = returned || event.returned.MessageId == messageID
+			case event.confirm.DeliveryTag < tag:
+				// a late confirmation of a message that has timed out
+			case !event.confirm.Ack:
+				return ErrNacked
+			case returned:
+				return ErrUnroutable
+			default:
+				return nil
+			}
 		}
 	}
-	if p.connection != nil {
-		if err := p.connection.Close(); err != nil {
-			return fmt.Errorf("failed to close connection: %w", err)
-		}
+}
+
+func confirmTimeout(config *conf.AMQPConfig) time.Duration {
+	if config.Confirm.Timeout <= 0 {
+		return defaultConfirmTimeout
 	}
-	return nil
+	return time.Duration(config.Confirm.Timeout) * time.Second
+}
+
+// State returns the state of the connection to the broker.
+func (p *Producer) State() State {
+	return p.session.State()
+}
+
+func (p *Producer) Close() error {
+	return p.session.Close()
 }
@@ -0,0 +1,93 @@
+package amqp
+
+import (
+	"testing"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/logger"
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitConfirm(t *testing.T) {
+	confirm := func(tag uint64, ack bool) confirmEvent {
+		return confirmEvent{confirm: &amqp.Confirmation{DeliveryTag: tag, Ack: ack}}
+	}
+	returned := func(messageID string) confirmEvent {
+		return confirmEvent{returned: &amqp.Return{MessageId: messageID}}
+	}
+
+	testData := []struct {
+		name   string
+		events []confirmEvent
+		err    error
+	}{
+		{name: "acknowledged", events: []confirmEvent{confirm(2, true)}},
+		{name: "late confirmation is skipped", events: []confirmEvent{confirm(1, false), confirm(2, true)}},
+		{name: "not acknowledged", events: []confirmEvent{confirm(2, false)}, err: ErrNacked},
+		{name: "returned", events: []confirmEvent{returned("m2"), confirm(2, true)}, err: ErrUnroutable},
+		{name: "other message returned", events: []confirmEvent{returned("m1"), confirm(2, true)}},
+		{name: "timeout", events: []confirmEvent{confirm(1, true)}, err: ErrConfirmTimeout},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Producer{
+				config: &conf.AMQPConfig{Confirm: conf.ConfirmConf{Timeout: 1}},
+				events: make(chan confirmEvent, len(tt.events)),
+			}
+			for _, event := range tt.events {
+				p.events <- event
+			}
+			err := p.waitConfirm("m2", 2)
+			if tt.err == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestTrackForwardsAwaitedEvents(t *testing.T) {
+	logg, err := logger.New("ERROR")
+	require.NoError(t, err)
+	// a publish waits for its confirmation even if waiting is not configured
+	p := &Producer{
+		config: &conf.AMQPConfig{Confirm: conf.ConfirmConf{Wait: false}},
+		logger: logg,
+	}
+	p.setWaiter(&confirmWaiter{tag: 2, messageID: "m2"})
+
+	// both channels are ready when track starts, the return must still precede the confirmation,
+	// select picks at random, so the race is run a number of times
+	for i := 0; i < 100; i++ {
+		returns := make(chan amqp.Return, 2)
+		returns <- amqp.Return{MessageId: "m1"}
+		returns <- amqp.Return{MessageId: "m2"}
+		close(returns)
+		confirms := make(chan amqp.Confirmation, 2)
+		confirms <- amqp.Confirmation{DeliveryTag: 1, Ack: false}
+		confirms <- amqp.Confirmation{DeliveryTag: 2, Ack: true}
+		close(confirms)
+		events := make(chan confirmEvent, 4)
+		p.track(confirms, returns, events)
+
+		// the events of the other messages are only logged
+		require.Len(t, events, 2)
+		event := <-events
+		require.NotNil(t, event.returned, "the confirmation was forwarded before the return")
+		require.Equal(t, "m2", event.returned.MessageId)
+		event = <-events
+		require.Equal(t, uint64(2), event.confirm.DeliveryTag)
+	}
+
+	// without a waiter nothing is forwarded
+	p.setWaiter(nil)
+	confirms := make(chan amqp.Confirmation, 1)
+	confirms <- amqp.Confirmation{DeliveryTag: 3, Ack: true}
+	close(confirms)
+	events := make(chan confirmEvent, 1)
+	p.track(confirms, nil, events)
+	require.Empty(t, events)
+}
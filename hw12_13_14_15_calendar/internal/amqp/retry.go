@@ -0,0 +1,178 @@
+package amqp
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
+	"github.com/streadway/amqp"
+)
+
+const (
+	// AttemptHeader is the number of the delivery attempt, the first delivery has no header.
+	AttemptHeader = "x-attempt"
+	// ErrorHeader is the error of the last failed attempt.
+	ErrorHeader = "x-last-error"
+	// RoutingKeyHeader is the original routing key of a dead-lettered message.
+	RoutingKeyHeader = "x-original-routing-key"
+
+	defaultMaxAttempts  = 3
+	defaultInitialDelay = 5 * time.Second
+	defaultMaxDelay     = time.Minute
+)
+
+// ErrPermanent marks a handler error another attempt cannot fix, e.g. a message that cannot be decoded.
+// Such a message is dead-lettered without retries.
+var ErrPermanent = errors.New("permanent failure")
+
+// RetryPolicy decides when a failed message is retried and when it is dead-lettered.
+type RetryPolicy struct {
+	MaxAttempts  int
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+}
+
+func NewRetryPolicy(config *conf.RetryConf) RetryPolicy {
+	p := RetryPolicy{
+		MaxAttempts:  config.MaxAttempts,
+		InitialDelay: time.Duration(config.InitialDelay) * time.Second,
+		MaxDelay:     time.Duration(config.MaxDelay) * time.Second,
+	}
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = defaultMaxAttempts
+	}
+	if p.InitialDelay <= 0 {
+		p.InitialDelay = defaultInitialDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = defaultMaxDelay
+	}
+	return p
+}
+
+// Delay returns how long to wait before the attempt following the failed one.
+func (p RetryPolicy) Delay(failedAttempt int) time.Duration {
+	delay := p.InitialDelay
+	for i := 1; i < failedAttempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// Delays returns distinct delays of all retries, a delay queue is declared for each of them.
+func (p RetryPolicy) Delays() []time.Duration {
+	var delays []time.Duration
+	for attempt := 1; attempt < p.MaxAttempts; attempt++ {
+		delay := p.Delay(attempt)
+		if len(delays) == 0 || delays[len(delays)-1] != delay {
+			delays = append(delays, delay)
+		}
+	}
+	return delays
+}
+
+// DelayQueueName returns the queue holding messages for the delay. Messages expire there
+// after the delay and are dead-lettered back to the main queue.
+func DelayQueueName(config *conf.AMQPConfig, delay time.Duration) string {
+	return fmt.Sprintf("%s.retry.%dms", config.Queue, delay.Milliseconds())
+}
+
+// DeadLetterExchangeName returns the exchange receiving messages that failed all attempts.
+func DeadLetterExchangeName(config *conf.AMQPConfig) string {
+	return config.Exchange + ".dlx"
+}
+
+// DeadLetterQueueName returns the queue keeping dead-lettered messages for inspection and replay.
+func DeadLetterQueueName(config *conf.AMQPConfig) string {
+	return config.Queue + ".dead"
+}
+
+// declareRetryTopology declares the delay queues and the dead-letter exchange with its queue.
+func declareRetryTopology(channel *amqp.Channel, config *conf.AMQPConfig, policy RetryPolicy) error {
+	for _, delay := range policy.Delays() {
+		_, err := channel.QueueDeclare(
+			DelayQueueName(config, delay),
+			true,  // durable
+			false, // delete when unused
+			false, // exclusive
+			false, // noWait
+			amqp.Table{
+				"x-message-ttl":             delay.Milliseconds(),
+				"x-dead-letter-exchange":    "", // the default exchange routes by queue name
+				"x-dead-letter-routing-key": config.Queue,
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("delay queue Declare: %w", err)
+		}
+	}
+
+	if err := channel.ExchangeDeclare(
+		DeadLetterExchangeName(config),
+		"fanout",
+		true,  // durable
+		false, // auto-deleted
+		false, // internal
+		false, // noWait
+		nil,   // arguments
+	); err != nil {
+		return fmt.Errorf("dead-letter exchange Declare: %w", err)
+	}
+	if _, err := channel.QueueDeclare(
+		DeadLetterQueueName(config),
+		true,  // durable
+		false, // delete when unused
+		false, // exclusive
+		false, // noWait
+		nil,   // arguments
+	); err != nil {
+		return fmt.Errorf("dead-letter queue Declare: %w", err)
+	}
+	if err := channel.QueueBind(
+		DeadLetterQueueName(config),
+		"", // bindingKey, ignored by fanout
+		DeadLetterExchangeName(config),
+		false, // noWait
+		nil,   // arguments
+	); err != nil {
+		return fmt.Errorf("dead-letter queue Bind: %w", err)
+	}
+	return nil
+}
+
+// attempt returns the attempt number of the delivery.
+func attempt(headers amqp.Table) int {
+	switch v := headers[AttemptHeader].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	default:
+		return 1
+	}
+}
+
+// republishing copies the delivery with the headers updated for the next attempt or for the dead-letter queue.
+func republishing(d amqp.Delivery, headers amqp.Table) amqp.Publishing {
+	merged := amqp.Table{}
+	for k, v := range d.Headers {
+		merged[k] = v
+	}
+	for k, v := range headers {
+		merged[k] = v
+	}
+	return amqp.Publishing{
+		Headers:      merged,
+		ContentType:  d.ContentType,
+		DeliveryMode: amqp.Persistent,
+		MessageId:    d.MessageId,
+		Timestamp:    d.Timestamp,
+		Body:         d.Body,
+	}
+}
@@ -0,0 +1,48 @@
+package amqp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	p := NewRetryPolicy(&conf.RetryConf{MaxAttempts: 6, InitialDelay: 5, MaxDelay: 30})
+
+	require.Equal(t, 5*time.Second, p.Delay(1))
+	require.Equal(t, 10*time.Second, p.Delay(2))
+	require.Equal(t, 20*time.Second, p.Delay(3))
+	require.Equal(t, 30*time.Second, p.Delay(4))
+	require.Equal(t, 30*time.Second, p.Delay(5))
+	require.Equal(t, []time.Duration{5 * time.Second, 10 * time.Second, 20 * time.Second, 30 * time.Second}, p.Delays())
+}
+
+func TestRetryPolicyDefaults(t *testing.T) {
+	p := NewRetryPolicy(&conf.RetryConf{})
+
+	require.Equal(t, defaultMaxAttempts, p.MaxAttempts)
+	require.Equal(t, defaultInitialDelay, p.Delay(1))
+	require.Len(t, p.Delays(), defaultMaxAttempts-1)
+}
+
+func TestAttempt(t *testing.T) {
+	require.Equal(t, 1, attempt(nil))
+	require.Equal(t, 3, attempt(amqp.Table{AttemptHeader: int32(3)}))
+	require.Equal(t, 2, attempt(amqp.Table{AttemptHeader: int64(2)}))
+
+	msg := Message{Attempt: attempt(amqp.Table{AttemptHeader: int32(3)}), MaxAttempts: 3}
+	require.True(t, msg.FinalAttempt())
+	msg.Attempt = 2
+	require.False(t, msg.FinalAttempt())
+}
+
+func TestDelayQueueName(t *testing.T) {
+	config := &conf.AMQPConfig{Exchange: "calendar", Queue: "sender"}
+
+	require.Equal(t, "sender.retry.5000ms", DelayQueueName(config, 5*time.Second))
+	require.Equal(t, "calendar.dlx", DeadLetterExchangeName(config))
+	require.Equal(t, "sender.dead", DeadLetterQueueName(config))
+}
@@ -0,0 +1,221 @@
+package amqp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
+	"github.com/streadway/amqp"
+)
+
+var (
+	// ErrDisconnected is returned while the connection to the broker is being restored.
+	ErrDisconnected = errors.New("amqp connection is not available")
+	// ErrClosed is returned after the producer or consumer has been closed.
+	ErrClosed = errors.New("amqp connection is closed")
+)
+
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+// State is the state of the connection to the broker.
+type State int32
+
+const (
+	StateConnecting State = iota
+	StateConnected
+	StateClosed
+)
+
+func (s State) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateClosed:
+		return "closed"
+	default:
+		return fmt.Sprintf("State(%d)", int32(s))
+	}
+}
+
+type Logger interface {
+	InfoContext(ctx context.Context, msg string, args ...any)
+	ErrorContext(ctx context.Context, msg string, args ...any)
+}
+
+// session keeps a connection and a channel to the broker and restores them when the broker
+// closes them. After every reconnection setup declares the topology again.
+type session struct {
+	config *conf.AMQPConfig
+	logger Logger
+	setup  func(*amqp.Channel) error
+
+	mu      sync.RWMutex
+	conn    *amqp.Connection
+	channel *amqp.Channel
+	// connected is closed while the session is connected and replaced when the connection is lost
+	connected chan struct{}
+
+	state     atomic.Int32
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newSession(config *conf.AMQPConfig, logger Logger, setup func(*amqp.Channel) error) (*session, error) {
+	s := &session{
+		config:    config,
+		logger:    logger,
+		setup:     setup,
+		connected: make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	go s.watch()
+	return s, nil
+}
+
+func (s *session) connect() error {
+	conn, channel, err := NewChannel(s.config)
+	if err != nil {
+		return err
+	}
+	if s.setup != nil {
+		if err = s.setup(channel); err != nil {
+			_ = conn.Close()
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.done:
+		_ = conn.Close()
+		return ErrClosed
+	default:
+	}
+	s.conn, s.channel = conn, channel
+	s.state.Store(int32(StateConnected))
+	close(s.connected)
+	return nil
+}
+
+// watch reconnects whenever the connection or the channel is closed by the broker.
+func (s *session) watch() {
+	for {
+		s.mu.RLock()
+		connClosed := s.conn.NotifyClose(make(chan *amqp.Error, 1))
+		channelClosed := s.channel.NotifyClose(make(chan *amqp.Error, 1))
+		s.mu.RUnlock()
+
+		var reason *amqp.Error
+		select {
+		case <-s.done:
+			return
+		case reason = <-connClosed:
+		case reason = <-channelClosed:
+		}
+		select {
+		case <-s.done:
+			return
+		default:
+		}
+
+		s.mu.Lock()
+		s.state.Store(int32(StateConnecting))
+		s.connected = make(chan struct{})
+		// a closed channel leaves the connection open, drop it to start over
+		_ = s.conn.Close()
+		s.mu.Unlock()
+		s.logger.ErrorContext(context.Background(), "amqp connection lost, reconnecting", "error", reason)
+
+		if !s.reconnect() {
+			return
+		}
+		s.logger.InfoContext(context.Background(), "amqp connection restored")
+	}
+}
+
+// reconnect retries with exponential backoff until it succeeds or the session is closed.
+func (s *session) reconnect() bool {
+	delay := minReconnectDelay
+	for {
+		select {
+		case <-s.done:
+			return false
+		case <-time.After(delay):
+		}
+		err := s.connect()
+		if err == nil {
+			return true
+		}
+		if errors.Is(err, ErrClosed) {
+			return false
+		}
+		s.logger.ErrorContext(context.Background(), "failed to reconnect to amqp", "error", err, "retry_in", delay)
+		delay *= 2
+		if delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
+
+// Channel returns the current channel, it fails fast while the session is reconnecting.
+func (s *session) Channel() (*amqp.Channel, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	switch s.State() {
+	case StateConnected:
+		return s.channel, nil
+	case StateClosed:
+		return nil, ErrClosed
+	default:
+		return nil, ErrDisconnected
+	}
+}
+
+// waitConnected blocks until the session is connected, closed or ctx is done.
+func (s *session) waitConnected(ctx context.Context) error {
+	s.mu.RLock()
+	connected := s.connected
+	s.mu.RUnlock()
+	select {
+	case <-connected:
+		return nil
+	case <-s.done:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *session) State() State {
+	return State(s.state.Load())
+}
+
+func (s *session) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.state.Store(int32(StateClosed))
+		if s.conn != nil && !s.conn.IsClosed() {
+			err = s.conn.Close()
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to close connection: %w", err)
+	}
+	return nil
+}
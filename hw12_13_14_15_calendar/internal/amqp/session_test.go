@@ -0,0 +1,26 @@
+package amqp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionFailsFastWhileDisconnected(t *testing.T) {
+	s := &session{connected: make(chan struct{}), done: make(chan struct{})}
+	p := &Producer{session: s, config: &conf.AMQPConfig{}}
+
+	require.Equal(t, StateConnecting, p.State())
+	require.ErrorIs(t, p.PublishRaw("id", []byte("{}")), ErrDisconnected)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, s.waitConnected(ctx), context.Canceled)
+
+	require.NoError(t, p.Close())
+	require.Equal(t, StateClosed, p.State())
+	require.ErrorIs(t, p.PublishRaw("id", []byte("{}")), ErrClosed)
+	require.ErrorIs(t, s.waitConnected(context.Background()), ErrClosed)
+}
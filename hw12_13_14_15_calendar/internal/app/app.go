@@ -5,17 +5,21 @@ import (
 
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage"
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/webhook"
 )
 
 type App struct {
 	Storage  storage.Storage
 	Logger   Logger
 	BindAddr string
+	Webhooks *webhook.Dispatcher // nil disables outgoing webhooks
 }
 
 type Logger interface {
 	Info(msg string)
 	Error(msg string)
+	InfoContext(ctx context.Context, msg string, args ...any)
+	ErrorContext(ctx context.Context, msg string, args ...any)
 }
 
 func New(logger Logger, storage storage.Storage) *App {
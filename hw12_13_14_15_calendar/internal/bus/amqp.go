@@ -0,0 +1,92 @@
+package bus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/amqp"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
+)
+
+// AMQPBus is the RabbitMQ transport. The producer and the consumer connect on first use,
+// so a process that only publishes does not declare the queue and vice versa.
+type AMQPBus struct {
+	logger Logger
+	config *conf.AMQPConfig
+
+	mu       sync.Mutex
+	producer *amqp.Producer
+	consumer *amqp.Consumer
+}
+
+func NewAMQPBus(logger Logger, config *conf.AMQPConfig) *AMQPBus {
+	return &AMQPBus{logger: logger, config: config}
+}
+
+// Publish waits for the broker to confirm the message.
+func (b *AMQPBus) Publish(_ context.Context, msg Message) error {
+	b.mu.Lock()
+	if b.producer == nil {
+		producer, err := amqp.NewProducer(b.logger, b.config)
+		if err != nil {
+			b.mu.Unlock()
+			return fmt.Errorf("failed to create producer: %w", err)
+		}
+		b.producer = producer
+	}
+	producer := b.producer
+	b.mu.Unlock()
+	return producer.PublishConfirmed(msg.ID, msg.ContentType, msg.Headers, msg.Body)
+}
+
+func (b *AMQPBus) Consume(ctx context.Context, handler Handler) error {
+	consumer, err := amqp.NewConsumer(b.logger, b.config, func(msg amqp.Message) error {
+		return handler(Message{
+			ID:          msg.ID,
+			ContentType: msg.ContentType,
+			Body:        msg.Body,
+			Headers:     msg.Headers,
+			Attempt:     msg.Attempt,
+			MaxAttempts: msg.MaxAttempts,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create consumer: %w", err)
+	}
+	b.mu.Lock()
+	b.consumer = consumer
+	b.mu.Unlock()
+	return consumer.Consume(ctx)
+}
+
+// Ready checks the connections of the producer and the consumer. They connect on first use,
+// so a bus that has not published or consumed yet is ready.
+func (b *AMQPBus) Ready() error {
+	b.mu.Lock()
+	producer, consumer := b.producer, b.consumer
+	b.mu.Unlock()
+	if producer != nil && producer.State() != amqp.StateConnected {
+		return fmt.Errorf("%w: producer is %s", ErrNotReady, producer.State())
+	}
+	if consumer != nil && consumer.State() != amqp.StateConnected {
+		return fmt.Errorf("%w: consumer is %s", ErrNotReady, consumer.State())
+	}
+	return nil
+}
+
+func (b *AMQPBus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.consumer != nil {
+		if err := b.consumer.Stop(); err != nil {
+			return fmt.Errorf("failed to stop consumer: %w", err)
+		}
+	}
+	if b.producer != nil {
+		if err := b.producer.Close(); err != nil {
+			return fmt.Errorf("failed to close producer: %w", err)
+		}
+	}
+	return nil
+}
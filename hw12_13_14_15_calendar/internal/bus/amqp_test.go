@@ -0,0 +1,93 @@
+package bus
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/logger"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+const testRabbitMQImage = "rabbitmq:3.13"
+
+func TestAMQPBusWithoutConfirmWait(t *testing.T) {
+	ctx := context.Background()
+	logg, err := logger.New("ERROR")
+	require.NoError(t, err)
+	config := &conf.AMQPConfig{
+		URI:          createRabbitMQContainer(ctx, t),
+		Exchange:     "calendar",
+		ExchangeType: "direct",
+		RoutingKey:   "notifications",
+		Queue:        "notifications",
+		Retry:        conf.RetryConf{MaxAttempts: 1},
+		// the bus waits for the confirmations anyway
+		Confirm: conf.ConfirmConf{Wait: false, Timeout: 2},
+	}
+	b := NewAMQPBus(logg, config)
+	t.Cleanup(func() {
+		require.NoError(t, b.Close())
+	})
+
+	received := make(chan Message, 10)
+	go func() {
+		_ = b.Consume(ctx, func(msg Message) error {
+			received <- msg
+			return nil
+		})
+	}()
+	// the queue is declared by the consumer, publishing before would be unroutable
+	require.Eventually(t, func() bool {
+		b.mu.Lock()
+		consumer := b.consumer
+		b.mu.Unlock()
+		return consumer != nil && b.Ready() == nil
+	}, 10*time.Second, 50*time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		start := time.Now()
+		require.NoError(t, b.Publish(ctx, Message{ID: fmt.Sprint(i), ContentType: "application/json", Body: []byte("{}")}))
+		require.Less(t, time.Since(start), time.Second, "publish must not wait for the confirm timeout")
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case msg := <-received:
+			require.Equal(t, fmt.Sprint(i), msg.ID)
+		case <-time.After(5 * time.Second):
+			t.Fatal("message was not delivered")
+		}
+	}
+	select {
+	case msg := <-received:
+		t.Fatalf("unexpected duplicate %q", msg.ID)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func createRabbitMQContainer(ctx context.Context, t *testing.T) string {
+	t.Helper()
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        testRabbitMQImage,
+			ExposedPorts: []string{"5672/tcp"},
+			WaitingFor:   wait.ForLog("Server startup complete").WithStartupTimeout(time.Minute),
+		},
+		Started: true,
+	})
+	require.NoError(t, err, "create container")
+	t.Cleanup(func() {
+		if err := container.Terminate(ctx); err != nil {
+			t.Fatalf("failed to terminate container: %s", err)
+		}
+	})
+	host, err := container.Host(ctx)
+	require.NoError(t, err)
+	port, err := container.MappedPort(ctx, "5672/tcp")
+	require.NoError(t, err)
+	return fmt.Sprintf("amqp://guest:guest@%s:%s/", host, port.Port())
+}
@@ -0,0 +1,85 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/amqp"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
+)
+
+var (
+	ErrUnknownBusType = errors.New("unknown bus type")
+	// ErrClosed is returned by Publish after the bus has been closed.
+	ErrClosed = errors.New("message bus is closed")
+	// ErrNotReady is returned by Ready while the connection to the broker is being restored.
+	ErrNotReady = errors.New("message bus is not ready")
+	// ErrPermanent marks a handler error another attempt cannot fix, the message is dead-lettered at once.
+	ErrPermanent = amqp.ErrPermanent
+)
+
+type Logger interface {
+	InfoContext(ctx context.Context, msg string, args ...any)
+	ErrorContext(ctx context.Context, msg string, args ...any)
+}
+
+// Message is a message passed to the Handler.
+type Message struct {
+	ID          string
+	ContentType string
+	Body        []byte
+	// Headers carry the trace context, the AMQP bus passes them as message headers,
+	// the postgres bus drops them
+	Headers     map[string]string
+	Attempt     int
+	MaxAttempts int
+}
+
+// FinalAttempt reports whether the message is dead-lettered if the handler fails.
+func (m Message) FinalAttempt() bool {
+	return m.Attempt >= m.MaxAttempts
+}
+
+// deadLetter reports whether the message failed with the error is dead-lettered rather than retried.
+func (m Message) deadLetter(err error) bool {
+	return m.FinalAttempt() || errors.Is(err, ErrPermanent)
+}
+
+// Handler handles a message, a failed message is retried with a growing delay
+// unless the error wraps ErrPermanent.
+type Handler func(Message) error
+
+// MessageBus delivers notifications from the scheduler to the sender at least once.
+type MessageBus interface {
+	// Publish returns once the transport has durably accepted the message,
+	// the attempt fields of the message are ignored.
+	Publish(ctx context.Context, msg Message) error
+	// Consume passes messages to the handler until ctx is done or the bus is closed.
+	Consume(ctx context.Context, handler Handler) error
+	// Ready returns an error when the transport cannot pass messages, e.g. the broker is disconnected.
+	Ready() error
+	Close() error
+}
+
+// NewFromConfig creates the bus selected by busConf.Type. Messages are routed by the routing key
+// of amqpConf for every transport, and failed messages are retried according to amqpConf.Retry.
+func NewFromConfig(
+	logger Logger, busConf *conf.BusConf, amqpConf *conf.AMQPConfig, storageConf *conf.StorageConf,
+) (MessageBus, error) {
+	switch busConf.Type {
+	case "", "amqp":
+		return NewAMQPBus(logger, amqpConf), nil
+	case "memory":
+		return NewMemoryBus(logger, amqpConf.RoutingKey, amqp.NewRetryPolicy(&amqpConf.Retry)), nil
+	case "postgres":
+		dsn := busConf.DSN
+		if dsn == "" {
+			dsn = storageConf.DSN
+		}
+		return NewPostgresBus(logger, dsn, amqpConf.RoutingKey, amqp.NewRetryPolicy(&amqpConf.Retry),
+			pollInterval(busConf))
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownBusType, busConf.Type)
+	}
+}
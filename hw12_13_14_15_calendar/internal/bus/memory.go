@@ -0,0 +1,114 @@
+package bus
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/amqp"
+)
+
+const memoryQueueSize = 1000
+
+var (
+	memoryMu     sync.Mutex
+	memoryQueues = map[string]chan Message{}
+)
+
+// memoryQueue returns the queue shared by all memory buses of the process with the same topic,
+// so a scheduler and a sender running in one binary talk to each other.
+func memoryQueue(topic string) chan Message {
+	memoryMu.Lock()
+	defer memoryMu.Unlock()
+	queue, ok := memoryQueues[topic]
+	if !ok {
+		queue = make(chan Message, memoryQueueSize)
+		memoryQueues[topic] = queue
+	}
+	return queue
+}
+
+// MemoryBus is an in-process transport. Messages do not survive a restart,
+// a message that fails every attempt is logged and dropped.
+type MemoryBus struct {
+	logger    Logger
+	queue     chan Message
+	policy    amqp.RetryPolicy
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func NewMemoryBus(logger Logger, topic string, policy amqp.RetryPolicy) *MemoryBus {
+	return &MemoryBus{
+		logger: logger,
+		queue:  memoryQueue(topic),
+		policy: policy,
+		done:   make(chan struct{}),
+	}
+}
+
+// Publish blocks while the queue is full.
+func (b *MemoryBus) Publish(ctx context.Context, msg Message) error {
+	msg.Attempt = 1
+	return b.enqueue(ctx, msg)
+}
+
+func (b *MemoryBus) enqueue(ctx context.Context, msg Message) error {
+	select {
+	case b.queue <- msg:
+		return nil
+	case <-b.done:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *MemoryBus) Consume(ctx context.Context, handler Handler) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-b.done:
+			return nil
+		case msg := <-b.queue:
+			msg.MaxAttempts = b.policy.MaxAttempts
+			if err := handler(msg); err != nil {
+				b.retry(ctx, msg, err)
+			}
+		}
+	}
+}
+
+func (b *MemoryBus) retry(ctx context.Context, msg Message, handleErr error) {
+	if msg.deadLetter(handleErr) {
+		b.logger.ErrorContext(ctx, "dropping message", "message_id", msg.ID, "attempt", msg.Attempt, "error", handleErr)
+		return
+	}
+	delay := b.policy.Delay(msg.Attempt)
+	b.logger.InfoContext(ctx, "retrying message", "message_id", msg.ID, "attempt", msg.Attempt, "delay", delay)
+	msg.Attempt++
+	time.AfterFunc(delay, func() {
+		ctx := context.Background()
+		if err := b.enqueue(ctx, msg); err != nil {
+			b.logger.ErrorContext(ctx, "failed to retry message", "message_id", msg.ID, "error", err)
+		}
+	})
+}
+
+// Ready fails only once the bus has been closed.
+func (b *MemoryBus) Ready() error {
+	select {
+	case <-b.done:
+		return ErrClosed
+	default:
+		return nil
+	}
+}
+
+func (b *MemoryBus) Close() error {
+	b.closeOnce.Do(func() {
+		close(b.done)
+	})
+	return nil
+}
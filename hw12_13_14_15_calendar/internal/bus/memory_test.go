@@ -0,0 +1,66 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/amqp"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/logger"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryBus(t *testing.T) {
+	logg, err := logger.New("ERROR")
+	require.NoError(t, err)
+	policy := amqp.RetryPolicy{MaxAttempts: 3, InitialDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+	publisher := NewMemoryBus(logg, t.Name(), policy)
+	consumer := NewMemoryBus(logg, t.Name(), policy)
+	t.Cleanup(func() {
+		require.NoError(t, consumer.Close())
+	})
+
+	ctx := context.Background()
+	require.NoError(t, publisher.Publish(ctx, Message{ID: "flaky", Body: []byte("1")}))
+	require.NoError(t, publisher.Publish(ctx, Message{ID: "broken", Body: []byte("2")}))
+
+	attempts := make(chan Message, 10)
+	go func() {
+		_ = consumer.Consume(ctx, func(msg Message) error {
+			attempts <- msg
+			if msg.ID == "broken" || msg.Attempt < 2 {
+				return errors.New("failed")
+			}
+			return nil
+		})
+	}()
+
+	got := map[string][]int{}
+	for i := 0; i < 5; i++ {
+		select {
+		case msg := <-attempts:
+			require.Equal(t, 3, msg.MaxAttempts)
+			got[msg.ID] = append(got[msg.ID], msg.Attempt)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out, got %v", got)
+		}
+	}
+	require.Equal(t, map[string][]int{"flaky": {1, 2}, "broken": {1, 2, 3}}, got)
+
+	// the final failure is not retried
+	select {
+	case msg := <-attempts:
+		t.Fatalf("unexpected attempt %d of %q", msg.Attempt, msg.ID)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestMemoryBusClosed(t *testing.T) {
+	logg, err := logger.New("ERROR")
+	require.NoError(t, err)
+	b := NewMemoryBus(logg, t.Name(), amqp.RetryPolicy{MaxAttempts: 1})
+	require.NoError(t, b.Close())
+
+	require.NoError(t, b.Consume(context.Background(), func(Message) error { return nil }))
+}
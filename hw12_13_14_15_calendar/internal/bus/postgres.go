@@ -0,0 +1,184 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/amqp"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const defaultPollInterval = time.Second
+
+func pollInterval(c *conf.BusConf) time.Duration {
+	if c.PollInterval <= 0 {
+		return defaultPollInterval
+	}
+	return time.Duration(c.PollInterval) * time.Second
+}
+
+// readyTimeout bounds the ping of the database in Ready.
+const readyTimeout = 5 * time.Second
+
+// PostgresBus keeps messages in the bus_messages table. Consumers lock a message with
+// FOR UPDATE SKIP LOCKED while handling it, so any number of them can share a topic.
+// A failed message is delayed by moving its available_at, after the final attempt it is marked dead.
+type PostgresBus struct {
+	logger   Logger
+	topic    string
+	policy   amqp.RetryPolicy
+	interval time.Duration
+
+	// publishing and consuming share the pool, a consumer holds a connection
+	// for as long as the handler runs
+	pool *pgxpool.Pool
+	done chan struct{}
+	once sync.Once
+}
+
+// NewPostgresBus creates the bus, it connects to the database on the first use.
+func NewPostgresBus(
+	logger Logger, dsn, topic string, policy amqp.RetryPolicy, interval time.Duration,
+) (*PostgresBus, error) {
+	pool, err := pgxpool.New(context.Background(), dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bus database pool: %w", err)
+	}
+	return &PostgresBus{
+		logger:   logger,
+		topic:    topic,
+		policy:   policy,
+		interval: interval,
+		pool:     pool,
+		done:     make(chan struct{}),
+	}, nil
+}
+
+func (b *PostgresBus) Publish(ctx context.Context, msg Message) error {
+	select {
+	case <-b.done:
+		return ErrClosed
+	default:
+	}
+	_, err := b.pool.Exec(ctx,
+		"INSERT INTO bus_messages (topic, message_id, content_type, body) VALUES ($1, $2, $3, $4)",
+		b.topic, msg.ID, msg.ContentType, msg.Body)
+	if err != nil {
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+	return nil
+}
+
+// Consume polls the topic until ctx is done or the bus is closed. When the database cannot be
+// reached it keeps trying after a growing pause, so a restart of the database does not end it.
+func (b *PostgresBus) Consume(ctx context.Context, handler Handler) error {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	failures := 0
+	for {
+		// handle everything that is due, then wait for the next poll
+		wait := ticker.C
+		for {
+			handled, err := b.consumeOne(ctx, handler)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				failures++
+				delay := b.policy.Delay(failures)
+				b.logger.ErrorContext(ctx, "failed to consume message", "topic", b.topic, "delay", delay, "error", err)
+				wait = time.After(delay)
+				break
+			}
+			failures = 0
+			if !handled {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-b.done:
+			return nil
+		case <-wait:
+		}
+	}
+}
+
+// consumeOne handles the oldest due message, it reports whether there was one.
+func (b *PostgresBus) consumeOne(ctx context.Context, handler Handler) (bool, error) {
+	tx, err := b.pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	var id int64
+	msg := Message{MaxAttempts: b.policy.MaxAttempts}
+	err = tx.QueryRow(ctx, `
+SELECT id, message_id, content_type, body, attempt FROM bus_messages
+WHERE topic = $1 AND NOT dead AND available_at <= now()
+ORDER BY available_at, id
+LIMIT 1
+FOR UPDATE SKIP LOCKED`, b.topic).Scan(&id, &msg.ID, &msg.ContentType, &msg.Body, &msg.Attempt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch message: %w", err)
+	}
+
+	if handleErr := handler(msg); handleErr != nil {
+		err = b.retry(ctx, tx, id, msg, handleErr)
+	} else {
+		_, err = tx.Exec(ctx, "DELETE FROM bus_messages WHERE id = $1", id)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to update message: %w", err)
+	}
+	return true, tx.Commit(ctx)
+}
+
+func (b *PostgresBus) retry(ctx context.Context, tx pgx.Tx, id int64, msg Message, handleErr error) error {
+	if msg.deadLetter(handleErr) {
+		b.logger.InfoContext(ctx, "dead-lettering message", "message_id", msg.ID, "attempt", msg.Attempt, "error", handleErr)
+		_, err := tx.Exec(ctx, "UPDATE bus_messages SET dead = true, last_error = $2 WHERE id = $1",
+			id, handleErr.Error())
+		return err
+	}
+	delay := b.policy.Delay(msg.Attempt)
+	b.logger.InfoContext(ctx, "retrying message", "message_id", msg.ID, "attempt", msg.Attempt, "delay", delay)
+	_, err := tx.Exec(ctx, `
+UPDATE bus_messages SET attempt = attempt + 1, available_at = $2, last_error = $3
+WHERE id = $1`, id, time.Now().Add(delay), handleErr.Error())
+	return err
+}
+
+// Ready pings the database, it fails once the bus has been closed.
+func (b *PostgresBus) Ready() error {
+	select {
+	case <-b.done:
+		return ErrClosed
+	default:
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), readyTimeout)
+	defer cancel()
+	if err := b.pool.Ping(ctx); err != nil {
+		return fmt.Errorf("%w: %w", ErrNotReady, err)
+	}
+	return nil
+}
+
+// Close stops Consume after the message in progress and closes the pool once its connections are released.
+func (b *PostgresBus) Close() error {
+	b.once.Do(func() {
+		close(b.done)
+		b.pool.Close()
+	})
+	return nil
+}
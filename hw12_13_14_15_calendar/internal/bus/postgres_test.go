@@ -0,0 +1,117 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/amqp"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/logger"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/pgtest"
+	sqlstorage "github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/sql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresBus(t *testing.T) {
+	ctx := context.Background()
+	connStr, err := pgtest.CreatePostgresContainer(ctx, t)
+	require.NoError(t, err)
+	s := sqlstorage.New(connStr)
+	require.NoError(t, s.Connect(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, s.Close(ctx))
+	})
+	require.NoError(t, s.Migrate(ctx, nil))
+
+	logg, err := logger.New("ERROR")
+	require.NoError(t, err)
+	policy := amqp.RetryPolicy{MaxAttempts: 3, InitialDelay: 10 * time.Millisecond, MaxDelay: 20 * time.Millisecond}
+	publisher, err := NewPostgresBus(logg, connStr, "notifications", policy, 10*time.Millisecond)
+	require.NoError(t, err)
+	consumer, err := NewPostgresBus(logg, connStr, "notifications", policy, 10*time.Millisecond)
+	require.NoError(t, err)
+	other, err := NewPostgresBus(logg, connStr, "other", policy, 10*time.Millisecond)
+	require.NoError(t, err)
+	require.NoError(t, publisher.Ready())
+	t.Cleanup(func() {
+		require.NoError(t, consumer.Close())
+	})
+
+	require.NoError(t, publisher.Publish(ctx, Message{ID: "flaky", ContentType: "application/json", Body: []byte("1")}))
+	require.NoError(t, publisher.Publish(ctx, Message{ID: "broken", ContentType: "application/json", Body: []byte("2")}))
+	require.NoError(t, other.Publish(ctx, Message{ID: "other", Body: []byte("3")}))
+	require.NoError(t, other.Close())
+
+	attempts := make(chan Message, 10)
+	consumed := make(chan error, 1)
+	go func() {
+		consumed <- consumer.Consume(ctx, func(msg Message) error {
+			attempts <- msg
+			if msg.ID == "broken" || msg.Attempt < 2 {
+				return errors.New("failed")
+			}
+			return nil
+		})
+	}()
+
+	got := map[string][]int{}
+	for i := 0; i < 5; i++ {
+		select {
+		case msg := <-attempts:
+			require.Equal(t, 3, msg.MaxAttempts)
+			require.Equal(t, "application/json", msg.ContentType)
+			got[msg.ID] = append(got[msg.ID], msg.Attempt)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out, got %v", got)
+		}
+	}
+	// messages of other topics are not consumed
+	require.Equal(t, map[string][]int{"flaky": {1, 2}, "broken": {1, 2, 3}}, got)
+
+	// the handled message is deleted, the one that failed every attempt is kept as dead
+	var dead bool
+	var lastError string
+	require.Eventually(t, func() bool {
+		err := s.Pool.QueryRow(ctx, "SELECT dead, last_error FROM bus_messages WHERE message_id = 'broken'").
+			Scan(&dead, &lastError)
+		return err == nil && dead
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, "failed", lastError)
+	var count int
+	require.NoError(t, s.Pool.QueryRow(ctx, "SELECT count(*) FROM bus_messages WHERE message_id = 'flaky'").Scan(&count))
+	require.Zero(t, count)
+
+	// closing the bus stops consuming and publishing
+	require.NoError(t, consumer.Close())
+	select {
+	case err := <-consumed:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("consume did not stop")
+	}
+	require.NoError(t, publisher.Close())
+	require.ErrorIs(t, publisher.Publish(ctx, Message{ID: "late"}), ErrClosed)
+	require.ErrorIs(t, publisher.Ready(), ErrClosed)
+}
+
+func TestPostgresBusRetriesUnreachableDatabase(t *testing.T) {
+	logg, err := logger.New("ERROR")
+	require.NoError(t, err)
+	policy := amqp.RetryPolicy{MaxAttempts: 3, InitialDelay: 10 * time.Millisecond, MaxDelay: 20 * time.Millisecond}
+	// nothing listens on the port
+	b, err := NewPostgresBus(logg, "postgres://postgres@127.0.0.1:1/test?connect_timeout=1", "notifications",
+		policy, 10*time.Millisecond)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, b.Close())
+	})
+	require.ErrorIs(t, b.Ready(), ErrNotReady)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	require.NoError(t, b.Consume(ctx, func(Message) error { return nil }))
+	// consuming goes on until it is canceled
+	require.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+}
@@ -1,50 +1,177 @@
 package conf
 
 import (
+	"fmt"
+	"slices"
+
 	"github.com/BurntSushi/toml"
 )
 
 // При желании конфигурацию можно вынести в internal/config.
 // Организация конфига в main принуждает нас сужать API компонентов, использовать
 // при их конструировании только необходимые параметры, а также уменьшает вероятность циклической зависимости.
+// Settings tagged reload:"restart" take effect only after a restart, see Reloader.
 type APIConfig struct {
-	Logger  LoggerConf
-	HTTP    HTTPConf
-	GRPC    GRPCConf
-	Storage StorageConf
+	Logger   LoggerConf
+	HTTP     HTTPConf     `reload:"restart"`
+	GRPC     GRPCConf     `reload:"restart"`
+	Storage  StorageConf  `reload:"restart"`
+	Webhooks WebhooksConf `reload:"restart"`
+	Tracing  TracingConf  `reload:"restart"`
 }
 
+// AMQPConfig configures the broker, URI and Exchange are required when the bus type is amqp.
 type AMQPConfig struct {
 	URI          string
 	Exchange     string
-	ExchangeType string
+	ExchangeType string `validate:"oneof=direct fanout topic headers"`
 	RoutingKey   string
 	Queue        string
+	Retry        RetryConf
+	Confirm      ConfirmConf
+}
+
+// BusConf selects the message bus transport between the scheduler and the sender.
+// The retry settings of the amqp section apply to every transport.
+type BusConf struct {
+	Type         string `validate:"oneof=amqp memory postgres"` // amqp (default), memory (in-process), postgres
+	DSN          string // postgres, defaults to the storage DSN
+	PollInterval int    `validate:"min=0"` // postgres, in seconds
+	// encoding of published messages: application/json (default), application/x-protobuf
+	ContentType string `validate:"oneof=application/json application/x-protobuf"`
+}
+
+// ConfirmConf configures publisher confirms. With Wait set every publish waits
+// up to Timeout seconds for the broker to acknowledge the message.
+type ConfirmConf struct {
+	Wait    bool
+	Timeout int `validate:"min=0"`
+}
+
+// RetryConf configures redelivery of messages the consumer has failed to handle, delays are in seconds.
+// The delay doubles with every attempt, after MaxAttempts the message goes to the dead-letter queue.
+type RetryConf struct {
+	MaxAttempts  int `validate:"min=0"`
+	InitialDelay int `validate:"min=0"`
+	MaxDelay     int `validate:"min=0"`
 }
 
+// StorageConf selects the storage, DSN is required for sql.
 type StorageConf struct {
 	DSN  string
-	Type string // sql, inmemory
+	Type string `validate:"oneof=sql inmemory"`
 }
 
 type GRPCConf struct {
-	BindAddr string
+	BindAddr string `validate:"required"`
 }
 
 type HTTPConf struct {
+	BindAddr string `validate:"required"`
+}
+
+// TracingConf configures OpenTelemetry tracing. Spans are exported over OTLP/gRPC to Endpoint,
+// printed to stdout or appended to the file at Path, the none exporter disables tracing.
+type TracingConf struct {
+	Exporter    string `validate:"oneof=none otlp stdout file"`
+	ServiceName string `validate:"required"`
+	Endpoint    string // otlp, host:port of the collector
+	Insecure    bool   // otlp, connect without TLS
+	Path        string // file
+	// share of the traces started by the service that are recorded, the services
+	// continuing a trace follow the decision of the caller
+	SampleRatio float64 `validate:"min=0,max=1"`
+}
+
+func defaultTracingConf(serviceName string) TracingConf {
+	return TracingConf{Exporter: "none", ServiceName: serviceName, SampleRatio: 1}
+}
+
+func (c *TracingConf) validate(res *ValidationError) {
+	res.check(c.Exporter != "otlp" || c.Endpoint != "", "tracing.endpoint", "is required for otlp exporter")
+	res.check(c.Exporter != "file" || c.Path != "", "tracing.path", "is required for file exporter")
+}
+
+// AdminConf configures the admin HTTP listener of the scheduler and the sender, it serves /metrics
+// and the health probes /healthz and /readyz. An empty BindAddr disables it.
+type AdminConf struct {
 	BindAddr string
 }
 
+// WebhooksConf configures delivery of outgoing webhooks, intervals are in seconds.
+type WebhooksConf struct {
+	Workers        int `validate:"min=0"`
+	MaxAttempts    int `validate:"min=0"`
+	InitialBackoff int `validate:"min=0"`
+	MaxBackoff     int `validate:"min=0"`
+	Timeout        int `validate:"min=0"`
+}
+
+// LoggerConf configures logging, Packages overrides Level for the loggers of the named packages,
+// e.g. scheduler = "DEBUG". Only the levels are applied on a reload.
 type LoggerConf struct {
-	Level string
-	// TODO
+	Level    string            `validate:"oneof=DEBUG INFO WARN ERROR"`
+	Packages map[string]string // package to level
+	Format   string            `validate:"oneof=text json logfmt" reload:"restart"`
+	// stdout, stderr or a file path, the file is rotated when it grows over MaxSize megabytes
+	// and MaxBackups rotated files are kept
+	Output     string `validate:"required" reload:"restart"`
+	MaxSize    int    `validate:"min=0" reload:"restart"`
+	MaxBackups int    `validate:"min=0" reload:"restart"`
+	// keys whose values are not logged, in addition to the defaults
+	Redact []string `reload:"restart"`
+}
+
+func defaultLoggerConf() LoggerConf {
+	return LoggerConf{Level: "INFO", Format: "text", Output: "stdout"}
+}
+
+func (c *LoggerConf) validate(res *ValidationError) {
+	for name, level := range c.Packages {
+		res.check(slices.Contains([]string{"DEBUG", "INFO", "WARN", "ERROR"}, level), "logger.packages."+name,
+			fmt.Sprintf("must be one of DEBUG, INFO, WARN, ERROR, got %q", level))
+	}
 }
 
+// NewConfig returns the config with defaults, the settings a file does not mention keep them.
 func NewConfig() APIConfig {
-	return APIConfig{}
+	return APIConfig{
+		Logger:  defaultLoggerConf(),
+		GRPC:    GRPCConf{BindAddr: ":50051"},
+		HTTP:    HTTPConf{BindAddr: ":8081"},
+		Storage: StorageConf{Type: "sql"},
+		Tracing: defaultTracingConf("calendar"),
+	}
 }
 
 func (c *APIConfig) LoadFromFile(path string) error {
 	_, err := toml.DecodeFile(path, c)
 	return err
 }
+
+// Load overrides the config with the file, the environment and the flags, in this order,
+// and validates it. An empty path skips the file.
+func (c *APIConfig) Load(path string, flags Overrides) error {
+	return load(c, path, flags)
+}
+
+func (c *APIConfig) Validate() error {
+	res := validateTags(c)
+	c.Logger.validate(res)
+	c.Storage.validate(res)
+	c.Tracing.validate(res)
+	return res.err()
+}
+
+func (c *StorageConf) validate(res *ValidationError) {
+	res.check(c.Type != "sql" || c.DSN != "", "storage.dsn", "is required for sql storage")
+}
+
+// validate checks the settings the bus of the given type needs.
+func (c *AMQPConfig) validate(res *ValidationError, busType string) {
+	if busType != "amqp" {
+		return
+	}
+	res.check(c.URI != "", "amqp.uri", "is required for amqp bus")
+	res.check(c.Exchange != "", "amqp.exchange", "is required for amqp bus")
+}
@@ -0,0 +1,171 @@
+package conf
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/BurntSushi/toml"
+)
+
+// EnvPrefix starts the names of environment variables overriding the config,
+// e.g. CALENDAR_STORAGE_DSN or CALENDAR_AMQP_RETRY_MAX_ATTEMPTS.
+const EnvPrefix = "CALENDAR_"
+
+// Overrides are config values given by command-line flags, by flag name.
+type Overrides map[string]string
+
+// RegisterFlags adds a flag for every setting of cfg, e.g. -storage.dsn or -amqp.retry.max-attempts.
+// The values are not applied until Load, so they take precedence over the file and the environment.
+func RegisterFlags(fs *flag.FlagSet, cfg any) Overrides {
+	overrides := Overrides{}
+	walk(reflect.ValueOf(cfg).Elem(), nil, func(path []string, v reflect.Value) {
+		name := flagName(path)
+		fs.Func(name, fmt.Sprintf("overrides %s of the config (%s, env %s)", name, v.Type(), envName(path)),
+			func(value string) error {
+				overrides[name] = value
+				return nil
+			})
+	})
+	return overrides
+}
+
+// load fills cfg, which holds the defaults, from the file, the environment and the flags
+// in this order and validates the result. An empty path skips the file.
+func load(cfg validator, path string, flags Overrides) error {
+	if path != "" {
+		if _, err := toml.DecodeFile(path, cfg); err != nil {
+			return err
+		}
+	}
+	if err := applyEnv(cfg, os.LookupEnv); err != nil {
+		return err
+	}
+	if err := applyOverrides(cfg, flags); err != nil {
+		return err
+	}
+	return cfg.Validate()
+}
+
+func applyEnv(cfg any, lookup func(string) (string, bool)) error {
+	var err error
+	walk(reflect.ValueOf(cfg).Elem(), nil, func(path []string, v reflect.Value) {
+		name := envName(path)
+		if value, ok := lookup(name); ok && err == nil {
+			if setErr := set(v, value); setErr != nil {
+				err = fmt.Errorf("invalid %s: %w", name, setErr)
+			}
+		}
+	})
+	return err
+}
+
+func applyOverrides(cfg any, overrides Overrides) error {
+	var err error
+	walk(reflect.ValueOf(cfg).Elem(), nil, func(path []string, v reflect.Value) {
+		name := flagName(path)
+		if value, ok := overrides[name]; ok && err == nil {
+			if setErr := set(v, value); setErr != nil {
+				err = fmt.Errorf("invalid -%s: %w", name, setErr)
+			}
+		}
+	})
+	return err
+}
+
+// walk calls fn for every settable leaf of the struct, maps are only configurable in the file.
+func walk(v reflect.Value, path []string, fn func(path []string, v reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fieldPath := append(path[:len(path):len(path)], field.Name)
+		switch field.Type.Kind() { //nolint:exhaustive
+		case reflect.Struct:
+			walk(v.Field(i), fieldPath, fn)
+		case reflect.Map:
+		default:
+			fn(fieldPath, v.Field(i))
+		}
+	}
+}
+
+func set(v reflect.Value, value string) error {
+	switch v.Kind() { //nolint:exhaustive
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// envName turns a field path like AMQP.Retry.MaxAttempts into CALENDAR_AMQP_RETRY_MAX_ATTEMPTS.
+func envName(path []string) string {
+	parts := make([]string, 0, len(path))
+	for _, name := range path {
+		parts = append(parts, strings.ToUpper(strings.Join(words(name), "_")))
+	}
+	return EnvPrefix + strings.Join(parts, "_")
+}
+
+// flagName turns a field path like AMQP.Retry.MaxAttempts into amqp.retry.max-attempts.
+func flagName(path []string) string {
+	parts := make([]string, 0, len(path))
+	for _, name := range path {
+		parts = append(parts, strings.ToLower(strings.Join(words(name), "-")))
+	}
+	return strings.Join(parts, ".")
+}
+
+// words splits a Go identifier into words keeping acronyms whole, e.g. DedupCacheSize, URI, SMTPConf.
+func words(name string) []string {
+	var res []string
+	runes := []rune(name)
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		lowerToUpper := unicode.IsLower(runes[i-1]) && unicode.IsUpper(runes[i])
+		acronymEnd := unicode.IsUpper(runes[i-1]) && unicode.IsUpper(runes[i]) &&
+			i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if lowerToUpper || acronymEnd {
+			res = append(res, string(runes[start:i]))
+			start = i
+		}
+	}
+	return append(res, string(runes[start:]))
+}
@@ -0,0 +1,96 @@
+package conf
+
+import (
+	"errors"
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfigs(t *testing.T) {
+	api := NewConfig()
+	require.NoError(t, api.Load("../../configs/config.toml", nil))
+	scheduler := NewSchedulerConfig()
+	require.NoError(t, scheduler.Load("../../configs/scheduler.toml", nil))
+	sender := NewSenderConfig()
+	require.NoError(t, sender.Load("../../configs/sender.toml", nil))
+	require.Equal(t, 3, sender.AMQP.Retry.MaxAttempts)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	config := NewSchedulerConfig()
+	fs := flag.NewFlagSet("scheduler", flag.ContinueOnError)
+	overrides := RegisterFlags(fs, &config)
+	require.NoError(t, fs.Parse([]string{"-relay-batch-size", "7", "-amqp.retry.max-attempts=9"}))
+
+	t.Setenv("CALENDAR_RELAY_BATCH_SIZE", "5")
+	t.Setenv("CALENDAR_STORAGE_DSN", "postgres://env")
+	t.Setenv("CALENDAR_AMQP_CONFIRM_WAIT", "false")
+	require.NoError(t, config.Load("../../configs/scheduler.toml", overrides))
+
+	require.Equal(t, 7, config.RelayBatchSize, "flags override the environment")
+	require.Equal(t, 9, config.AMQP.Retry.MaxAttempts)
+	require.Equal(t, "postgres://env", config.Storage.DSN, "the environment overrides the file")
+	require.False(t, config.AMQP.Confirm.Wait)
+	require.Equal(t, 30, config.ScanInterval, "the file overrides the defaults")
+	require.Equal(t, 7, config.OutboxRetentionDays)
+	require.Equal(t, "direct", config.AMQP.ExchangeType)
+
+	t.Setenv("CALENDAR_SCAN_INTERVAL", "soon")
+	require.ErrorContains(t, config.Load("", nil), "CALENDAR_SCAN_INTERVAL")
+}
+
+func TestLoadDefaults(t *testing.T) {
+	t.Setenv("CALENDAR_STORAGE_DSN", "postgres://env")
+	t.Setenv("CALENDAR_CHANNELS_DEFAULT", "email, file")
+	config := NewSenderConfig()
+	config.AMQP.URI = "amqp://localhost"
+	config.AMQP.Exchange = "calendar"
+	config.AMQP.Queue = "sender"
+	require.NoError(t, config.Load("", nil))
+	require.Equal(t, []string{"email", "file"}, config.Channels.Default)
+	require.Equal(t, "INFO", config.Logger.Level)
+}
+
+func TestValidate(t *testing.T) {
+	config := NewSchedulerConfig()
+	config.ScanInterval = 0
+	config.LatePolicy = "ignore"
+	config.Bus.Type = "amqp"
+	config.Retention.Users = map[string]int{"u1": -1}
+	// the default lease of 15s does not outlast the retry interval
+	config.Leader.RetryInterval = 15
+
+	err := config.Validate()
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	fields := map[string]bool{}
+	for _, fe := range validationErr.Errors {
+		fields[fe.Field] = true
+	}
+	require.Equal(t, map[string]bool{
+		"scan-interval":         true,
+		"late-policy":           true,
+		"storage.dsn":           true,
+		"amqp.uri":              true,
+		"amqp.exchange":         true,
+		"retention.users.u1":    true,
+		"leader.lease-duration": true,
+	}, fields)
+	require.Contains(t, err.Error(), "scan-interval: must be at least 1, got 0")
+	require.Contains(t, err.Error(), "leader.lease-duration: must be greater than leader.retry-interval (15s), got 15s")
+
+	api := NewConfig()
+	api.Storage.Type = "inmemory"
+	require.NoError(t, api.Validate())
+}
+
+func TestNames(t *testing.T) {
+	path := []string{"AMQP", "Retry", "MaxAttempts"}
+	require.Equal(t, "CALENDAR_AMQP_RETRY_MAX_ATTEMPTS", envName(path))
+	require.Equal(t, "amqp.retry.max-attempts", flagName(path))
+	require.Equal(t, []string{"SMTP", "Conf"}, words("SMTPConf"))
+	require.Equal(t, []string{"Bind", "Addr"}, words("BindAddr"))
+	require.Equal(t, []string{"URI"}, words("URI"))
+}
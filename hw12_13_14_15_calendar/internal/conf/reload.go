@@ -0,0 +1,168 @@
+package conf
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Reloader loads the config again on SIGHUP or when its file changes and passes it to the subscribers.
+// Settings tagged reload:"restart" are used only at startup, a reload changing them is rejected
+// as a whole and the current config stays in effect.
+type Reloader[T any] struct {
+	path      string
+	overrides Overrides
+	load      func(path string, overrides Overrides) (*T, error)
+
+	mu          sync.Mutex
+	current     *T
+	subscribers []func(*T)
+}
+
+func newReloader[T any](
+	current *T, path string, overrides Overrides, load func(string, Overrides) (*T, error),
+) *Reloader[T] {
+	return &Reloader[T]{path: path, overrides: overrides, load: load, current: current}
+}
+
+func NewAPIReloader(current *APIConfig, path string, overrides Overrides) *Reloader[APIConfig] {
+	return newReloader(current, path, overrides, func(path string, overrides Overrides) (*APIConfig, error) {
+		c := NewConfig()
+		return &c, c.Load(path, overrides)
+	})
+}
+
+func NewSchedulerReloader(current *SchedulerConfig, path string, overrides Overrides) *Reloader[SchedulerConfig] {
+	return newReloader(current, path, overrides, func(path string, overrides Overrides) (*SchedulerConfig, error) {
+		c := NewSchedulerConfig()
+		return &c, c.Load(path, overrides)
+	})
+}
+
+func NewSenderReloader(current *SenderConfig, path string, overrides Overrides) *Reloader[SenderConfig] {
+	return newReloader(current, path, overrides, func(path string, overrides Overrides) (*SenderConfig, error) {
+		c := NewSenderConfig()
+		return c, c.Load(path, overrides)
+	})
+}
+
+// Subscribe registers fn to receive every config accepted by a reload.
+// The config passed to fn must not be modified.
+func (r *Reloader[T]) Subscribe(fn func(*T)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscribers = append(r.subscribers, fn)
+}
+
+// Current returns the config in effect.
+func (r *Reloader[T]) Current() *T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Reload loads the config and passes it to the subscribers unless it is invalid
+// or changes settings that need a restart.
+func (r *Reloader[T]) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	next, err := r.load(r.path, r.overrides)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if changed := restartChanges(reflect.ValueOf(r.current).Elem(), reflect.ValueOf(next).Elem(), nil); len(changed) > 0 {
+		return fmt.Errorf("config is not reloaded, changes of %s need a restart", strings.Join(changed, ", "))
+	}
+	r.current = next
+	for _, fn := range r.subscribers {
+		fn(next)
+	}
+	return nil
+}
+
+// Start reloads the config on SIGHUP and, if watchInterval is positive, when the modification time
+// of the file changes, until ctx is done. The result of every reload is passed to report.
+// SIGHUP is handled from the moment Start returns, so it no longer terminates the process.
+func (r *Reloader[T]) Start(ctx context.Context, watchInterval time.Duration, report func(err error)) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	modified := r.modTime()
+	go func() {
+		defer signal.Stop(signals)
+		r.run(ctx, signals, modified, watchInterval, report)
+	}()
+}
+
+func (r *Reloader[T]) run(ctx context.Context, signals <-chan os.Signal, modified time.Time,
+	watchInterval time.Duration, report func(err error),
+) {
+	var watch <-chan time.Time
+	if watchInterval > 0 && r.path != "" {
+		ticker := time.NewTicker(watchInterval)
+		defer ticker.Stop()
+		watch = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			report(r.Reload())
+		case <-watch:
+			if t := r.modTime(); !t.Equal(modified) {
+				modified = t
+				report(r.Reload())
+			}
+		}
+	}
+}
+
+func (r *Reloader[T]) modTime() time.Time {
+	if r.path == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// restartChanges returns the names of the settings tagged reload:"restart" that differ.
+func restartChanges(old, next reflect.Value, path []string) []string {
+	var changed []string
+	t := old.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldPath := append(path[:len(path):len(path)], field.Name)
+		switch {
+		case field.Tag.Get("reload") == "restart":
+			changed = append(changed, diff(old.Field(i), next.Field(i), fieldPath)...)
+		case field.Type.Kind() == reflect.Struct:
+			changed = append(changed, restartChanges(old.Field(i), next.Field(i), fieldPath)...)
+		}
+	}
+	return changed
+}
+
+// diff returns the names of the settings that differ.
+func diff(old, next reflect.Value, path []string) []string {
+	if old.Kind() != reflect.Struct {
+		if reflect.DeepEqual(old.Interface(), next.Interface()) {
+			return nil
+		}
+		return []string{flagName(path)}
+	}
+	var changed []string
+	for i := 0; i < old.NumField(); i++ {
+		fieldPath := append(path[:len(path):len(path)], old.Type().Field(i).Name)
+		changed = append(changed, diff(old.Field(i), next.Field(i), fieldPath)...)
+	}
+	return changed
+}
@@ -0,0 +1,100 @@
+package conf
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const reloadConfig = `
+[logger]
+level = "%s"
+
+[grpc]
+bindAddr = "%s"
+
+[storage]
+type = "inmemory"
+`
+
+func writeConfig(t *testing.T, path, level, bindAddr string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf(reloadConfig, level, bindAddr)), 0o600))
+}
+
+func TestReloader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	writeConfig(t, path, "INFO", ":50051")
+	config := NewConfig()
+	require.NoError(t, config.Load(path, nil))
+
+	reloader := NewAPIReloader(&config, path, nil)
+	var levels []string
+	reloader.Subscribe(func(c *APIConfig) { levels = append(levels, c.Logger.Level) })
+
+	writeConfig(t, path, "DEBUG", ":50051")
+	require.NoError(t, reloader.Reload())
+	require.Equal(t, []string{"DEBUG"}, levels)
+	require.Equal(t, "DEBUG", reloader.Current().Logger.Level)
+
+	// a changed bind address rejects the whole reload
+	writeConfig(t, path, "ERROR", ":50052")
+	require.ErrorContains(t, reloader.Reload(), "grpc.bind-addr")
+	require.Equal(t, "DEBUG", reloader.Current().Logger.Level)
+
+	writeConfig(t, path, "LOUD", ":50051")
+	require.ErrorContains(t, reloader.Reload(), "logger.level")
+	require.Equal(t, []string{"DEBUG"}, levels)
+}
+
+func TestReloaderStart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	writeConfig(t, path, "INFO", ":50051")
+	config := NewConfig()
+	require.NoError(t, config.Load(path, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reloader := NewAPIReloader(&config, path, nil)
+	reloaded := make(chan string, 2)
+	reloader.Subscribe(func(c *APIConfig) { reloaded <- c.Logger.Level })
+	reloader.Start(ctx, 10*time.Millisecond, func(err error) { require.NoError(t, err) })
+
+	// the file is watched
+	writeConfig(t, path, "WARN", ":50051")
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Second)))
+	require.Equal(t, "WARN", receive(t, reloaded))
+
+	// SIGHUP reloads as well
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+	require.Equal(t, "WARN", receive(t, reloaded))
+}
+
+func receive(t *testing.T, ch <-chan string) string {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatal("config was not reloaded")
+		return ""
+	}
+}
+
+func TestRestartChanges(t *testing.T) {
+	old := NewSchedulerConfig()
+	next := NewSchedulerConfig()
+	next.ScanInterval = 5
+	next.Retention.Users = map[string]int{"u1": 30}
+	next.Storage.DSN = "postgres://other"
+	next.Leader.LockKey = 1
+	changed := restartChanges(reflect.ValueOf(old), reflect.ValueOf(next), nil)
+	require.Equal(t, []string{"storage.dsn", "leader.lock-key"}, changed)
+}
@@ -1,21 +1,105 @@
 package conf
 
-import "github.com/BurntSushi/toml"
+import (
+	"fmt"
 
+	"github.com/BurntSushi/toml"
+)
+
+// SchedulerConfig is the config of the scheduler, intervals are in seconds. Settings tagged
+// reload:"restart" take effect only after a restart, the rest is applied by App.Reload.
 type SchedulerConfig struct {
-	CleanInterval      int
-	CleanThresholdDays int
-	ScanInterval       int
-	Logger             LoggerConf
-	Storage            StorageConf
-	AMQP               AMQPConfig
+	CleanInterval       int `validate:"min=1"`
+	CleanThresholdDays  int `validate:"min=0"` // events are archived this many days after they start, 0 keeps them
+	ScanInterval        int `validate:"min=1"` // how often the timers are reconciled with the storage
+	RelayInterval       int `validate:"min=1"`
+	RelayBatchSize      int `validate:"min=1"`
+	OutboxRetentionDays int `validate:"min=0"`
+	MaxLateness         int `validate:"min=0"` // reminders missed by more are handled by LatePolicy, 0 means no limit
+
+	LatePolicy string `validate:"oneof=drop send"` // drop, send (marked as late)
+	Logger     LoggerConf
+	Storage    StorageConf  `reload:"restart"`
+	AMQP       AMQPConfig   `reload:"restart"`
+	Bus        BusConf      `reload:"restart"`
+	Webhooks   WebhooksConf `reload:"restart"`
+	Leader     LeaderConf   `reload:"restart"`
+	Admin      AdminConf    `reload:"restart"`
+	Tracing    TracingConf  `reload:"restart"`
+	Retention  RetentionConf
+}
+
+// RetentionConf overrides CleanThresholdDays per user.
+type RetentionConf struct {
+	Users map[string]int // user ID to days, 0 keeps the events of the user
 }
 
+// LeaderConf configures leader election among scheduler replicas, intervals are in seconds.
+// A standby takes over within about LeaseDuration + RetryInterval after the leader is gone.
+type LeaderConf struct {
+	LockKey       int64
+	RetryInterval int `validate:"min=0"`
+	LeaseDuration int `validate:"min=0"`
+}
+
+// the leader election intervals used when LeaderConf leaves them 0, in seconds.
+const (
+	DefaultLeaderRetryInterval = 5
+	DefaultLeaderLeaseDuration = 15
+)
+
+// validate checks that the lease outlasts the retry interval, otherwise the leader loses it
+// between renewals and the replicas take turns.
+func (c *LeaderConf) validate(res *ValidationError) {
+	retry, lease := c.RetryInterval, c.LeaseDuration
+	if retry <= 0 {
+		retry = DefaultLeaderRetryInterval
+	}
+	if lease <= 0 {
+		lease = DefaultLeaderLeaseDuration
+	}
+	res.check(lease > retry, "leader.lease-duration",
+		fmt.Sprintf("must be greater than leader.retry-interval (%ds), got %ds", retry, lease))
+}
+
+// NewSchedulerConfig returns the config with defaults, the settings a file does not mention keep them.
 func NewSchedulerConfig() SchedulerConfig {
-	return SchedulerConfig{}
+	return SchedulerConfig{
+		CleanInterval:       3600,
+		ScanInterval:        30,
+		RelayInterval:       5,
+		RelayBatchSize:      100,
+		OutboxRetentionDays: 7,
+		LatePolicy:          "send",
+		Logger:              defaultLoggerConf(),
+		Storage:             StorageConf{Type: "sql"},
+		AMQP:                AMQPConfig{ExchangeType: "direct"},
+		Bus:                 BusConf{Type: "amqp", ContentType: "application/json"},
+		Admin:               AdminConf{BindAddr: ":9101"},
+		Tracing:             defaultTracingConf("calendar-scheduler"),
+	}
 }
 
 func (c *SchedulerConfig) LoadFromFile(path string) error {
 	_, err := toml.DecodeFile(path, c)
 	return err
 }
+
+// Load overrides the config with the file, the environment and the flags, in this order,
+// and validates it. An empty path skips the file.
+func (c *SchedulerConfig) Load(path string, flags Overrides) error {
+	return load(c, path, flags)
+}
+
+func (c *SchedulerConfig) Validate() error {
+	res := validateTags(c)
+	c.Logger.validate(res)
+	c.Storage.validate(res)
+	c.Tracing.validate(res)
+	c.AMQP.validate(res, c.Bus.Type)
+	c.Leader.validate(res)
+	for user, days := range c.Retention.Users {
+		res.check(days >= 0, "retention.users."+user, "must be at least 0")
+	}
+	return res.err()
+}
@@ -2,17 +2,80 @@ package conf
 
 import "github.com/BurntSushi/toml"
 
+// SenderConfig is the config of the sender, only the log level is applied without a restart.
 type SenderConfig struct {
-	Logger  LoggerConf
-	Storage StorageConf
-	AMQP    AMQPConfig
+	DedupCacheSize int `validate:"min=0" reload:"restart"`
+	Logger         LoggerConf
+	Storage        StorageConf                `reload:"restart"`
+	AMQP           AMQPConfig                 `reload:"restart"`
+	Bus            BusConf                    `reload:"restart"`
+	Channels       ChannelsConf               `reload:"restart"`
+	Users          map[string]UserPreferences `reload:"restart"`
+	Admin          AdminConf                  `reload:"restart"`
+	Tracing        TracingConf                `reload:"restart"`
 }
 
+// ChannelsConf configures notification channels of the sender.
+// A channel is available only if its section is filled in, stdout is always available.
+type ChannelsConf struct {
+	Default []string // used when neither the reminder nor the user specify channels
+	SMTP    SMTPConf
+	Webhook WebhookConf
+	File    FileConf
+}
+
+type SMTPConf struct {
+	Addr     string
+	From     string
+	Username string
+	Password string
+}
+
+type WebhookConf struct {
+	URL     string
+	Secret  string
+	Timeout int `validate:"min=0"` // in seconds
+}
+
+type FileConf struct {
+	Path string
+}
+
+// UserPreferences are per user notification settings.
+type UserPreferences struct {
+	Channels []string
+	Email    string
+}
+
+// NewSenderConfig returns the config with defaults, the settings a file does not mention keep them.
 func NewSenderConfig() *SenderConfig {
-	return &SenderConfig{}
+	return &SenderConfig{
+		Logger:  defaultLoggerConf(),
+		Storage: StorageConf{Type: "sql"},
+		AMQP:    AMQPConfig{ExchangeType: "direct"},
+		Bus:     BusConf{Type: "amqp", ContentType: "application/json"},
+		Admin:   AdminConf{BindAddr: ":9102"},
+		Tracing: defaultTracingConf("calendar-sender"),
+	}
 }
 
 func (c *SenderConfig) LoadFromFile(path string) error {
 	_, err := toml.DecodeFile(path, c)
 	return err
 }
+
+// Load overrides the config with the file, the environment and the flags, in this order,
+// and validates it. An empty path skips the file.
+func (c *SenderConfig) Load(path string, flags Overrides) error {
+	return load(c, path, flags)
+}
+
+func (c *SenderConfig) Validate() error {
+	res := validateTags(c)
+	c.Logger.validate(res)
+	c.Storage.validate(res)
+	c.Tracing.validate(res)
+	c.AMQP.validate(res, c.Bus.Type)
+	res.check(c.Bus.Type != "amqp" || c.AMQP.Queue != "", "amqp.queue", "is required for amqp bus")
+	return res.err()
+}
@@ -0,0 +1,129 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var errBadRule = errors.New("invalid validation rule")
+
+type validator interface {
+	Validate() error
+}
+
+// FieldError describes an invalid setting, Field is named like its flag.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// ValidationError lists every invalid setting of a config.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		lines[i] = fmt.Sprintf("%s: %s", fe.Field, fe.Message)
+	}
+	return "invalid config: " + strings.Join(lines, "; ")
+}
+
+func (e *ValidationError) add(field, format string, args ...any) {
+	e.Errors = append(e.Errors, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// check adds an error unless ok, it is used for rules that do not fit a tag.
+func (e *ValidationError) check(ok bool, field, message string) {
+	if !ok {
+		e.add(field, "%s", message)
+	}
+}
+
+func (e *ValidationError) err() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+// validateTags checks the validate tags of the settings, rules are separated by commas:
+//
+//	required    the value is not empty
+//	min=N       a number is at least N
+//	max=N       a number is at most N
+//	oneof=a b   the value is one of the listed, spaces separate the values
+func validateTags(cfg any) *ValidationError {
+	res := &ValidationError{}
+	walkTags(reflect.ValueOf(cfg).Elem(), nil, func(path []string, v reflect.Value, tag string) {
+		for _, rule := range strings.Split(tag, ",") {
+			if msg := checkRule(v, rule); msg != "" {
+				res.add(flagName(path), "%s", msg)
+				return
+			}
+		}
+	})
+	return res
+}
+
+func walkTags(v reflect.Value, path []string, fn func(path []string, v reflect.Value, tag string)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldPath := append(path[:len(path):len(path)], field.Name)
+		if field.Type.Kind() == reflect.Struct {
+			walkTags(v.Field(i), fieldPath, fn)
+			continue
+		}
+		if tag := field.Tag.Get("validate"); tag != "" {
+			fn(fieldPath, v.Field(i), tag)
+		}
+	}
+}
+
+// checkRule returns what is wrong with the value, an empty string if it satisfies the rule.
+func checkRule(v reflect.Value, rule string) string {
+	name, arg, _ := strings.Cut(rule, "=")
+	switch name {
+	case "required":
+		if v.IsZero() {
+			return "is required"
+		}
+	case "min", "max":
+		limit, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			panic(fmt.Errorf("%w %q: %w", errBadRule, rule, err))
+		}
+		n := number(v)
+		if name == "min" && n < limit {
+			return fmt.Sprintf("must be at least %s, got %v", arg, v.Interface())
+		}
+		if name == "max" && n > limit {
+			return fmt.Sprintf("must be at most %s, got %v", arg, v.Interface())
+		}
+	case "oneof":
+		values := strings.Fields(arg)
+		if !slices.Contains(values, v.String()) {
+			return fmt.Sprintf("must be one of %s, got %q", strings.Join(values, ", "), v.String())
+		}
+	default:
+		panic(fmt.Errorf("%w %q", errBadRule, rule))
+	}
+	return ""
+}
+
+func number(v reflect.Value) float64 {
+	switch v.Kind() { //nolint:exhaustive
+	case reflect.Int, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Float64:
+		return v.Float()
+	default:
+		panic(fmt.Errorf("%w: %s is not a number", errBadRule, v.Type()))
+	}
+}
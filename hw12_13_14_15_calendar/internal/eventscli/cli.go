@@ -0,0 +1,279 @@
+// Package eventscli implements the events commands of cli-tools, a client of the gRPC EventService.
+package eventscli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/gen/events/pb"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const Usage = "usage: events create|update ID|delete ID...|get ID|day|week|month [DATE] [-format table|json|csv], " +
+	"see events <command> -help"
+
+var ErrUsage = errors.New(Usage)
+
+// Dial connects to the EventService at addr.
+func Dial(addr string) (*grpc.ClientConn, error) {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	return conn, nil
+}
+
+// CLI runs the events commands against the client and writes the results to out.
+type CLI struct {
+	client pb.EventServiceClient
+	out    io.Writer
+	now    func() time.Time
+}
+
+func New(client pb.EventServiceClient, out io.Writer) *CLI {
+	return &CLI{client: client, out: out, now: time.Now}
+}
+
+// Run runs the command given by args, e.g. ["day", "tomorrow", "-format", "json"].
+func (c *CLI) Run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return ErrUsage
+	}
+	switch args[0] {
+	case "create":
+		return c.create(ctx, args[1:])
+	case "update":
+		return c.update(ctx, args[1:])
+	case "delete":
+		return c.remove(ctx, args[1:])
+	case "get":
+		return c.get(ctx, args[1:])
+	case "day", "week", "month":
+		return c.filter(ctx, args[0], args[1:])
+	default:
+		return fmt.Errorf("unknown events command %q: %w", args[0], ErrUsage)
+	}
+}
+
+// eventFlags are the flags of create and update.
+type eventFlags struct {
+	fs        *flag.FlagSet
+	format    *string
+	id        *string
+	title     *string
+	start     *string
+	end       *string
+	duration  *time.Duration
+	user      *string
+	reminders reminderList
+}
+
+func newEventFlags(name string) *eventFlags {
+	f := &eventFlags{fs: flag.NewFlagSet(name, flag.ContinueOnError)}
+	f.format = f.fs.String("format", FormatTable, "Output format: table, json or csv")
+	f.title = f.fs.String("title", "", "Title of the event")
+	f.start = f.fs.String("start", "", "Start time, e.g. 'tomorrow 10:00', 'fri 9:30', '2024-12-31 18:00', +2h")
+	f.end = f.fs.String("end", "", "End time, in the same forms as -start")
+	f.duration = f.fs.Duration("duration", time.Hour, "Duration of the event, used when -end is not set")
+	f.user = f.fs.String("user", "", "Owner of the event")
+	f.fs.Var(&f.reminders, "remind", "Reminder before the start as OFFSET[:CHANNEL], e.g. 10m:email, repeatable")
+	return f
+}
+
+func (c *CLI) create(ctx context.Context, args []string) error {
+	f := newEventFlags("create")
+	f.id = f.fs.String("id", "", "Event ID, generated when empty")
+	if err := f.fs.Parse(args); err != nil {
+		return err
+	}
+	if *f.title == "" || *f.start == "" {
+		return errors.New("create: -title and -start are required")
+	}
+	event := &pb.Event{Id: *f.id, UserId: *f.user, Title: *f.title}
+	if event.Id == "" {
+		event.Id = uuid.NewString()
+	}
+	if err := c.applyTimes(event, f, true); err != nil {
+		return err
+	}
+	event.Reminders = f.reminders
+	res, err := c.client.CreateEvent(ctx, &pb.CreateEventRequest{Event: event})
+	if err != nil {
+		return fmt.Errorf("failed to create event: %w", err)
+	}
+	return WriteEvents(c.out, *f.format, c.now().Location(), []*pb.Event{res.GetEvent()})
+}
+
+// update changes only the fields given by flags, the rest is kept as stored.
+func (c *CLI) update(ctx context.Context, args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return errors.New("update: event ID is required")
+	}
+	f := newEventFlags("update")
+	if err := f.fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	got, err := c.client.GetEvent(ctx, &pb.GetEventRequest{Id: args[0]})
+	if err != nil {
+		return fmt.Errorf("failed to get event: %w", err)
+	}
+	event := got.GetEvent()
+	set := map[string]bool{}
+	f.fs.Visit(func(fl *flag.Flag) { set[fl.Name] = true })
+	if set["title"] {
+		event.Title = *f.title
+	}
+	if set["user"] {
+		event.UserId = *f.user
+	}
+	if err := c.applyTimes(event, f, set["duration"]); err != nil {
+		return err
+	}
+	if set["remind"] {
+		event.Reminders = f.reminders
+	}
+	res, err := c.client.UpdateEvent(ctx, &pb.UpdateEventRequest{Event: event})
+	if err != nil {
+		return fmt.Errorf("failed to update event: %w", err)
+	}
+	return WriteEvents(c.out, *f.format, c.now().Location(), []*pb.Event{res.GetEvent()})
+}
+
+// applyTimes sets the start and the end of the event from the flags. Without -end the end
+// is start + duration if useDuration is set, otherwise the event keeps its length.
+func (c *CLI) applyTimes(event *pb.Event, f *eventFlags, useDuration bool) error {
+	now := c.now()
+	start := event.GetStart().AsTime()
+	length := event.GetEnd().AsTime().Sub(start)
+	if *f.start != "" {
+		t, err := ParseTime(*f.start, now)
+		if err != nil {
+			return err
+		}
+		start = t
+		event.Start = timestamppb.New(start)
+	}
+	switch {
+	case *f.end != "":
+		end, err := ParseTime(*f.end, now)
+		if err != nil {
+			return err
+		}
+		event.End = timestamppb.New(end)
+	case useDuration:
+		event.End = timestamppb.New(start.Add(*f.duration))
+	case *f.start != "":
+		event.End = timestamppb.New(start.Add(length))
+	}
+	if event.GetEnd().AsTime().Before(start) {
+		return errors.New("the event ends before it starts")
+	}
+	return nil
+}
+
+func (c *CLI) remove(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return errors.New("delete: event ID is required")
+	}
+	for _, id := range ids {
+		if _, err := c.client.RemoveEvent(ctx, &pb.RemoveEventRequest{Id: id}); err != nil {
+			return fmt.Errorf("failed to delete event %s: %w", id, err)
+		}
+		fmt.Fprintf(c.out, "deleted %s\n", id)
+	}
+	return nil
+}
+
+func (c *CLI) get(ctx context.Context, args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return errors.New("get: event ID is required")
+	}
+	fs := flag.NewFlagSet("get", flag.ContinueOnError)
+	format := fs.String("format", FormatTable, "Output format: table, json or csv")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	res, err := c.client.GetEvent(ctx, &pb.GetEventRequest{Id: args[0]})
+	if err != nil {
+		return fmt.Errorf("failed to get event: %w", err)
+	}
+	return WriteEvents(c.out, *format, c.now().Location(), []*pb.Event{res.GetEvent()})
+}
+
+// filter lists the events of the period containing the date, today by default.
+// The date may span several arguments, e.g. day tomorrow 10:00.
+func (c *CLI) filter(ctx context.Context, period string, args []string) error {
+	var words []string
+	for len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		words = append(words, args[0])
+		args = args[1:]
+	}
+	fs := flag.NewFlagSet(period, flag.ContinueOnError)
+	format := fs.String("format", FormatTable, "Output format: table, json or csv")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	now := c.now()
+	date := now
+	if len(words) > 0 {
+		t, err := ParseTime(strings.Join(words, " "), now)
+		if err != nil {
+			return err
+		}
+		date = t
+	}
+
+	var events []*pb.Event
+	var err error
+	switch period {
+	case "day":
+		var res *pb.FilterEventsByDayResponse
+		res, err = c.client.FilterEventsByDay(ctx, &pb.FilterEventsByDayRequest{Date: timestamppb.New(date)})
+		events = res.GetEvents()
+	case "week":
+		var res *pb.FilterEventsByWeekResponse
+		res, err = c.client.FilterEventsByWeek(ctx, &pb.FilterEventsByWeekRequest{Date: timestamppb.New(date)})
+		events = res.GetEvents()
+	case "month":
+		var res *pb.FilterEventsByMonthResponse
+		res, err = c.client.FilterEventsByMonth(ctx, &pb.FilterEventsByMonthRequest{Date: timestamppb.New(date)})
+		events = res.GetEvents()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list events: %w", err)
+	}
+	return WriteEvents(c.out, *format, now.Location(), events)
+}
+
+// reminderList is a repeatable flag of reminders given as OFFSET[:CHANNEL].
+type reminderList []*pb.Reminder
+
+func (l *reminderList) String() string {
+	if l == nil {
+		return ""
+	}
+	s := make([]string, len(*l))
+	for i, r := range *l {
+		s[i] = formatReminder(r)
+	}
+	return strings.Join(s, ",")
+}
+
+func (l *reminderList) Set(value string) error {
+	offset, channel, _ := strings.Cut(value, ":")
+	d, err := time.ParseDuration(offset)
+	if err != nil {
+		return fmt.Errorf("invalid reminder offset %q: %w", offset, err)
+	}
+	*l = append(*l, &pb.Reminder{Offset: durationpb.New(d), Channel: channel})
+	return nil
+}
@@ -0,0 +1,126 @@
+package eventscli
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/gen/events/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeClient keeps the events in a map, methods not used by the CLI panic.
+type fakeClient struct {
+	pb.EventServiceClient
+	events map[string]*pb.Event
+	date   time.Time
+}
+
+func (f *fakeClient) CreateEvent(_ context.Context, r *pb.CreateEventRequest, _ ...grpc.CallOption) (
+	*pb.CreateEventResponse, error,
+) {
+	f.events[r.GetEvent().GetId()] = r.GetEvent()
+	return &pb.CreateEventResponse{Event: r.GetEvent()}, nil
+}
+
+func (f *fakeClient) UpdateEvent(_ context.Context, r *pb.UpdateEventRequest, _ ...grpc.CallOption) (
+	*pb.UpdateEventResponse, error,
+) {
+	f.events[r.GetEvent().GetId()] = r.GetEvent()
+	return &pb.UpdateEventResponse{Event: r.GetEvent()}, nil
+}
+
+func (f *fakeClient) GetEvent(_ context.Context, r *pb.GetEventRequest, _ ...grpc.CallOption) (
+	*pb.GetEventResponse, error,
+) {
+	// a copy, like a real response
+	return &pb.GetEventResponse{Event: proto.Clone(f.events[r.GetId()]).(*pb.Event)}, nil
+}
+
+func (f *fakeClient) FilterEventsByDay(_ context.Context, r *pb.FilterEventsByDayRequest, _ ...grpc.CallOption) (
+	*pb.FilterEventsByDayResponse, error,
+) {
+	f.date = r.GetDate().AsTime()
+	res := &pb.FilterEventsByDayResponse{}
+	for _, e := range f.events {
+		res.Events = append(res.Events, e)
+	}
+	return res, nil
+}
+
+func TestCLI(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeClient{events: map[string]*pb.Event{}}
+	out := &bytes.Buffer{}
+	c := New(client, out)
+	c.now = func() time.Time { return time.Date(2024, 10, 2, 14, 30, 0, 0, time.UTC) }
+
+	err := c.Run(ctx, []string{
+		"create", "-id", "1", "-title", "Standup", "-start", "tomorrow 10:00", "-duration", "15m",
+		"-remind", "10m:email", "-remind", "1h",
+	})
+	require.NoError(t, err)
+	event := client.events["1"]
+	require.Equal(t, "Standup", event.GetTitle())
+	require.Equal(t, time.Date(2024, 10, 3, 10, 0, 0, 0, time.UTC), event.GetStart().AsTime())
+	require.Equal(t, time.Date(2024, 10, 3, 10, 15, 0, 0, time.UTC), event.GetEnd().AsTime())
+	require.Len(t, event.GetReminders(), 2)
+	require.Equal(t, 10*time.Minute, event.GetReminders()[0].GetOffset().AsDuration())
+	require.Equal(t, "email", event.GetReminders()[0].GetChannel())
+
+	// moving the event keeps its length and the fields not given
+	require.NoError(t, c.Run(ctx, []string{"update", "1", "-start", "tomorrow 11:00"}))
+	event = client.events["1"]
+	require.Equal(t, "Standup", event.GetTitle())
+	require.Equal(t, time.Date(2024, 10, 3, 11, 15, 0, 0, time.UTC), event.GetEnd().AsTime())
+	require.Len(t, event.GetReminders(), 2)
+
+	require.Error(t, c.Run(ctx, []string{"update", "1", "-end", "today"}))
+	require.Error(t, c.Run(ctx, []string{"create", "-title", "No start"}))
+	require.ErrorIs(t, c.Run(ctx, []string{"unknown"}), ErrUsage)
+
+	out.Reset()
+	require.NoError(t, c.Run(ctx, []string{"day", "tomorrow", "-format", "csv"}))
+	require.Equal(t, time.Date(2024, 10, 3, 0, 0, 0, 0, time.UTC), client.date)
+	records, err := csv.NewReader(out).ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, [][]string{
+		header,
+		{"1", "Standup", "2024-10-03T11:00:00Z", "2024-10-03T11:15:00Z", "", "10m0s:email 1h0m0s"},
+	}, records)
+}
+
+func TestWriteEvents(t *testing.T) {
+	events := []*pb.Event{{
+		Id:     "1",
+		Title:  "Lunch",
+		Start:  timestamppb.New(time.Date(2024, 10, 2, 12, 0, 0, 0, time.UTC)),
+		End:    timestamppb.New(time.Date(2024, 10, 2, 13, 0, 0, 0, time.UTC)),
+		UserId: "alice",
+	}}
+	loc := time.FixedZone("UTC+5", 5*60*60)
+
+	out := &bytes.Buffer{}
+	require.NoError(t, WriteEvents(out, FormatTable, loc, events))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	require.True(t, strings.HasPrefix(lines[0], "ID"))
+	require.Contains(t, lines[1], "2024-10-02 17:00")
+
+	out.Reset()
+	require.NoError(t, WriteEvents(out, FormatJSON, loc, events))
+	var decoded []eventJSON
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	require.Len(t, decoded, 1)
+	require.Equal(t, "alice", decoded[0].UserID)
+	require.True(t, events[0].GetStart().AsTime().Equal(decoded[0].Start))
+
+	require.Error(t, WriteEvents(out, "xml", loc, events))
+}
@@ -0,0 +1,123 @@
+package eventscli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/gen/events/pb"
+)
+
+// Output formats of the events.
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatCSV   = "csv"
+)
+
+var header = []string{"id", "title", "start", "end", "user", "reminders"}
+
+// eventJSON is the JSON form of an event, it spells out times and durations
+// instead of the protobuf well-known types.
+type eventJSON struct {
+	ID        string         `json:"id"`
+	Title     string         `json:"title"`
+	Start     time.Time      `json:"start"`
+	End       time.Time      `json:"end"`
+	UserID    string         `json:"userId"`
+	Reminders []reminderJSON `json:"reminders,omitempty"`
+}
+
+type reminderJSON struct {
+	Offset  string `json:"offset"`
+	Channel string `json:"channel,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// WriteEvents writes the events in the given format, times are shown in loc.
+func WriteEvents(out io.Writer, format string, loc *time.Location, events []*pb.Event) error {
+	switch format {
+	case FormatTable, "":
+		return writeTable(out, loc, events)
+	case FormatJSON:
+		return writeJSON(out, loc, events)
+	case FormatCSV:
+		return writeCSV(out, loc, events)
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+func writeTable(out io.Writer, loc *time.Location, events []*pb.Event) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.ToUpper(strings.Join(header, "\t")))
+	for _, e := range events {
+		fmt.Fprintln(w, strings.Join(row(e, loc, "2006-01-02 15:04"), "\t"))
+	}
+	return w.Flush()
+}
+
+func writeCSV(out io.Writer, loc *time.Location, events []*pb.Event) error {
+	w := csv.NewWriter(out)
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	for _, e := range events {
+		if err := w.Write(row(e, loc, time.RFC3339)); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func writeJSON(out io.Writer, loc *time.Location, events []*pb.Event) error {
+	res := make([]eventJSON, len(events))
+	for i, e := range events {
+		res[i] = eventJSON{
+			ID:     e.GetId(),
+			Title:  e.GetTitle(),
+			Start:  e.GetStart().AsTime().In(loc),
+			End:    e.GetEnd().AsTime().In(loc),
+			UserID: e.GetUserId(),
+		}
+		for _, r := range e.GetReminders() {
+			res[i].Reminders = append(res[i].Reminders, reminderJSON{
+				Offset:  r.GetOffset().AsDuration().String(),
+				Channel: r.GetChannel(),
+				Message: r.GetMessage(),
+			})
+		}
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
+}
+
+func row(e *pb.Event, loc *time.Location, layout string) []string {
+	reminders := make([]string, len(e.GetReminders()))
+	for i, r := range e.GetReminders() {
+		reminders[i] = formatReminder(r)
+	}
+	return []string{
+		e.GetId(),
+		e.GetTitle(),
+		e.GetStart().AsTime().In(loc).Format(layout),
+		e.GetEnd().AsTime().In(loc).Format(layout),
+		e.GetUserId(),
+		strings.Join(reminders, " "),
+	}
+}
+
+// formatReminder is the inverse of the -remind flag, e.g. 10m0s:email.
+func formatReminder(r *pb.Reminder) string {
+	s := r.GetOffset().AsDuration().String()
+	if r.GetChannel() != "" {
+		s += ":" + r.GetChannel()
+	}
+	return s
+}
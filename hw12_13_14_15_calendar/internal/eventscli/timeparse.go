@@ -0,0 +1,89 @@
+package eventscli
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrBadTime = errors.New("cannot parse time")
+
+var (
+	dateLayouts = []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02"}
+	weekdays    = map[string]time.Weekday{
+		"sunday": time.Sunday, "sun": time.Sunday,
+		"monday": time.Monday, "mon": time.Monday,
+		"tuesday": time.Tuesday, "tue": time.Tuesday,
+		"wednesday": time.Wednesday, "wed": time.Wednesday,
+		"thursday": time.Thursday, "thu": time.Thursday,
+		"friday": time.Friday, "fri": time.Friday,
+		"saturday": time.Saturday, "sat": time.Saturday,
+	}
+)
+
+// ParseTime parses a time in the location of now. It accepts:
+//
+//	now, +90m, -2h, +3d                       relative to now
+//	today, tomorrow, yesterday [15:04]         the day, at midnight by default
+//	monday..sunday, mon..sun [15:04]           the next such day after today
+//	2006-01-02 [15:04], 2006-01-02T15:04, RFC 3339
+func ParseTime(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	s = strings.ToLower(s)
+	if s == "now" {
+		return now, nil
+	}
+	if strings.HasPrefix(s, "+") || strings.HasPrefix(s, "-") {
+		d, err := parseDuration(s)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w %q: %w", ErrBadTime, s, err)
+		}
+		return now.Add(d), nil
+	}
+
+	day, clock, _ := strings.Cut(s, " ")
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	var date time.Time
+	switch day {
+	case "today":
+		date = midnight
+	case "tomorrow":
+		date = midnight.AddDate(0, 0, 1)
+	case "yesterday":
+		date = midnight.AddDate(0, 0, -1)
+	default:
+		weekday, ok := weekdays[day]
+		if !ok {
+			return time.Time{}, fmt.Errorf("%w %q", ErrBadTime, s)
+		}
+		days := (int(weekday)-int(now.Weekday())+6)%7 + 1
+		date = midnight.AddDate(0, 0, days)
+	}
+	if clock == "" {
+		return date, nil
+	}
+	t, err := time.Parse("15:04", strings.TrimSpace(clock))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w %q: %w", ErrBadTime, s, err)
+	}
+	return date.Add(time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute), nil
+}
+
+// parseDuration is time.ParseDuration that also accepts days, e.g. +3d.
+func parseDuration(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
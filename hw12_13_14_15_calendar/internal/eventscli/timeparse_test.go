@@ -0,0 +1,46 @@
+package eventscli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTime(t *testing.T) {
+	loc := time.FixedZone("UTC+5", 5*60*60)
+	// a Wednesday
+	now := time.Date(2024, 10, 2, 14, 30, 0, 0, loc)
+
+	testData := []struct {
+		input    string
+		expected time.Time
+	}{
+		{input: "now", expected: now},
+		{input: "+90m", expected: now.Add(90 * time.Minute)},
+		{input: "-2h", expected: now.Add(-2 * time.Hour)},
+		{input: "+3d", expected: now.AddDate(0, 0, 3)},
+		{input: "today", expected: time.Date(2024, 10, 2, 0, 0, 0, 0, loc)},
+		{input: "Tomorrow 10:00", expected: time.Date(2024, 10, 3, 10, 0, 0, 0, loc)},
+		{input: "yesterday 23:15", expected: time.Date(2024, 10, 1, 23, 15, 0, 0, loc)},
+		{input: "friday 9:30", expected: time.Date(2024, 10, 4, 9, 30, 0, 0, loc)},
+		{input: "wed", expected: time.Date(2024, 10, 9, 0, 0, 0, 0, loc)},
+		{input: "2024-12-31", expected: time.Date(2024, 12, 31, 0, 0, 0, 0, loc)},
+		{input: "2024-12-31 18:00", expected: time.Date(2024, 12, 31, 18, 0, 0, 0, loc)},
+		{input: "2024-12-31T18:00", expected: time.Date(2024, 12, 31, 18, 0, 0, 0, loc)},
+		{input: "2024-12-31T18:00:00Z", expected: time.Date(2024, 12, 31, 18, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseTime(tt.input, now)
+			require.NoError(t, err)
+			require.True(t, tt.expected.Equal(got), "expected %s, got %s", tt.expected, got)
+		})
+	}
+
+	for _, input := range []string{"", "someday", "tomorrow 25:00", "+soon"} {
+		_, err := ParseTime(input, now)
+		require.ErrorIs(t, err, ErrBadTime, input)
+	}
+}
@@ -2,26 +2,72 @@ package grpc
 
 import (
 	"context"
-	"fmt"
+	"strings"
 	"time"
 
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/gen/events/pb"
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/app"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/logger"
+	"github.com/google/uuid"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
-func UnaryLoggingInterceptor(logger app.Logger) grpc.UnaryServerInterceptor {
+const healthMethodPrefix = "/grpc.health.v1.Health/"
+
+// UnaryLoggingInterceptor assigns the call a request ID, taken from the x-request-id metadata
+// or generated, returns it in the header, logs the outcome of the call and records it in
+// the request metrics. The request ID and the user the request is about are added to the fields
+// logged while handling the call.
+// Request and response bodies are not logged, they may hold secrets.
+func UnaryLoggingInterceptor(logg app.Logger) grpc.UnaryServerInterceptor {
 	return func(
 		ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
 	) (interface{}, error) {
+		// the probes of the orchestrator would drown the other requests
+		if strings.HasPrefix(info.FullMethod, healthMethodPrefix) {
+			return handler(ctx, req)
+		}
 		start := time.Now()
+		requestID := ""
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(logger.RequestIDHeader); len(values) > 0 {
+				requestID = values[0]
+			}
+		}
+		if requestID == "" {
+			requestID = uuid.NewString()
+		}
+		_ = grpc.SetHeader(ctx, metadata.Pairs(strings.ToLower(logger.RequestIDHeader), requestID))
+		ctx = logger.WithFields(ctx, logger.RequestIDKey, requestID)
+		if userID := requestUserID(req); userID != "" {
+			ctx = logger.WithFields(ctx, logger.UserIDKey, userID)
+		}
+
 		res, err := handler(ctx, req)
-		dur := time.Since(start)
-		logger.Info(
-			fmt.Sprintf(
-				"[gRPC] %s, request=%v, response=%v, err=%v, duraton=%s",
-				info.FullMethod, req, res, err, dur,
-			),
-		)
+		code, duration := status.Code(err).String(), time.Since(start)
+		requestsTotal.WithLabelValues(info.FullMethod, code).Inc()
+		requestDuration.WithLabelValues(info.FullMethod).Observe(duration.Seconds())
+		args := []any{"method", info.FullMethod, "code", code, "duration", duration}
+		if err != nil {
+			logg.ErrorContext(ctx, "grpc request failed", append(args, "error", err)...)
+		} else {
+			logg.InfoContext(ctx, "grpc request", args...)
+		}
 		return res, err
 	}
 }
+
+// requestUserID returns the user a request is about, if it says.
+func requestUserID(req interface{}) string {
+	switch r := req.(type) {
+	case interface{ GetUserId() string }:
+		return r.GetUserId()
+	case interface{ GetEvent() *pb.Event }:
+		return r.GetEvent().GetUserId()
+	case interface{ GetWebhook() *pb.Webhook }:
+		return r.GetWebhook().GetUserId()
+	}
+	return ""
+}
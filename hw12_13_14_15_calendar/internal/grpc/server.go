@@ -2,45 +2,72 @@ package grpc
 
 import (
 	"context"
-	"fmt"
 	"net"
+	"time"
 
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/gen/events/pb"
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/app"
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/grpc/service"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/health"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 )
 
 type Server struct {
-	grpcServer    *grpc.Server
-	lsn           net.Listener
-	eventsService *service.EventsService
-	logger        app.Logger
+	grpcServer      *grpc.Server
+	lsn             net.Listener
+	eventsService   *service.EventsService
+	webhooksService *service.WebhooksService
+	archiveService  *service.ArchiveService
+	health          *grpchealth.Server
+	logger          app.Logger
 }
 
 func (s *Server) Serve() error {
-	s.logger.Info(fmt.Sprintf("gRPC server is running on %s", s.lsn.Addr().String()))
+	s.logger.InfoContext(context.Background(), "gRPC server is running", "addr", s.lsn.Addr().String())
 	return s.grpcServer.Serve(s.lsn)
 }
 
+// WatchHealth sets the status of the grpc.health.v1 service by the readiness checks
+// until ctx is done, see health.Checker.Watch.
+func (s *Server) WatchHealth(ctx context.Context, checker *health.Checker, interval time.Duration) {
+	checker.Watch(ctx, s.health, interval,
+		pb.EventService_ServiceDesc.ServiceName,
+		pb.WebhookService_ServiceDesc.ServiceName,
+		pb.ArchiveService_ServiceDesc.ServiceName,
+	)
+}
+
 func (s *Server) Stop() {
 	s.grpcServer.Stop()
 }
 
-func (s *Server) CreateGatewayMux(ctx context.Context) (*runtime.ServeMux, error) {
-	gwmux := runtime.NewServeMux()
+func (s *Server) CreateGatewayMux(ctx context.Context, opts ...runtime.ServeMuxOption) (*runtime.ServeMux, error) {
+	gwmux := runtime.NewServeMux(opts...)
 	err := pb.RegisterEventServiceHandlerServer(ctx, gwmux, s.eventsService)
 	if err != nil {
 		return nil, err
 	}
+	err = pb.RegisterWebhookServiceHandlerServer(ctx, gwmux, s.webhooksService)
+	if err != nil {
+		return nil, err
+	}
+	err = pb.RegisterArchiveServiceHandlerServer(ctx, gwmux, s.archiveService)
+	if err != nil {
+		return nil, err
+	}
 	return gwmux, nil
 }
 
 func NewServer(calendar *app.App, conf *conf.GRPCConf) (*Server, error) {
 	eventsService := service.NewEventsService(calendar)
+	webhooksService := service.NewWebhooksService(calendar)
+	archiveService := service.NewArchiveService(calendar)
 
 	// gRPC server
 	lsn, err := net.Listen("tcp", conf.BindAddr)
@@ -48,17 +75,27 @@ func NewServer(calendar *app.App, conf *conf.GRPCConf) (*Server, error) {
 		return nil, err
 	}
 	grpcServer := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
 		grpc.ChainUnaryInterceptor(
 			UnaryLoggingInterceptor(calendar.Logger),
 		),
 	)
 	pb.RegisterEventServiceServer(grpcServer, eventsService)
+	pb.RegisterWebhookServiceServer(grpcServer, webhooksService)
+	pb.RegisterArchiveServiceServer(grpcServer, archiveService)
+	// the services are not serving until the first readiness check, see WatchHealth
+	healthServer := grpchealth.NewServer()
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
 	reflection.Register(grpcServer)
 
 	return &Server{
-		grpcServer:    grpcServer,
-		lsn:           lsn,
-		eventsService: eventsService,
-		logger:        calendar.Logger,
+		grpcServer:      grpcServer,
+		lsn:             lsn,
+		eventsService:   eventsService,
+		webhooksService: webhooksService,
+		archiveService:  archiveService,
+		health:          healthServer,
+		logger:          calendar.Logger,
 	}, nil
 }
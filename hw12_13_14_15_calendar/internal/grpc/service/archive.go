@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/gen/events/pb"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/app"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/webhook"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type ArchiveService struct {
+	app    *app.App
+	events *EventsService
+	pb.UnimplementedArchiveServiceServer
+}
+
+func NewArchiveService(app *app.App) *ArchiveService {
+	return &ArchiveService{app: app, events: NewEventsService(app)}
+}
+
+func (s *ArchiveService) SearchArchivedEvents(ctx context.Context, r *pb.SearchArchivedEventsRequest) (
+	*pb.SearchArchivedEventsResponse, error,
+) {
+	filter := model.ArchiveFilter{
+		UserID: r.GetUserId(),
+		Title:  r.GetTitle(),
+		Limit:  int(r.GetLimit()),
+	}
+	if r.GetFrom() != nil {
+		filter.From = r.GetFrom().AsTime()
+	}
+	if r.GetTo() != nil {
+		filter.To = r.GetTo().AsTime()
+	}
+	events, err := s.app.Storage.SearchArchivedEvents(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*pb.ArchivedEvent, len(events))
+	for i, event := range events {
+		res[i] = &pb.ArchivedEvent{
+			Event:      s.events.internalToGrpc(&event.Event),
+			ArchivedAt: timestamppb.New(event.ArchivedAt),
+		}
+	}
+	return &pb.SearchArchivedEventsResponse{
+		Events: res,
+	}, nil
+}
+
+func (s *ArchiveService) RestoreArchivedEvent(ctx context.Context, r *pb.RestoreArchivedEventRequest) (
+	*pb.RestoreArchivedEventResponse, error,
+) {
+	event, err := s.app.Storage.RestoreArchivedEvent(ctx, r.GetId())
+	if err != nil {
+		return nil, err
+	}
+	s.app.Webhooks.Publish(ctx, webhook.EventCreated, event.UserID, webhook.NewEvent(event))
+	return &pb.RestoreArchivedEventResponse{
+		Event: s.events.internalToGrpc(event),
+	}, nil
+}
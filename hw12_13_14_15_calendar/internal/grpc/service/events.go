@@ -3,10 +3,16 @@ package service
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/gen/events/pb"
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/app"
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/webhook"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -19,13 +25,24 @@ func NewEventsService(app *app.App) *EventsService {
 	return &EventsService{app: app}
 }
 
+var tracer = otel.Tracer("github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/grpc/service")
+
+// startSpan starts the span of a method, the storage calls of the method are its children.
+// The outcome is recorded by the spans of the gRPC or HTTP request.
+func startSpan(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "EventsService."+method, trace.WithAttributes(attrs...))
+}
+
 func (s *EventsService) CreateEvent(ctx context.Context, r *pb.CreateEventRequest) (
 	*pb.CreateEventResponse, error,
 ) {
+	ctx, span := startSpan(ctx, "CreateEvent")
+	defer span.End()
 	event := s.grpcToInternal(r.Event)
 	if err := s.app.Storage.CreateEvent(ctx, event); err != nil {
 		return nil, err
 	}
+	s.app.Webhooks.Publish(ctx, webhook.EventCreated, event.UserID, webhook.NewEvent(event))
 	return &pb.CreateEventResponse{
 		Event: s.internalToGrpc(event),
 	}, nil
@@ -34,10 +51,13 @@ func (s *EventsService) CreateEvent(ctx context.Context, r *pb.CreateEventReques
 func (s *EventsService) UpdateEvent(ctx context.Context, r *pb.UpdateEventRequest) (
 	*pb.UpdateEventResponse, error,
 ) {
+	ctx, span := startSpan(ctx, "UpdateEvent")
+	defer span.End()
 	event := s.grpcToInternal(r.Event)
 	if err := s.app.Storage.UpdateEvent(ctx, event); err != nil {
 		return nil, err
 	}
+	s.app.Webhooks.Publish(ctx, webhook.EventUpdated, event.UserID, webhook.NewEvent(event))
 	return &pb.UpdateEventResponse{
 		Event: s.internalToGrpc(event),
 	}, nil
@@ -46,15 +66,39 @@ func (s *EventsService) UpdateEvent(ctx context.Context, r *pb.UpdateEventReques
 func (s *EventsService) RemoveEvent(ctx context.Context, r *pb.RemoveEventRequest) (
 	*pb.RemoveEventResponse, error,
 ) {
+	ctx, span := startSpan(ctx, "RemoveEvent", attribute.String("event.id", r.GetId()))
+	defer span.End()
+	// the event is read before the removal to pass its owner to the webhooks
+	event, err := s.app.Storage.GetEvent(ctx, r.GetId())
+	if err != nil {
+		return nil, err
+	}
 	if err := s.app.Storage.RemoveEvent(ctx, r.GetId()); err != nil {
 		return nil, err
 	}
+	s.app.Webhooks.Publish(ctx, webhook.EventDeleted, event.UserID, webhook.NewEvent(event))
 	return &pb.RemoveEventResponse{}, nil
 }
 
+func (s *EventsService) GetEvent(ctx context.Context, r *pb.GetEventRequest) (
+	*pb.GetEventResponse, error,
+) {
+	ctx, span := startSpan(ctx, "GetEvent", attribute.String("event.id", r.GetId()))
+	defer span.End()
+	event, err := s.app.Storage.GetEvent(ctx, r.GetId())
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetEventResponse{
+		Event: s.internalToGrpc(event),
+	}, nil
+}
+
 func (s *EventsService) FilterEventsByDay(ctx context.Context, r *pb.FilterEventsByDayRequest) (
 	*pb.FilterEventsByDayResponse, error,
 ) {
+	ctx, span := startSpan(ctx, "FilterEventsByDay")
+	defer span.End()
 	if r.GetDate() == nil {
 		return nil, errors.New("date is not specified")
 	}
@@ -70,6 +114,8 @@ func (s *EventsService) FilterEventsByDay(ctx context.Context, r *pb.FilterEvent
 func (s *EventsService) FilterEventsByWeek(ctx context.Context, r *pb.FilterEventsByWeekRequest) (
 	*pb.FilterEventsByWeekResponse, error,
 ) {
+	ctx, span := startSpan(ctx, "FilterEventsByWeek")
+	defer span.End()
 	if r.GetDate() == nil {
 		return nil, errors.New("date is not specified")
 	}
@@ -85,6 +131,8 @@ func (s *EventsService) FilterEventsByWeek(ctx context.Context, r *pb.FilterEven
 func (s *EventsService) FilterEventsByMonth(ctx context.Context, r *pb.FilterEventsByMonthRequest) (
 	*pb.FilterEventsByMonthResponse, error,
 ) {
+	ctx, span := startSpan(ctx, "FilterEventsByMonth")
+	defer span.End()
 	if r.GetDate() == nil {
 		return nil, errors.New("date is not specified")
 	}
@@ -97,6 +145,38 @@ func (s *EventsService) FilterEventsByMonth(ctx context.Context, r *pb.FilterEve
 	}, nil
 }
 
+func (s *EventsService) GetNotificationStatus(ctx context.Context, r *pb.GetNotificationStatusRequest) (
+	*pb.GetNotificationStatusResponse, error,
+) {
+	ctx, span := startSpan(ctx, "GetNotificationStatus", attribute.String("notification.id", r.GetId()))
+	defer span.End()
+	status, err := s.app.Storage.GetNotificationStatus(ctx, r.GetId())
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetNotificationStatusResponse{
+		Status: s.notificationStatusToGrpc(status),
+	}, nil
+}
+
+func (s *EventsService) ListNotificationStatuses(ctx context.Context, r *pb.ListNotificationStatusesRequest) (
+	*pb.ListNotificationStatusesResponse, error,
+) {
+	ctx, span := startSpan(ctx, "ListNotificationStatuses", attribute.String("event.id", r.GetEventId()))
+	defer span.End()
+	statuses, err := s.app.Storage.ListNotificationStatuses(ctx, r.GetEventId())
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*pb.NotificationStatus, len(statuses))
+	for i, status := range statuses {
+		res[i] = s.notificationStatusToGrpc(status)
+	}
+	return &pb.ListNotificationStatusesResponse{
+		Statuses: res,
+	}, nil
+}
+
 func (s *EventsService) internalSliceToGrpc(events []*model.Event) []*pb.Event {
 	res := make([]*pb.Event, len(events))
 	for i, e := range events {
@@ -106,7 +186,7 @@ func (s *EventsService) internalSliceToGrpc(events []*model.Event) []*pb.Event {
 }
 
 func (s *EventsService) grpcToInternal(g *pb.Event) *model.Event {
-	return &model.Event{
+	event := &model.Event{
 		ID:          g.GetId(),
 		Title:       g.GetTitle(),
 		StartTime:   g.GetStart().AsTime(),
@@ -114,10 +194,22 @@ func (s *EventsService) grpcToInternal(g *pb.Event) *model.Event {
 		UserID:      g.GetUserId(),
 		NotifyDelta: int(g.GetNotifyDelta()),
 	}
+	for _, r := range g.GetReminders() {
+		event.Reminders = append(event.Reminders, model.Reminder{
+			Offset:  r.GetOffset().AsDuration(),
+			Channel: r.GetChannel(),
+			Message: r.GetMessage(),
+		})
+	}
+	// legacy clients only send notifyDelta, which is in minutes
+	if len(event.Reminders) == 0 && event.NotifyDelta > 0 {
+		event.Reminders = []model.Reminder{{Offset: time.Duration(event.NotifyDelta) * time.Minute}}
+	}
+	return event
 }
 
 func (s *EventsService) internalToGrpc(e *model.Event) *pb.Event {
-	return &pb.Event{
+	event := &pb.Event{
 		Id:          e.ID,
 		Title:       e.Title,
 		Start:       timestamppb.New(e.StartTime),
@@ -125,4 +217,32 @@ func (s *EventsService) internalToGrpc(e *model.Event) *pb.Event {
 		UserId:      e.UserID,
 		NotifyDelta: uint32(e.NotifyDelta), //nolint
 	}
+	for _, r := range e.Reminders {
+		event.Reminders = append(event.Reminders, &pb.Reminder{
+			Offset:  durationpb.New(r.Offset),
+			Channel: r.Channel,
+			Message: r.Message,
+		})
+	}
+	return event
+}
+
+func (s *EventsService) notificationStatusToGrpc(status *model.NotificationStatus) *pb.NotificationStatus {
+	var state pb.NotificationState
+	switch status.State {
+	case model.NotificationDelivered:
+		state = pb.NotificationState_NOTIFICATION_STATE_DELIVERED
+	case model.NotificationFailed:
+		state = pb.NotificationState_NOTIFICATION_STATE_FAILED
+	case model.NotificationRetrying:
+		state = pb.NotificationState_NOTIFICATION_STATE_RETRYING
+	}
+	return &pb.NotificationStatus{
+		NotificationId: status.NotificationID,
+		EventId:        status.EventID,
+		State:          state,
+		Attempts:       uint32(status.Attempts), //nolint
+		Error:          status.Error,
+		UpdatedAt:      timestamppb.New(status.UpdatedAt),
+	}
 }
@@ -10,13 +10,12 @@ import (
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/gen/events/pb"
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/app"
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/logger"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/pgtest"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
 	sqlstorage "github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/sql"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/stretchr/testify/require"
-	"github.com/testcontainers/testcontainers-go"
-	"github.com/testcontainers/testcontainers-go/modules/postgres"
-	"github.com/testcontainers/testcontainers-go/wait"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/test/bufconn"
@@ -32,12 +31,6 @@ const (
 		"VALUES ($1, $2, $3, $4, $5, $6)"
 )
 
-const (
-	testDBName     = "test-db"
-	testDBUser     = "postgres"
-	testDBPassword = "postgres"
-	testDBImage    = "postgres:16"
-)
 const buffSize = 10 * 1024 * 1024
 
 func checkEvent(t *testing.T, event *pb.Event, row pgx.Row) {
@@ -77,7 +70,7 @@ func TestCreate(t *testing.T) {
 
 	require.NotNil(t, response)
 
-	row := pgStorage.Conn.QueryRow(context.TODO(),
+	row := pgStorage.Pool.QueryRow(context.TODO(),
 		selectStatement,
 		eventID,
 	)
@@ -90,7 +83,7 @@ func TestUpdate(t *testing.T) {
 	testApp, pgStorage := createApp(ctx, t)
 
 	eventID := uuid.NewString()
-	_, err := pgStorage.Conn.Exec(context.TODO(),
+	_, err := pgStorage.Pool.Exec(context.TODO(),
 		insertStatement,
 		eventID, "Kickoff meeting", time.Now(), time.Now().Add(time.Hour), uuid.NewString(), 10,
 	)
@@ -111,7 +104,7 @@ func TestUpdate(t *testing.T) {
 
 	require.NotNil(t, response)
 
-	row := pgStorage.Conn.QueryRow(context.TODO(),
+	row := pgStorage.Pool.QueryRow(context.TODO(),
 		selectStatement,
 		eventID,
 	)
@@ -124,7 +117,7 @@ func TestRemove(t *testing.T) {
 	testApp, pgStorage := createApp(ctx, t)
 
 	eventID := uuid.NewString()
-	_, err := pgStorage.Conn.Exec(context.TODO(),
+	_, err := pgStorage.Pool.Exec(context.TODO(),
 		insertStatement,
 		eventID, "Kickoff meeting", time.Now(), time.Now().Add(time.Hour), uuid.NewString(), 10,
 	)
@@ -137,7 +130,7 @@ func TestRemove(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, response)
 
-	row := pgStorage.Conn.QueryRow(context.TODO(),
+	row := pgStorage.Pool.QueryRow(context.TODO(),
 		selectCountStatement,
 		eventID,
 	)
@@ -201,33 +194,37 @@ func TestFilterEventsByMonth(t *testing.T) {
 	require.Len(t, response.Events, 2)
 }
 
+func TestGetNotificationStatus(t *testing.T) {
+	ctx := context.Background()
+	testApp, pgStorage := createApp(ctx, t)
+
+	client := testServer(ctx, t, testApp)
+	notificationID := uuid.NewString()
+	err := pgStorage.SaveNotificationStatus(ctx, &model.NotificationStatus{
+		NotificationID: notificationID,
+		EventID:        uuid.NewString(),
+		State:          model.NotificationFailed,
+		Error:          "mailbox unavailable",
+	})
+	require.NoError(t, err)
+
+	response, err := client.GetNotificationStatus(
+		context.Background(),
+		&pb.GetNotificationStatusRequest{Id: notificationID},
+	)
+
+	require.NoError(t, err)
+	require.Equal(t, pb.NotificationState_NOTIFICATION_STATE_FAILED, response.Status.State)
+	require.Equal(t, "mailbox unavailable", response.Status.Error)
+	require.Equal(t, uint32(1), response.Status.Attempts)
+}
+
 func createApp(ctx context.Context, t *testing.T) (*app.App, *sqlstorage.Storage) {
 	t.Helper()
 	logg, err := logger.New("DEBUG")
 	require.NoError(t, err, "failed to create logger")
-	pgContainer, err := postgres.Run(ctx,
-		testDBImage,
-		postgres.WithDatabase(testDBName),
-		postgres.WithUsername(testDBUser),
-		postgres.WithPassword(testDBPassword),
-		testcontainers.WithWaitStrategy(
-			wait.ForLog("database system is ready to accept connections").
-				WithOccurrence(2).WithStartupTimeout(5*time.Second)),
-	)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	t.Cleanup(func() {
-		if err := pgContainer.Terminate(ctx); err != nil {
-			t.Fatalf("failed to terminate pgContainer: %s", err)
-		}
-	})
-	connStr, err := pgContainer.ConnectionString(ctx, "sslmode=disable")
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
-	// return connStr, err
+	connStr, err := pgtest.CreatePostgresContainer(ctx, t)
+	require.NoError(t, err)
 	pgStorage := sqlstorage.New(connStr)
 	err = pgStorage.Connect(ctx)
 	t.Cleanup(func() {
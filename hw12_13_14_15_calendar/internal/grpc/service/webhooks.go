@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/gen/events/pb"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/app"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/webhook"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const defaultDeliveriesLimit = 50
+
+var errWebhooksDisabled = errors.New("webhooks are disabled")
+
+type WebhooksService struct {
+	app *app.App
+	pb.UnimplementedWebhookServiceServer
+}
+
+func NewWebhooksService(app *app.App) *WebhooksService {
+	return &WebhooksService{app: app}
+}
+
+func (s *WebhooksService) CreateWebhook(ctx context.Context, r *pb.CreateWebhookRequest) (
+	*pb.CreateWebhookResponse, error,
+) {
+	g := r.GetWebhook()
+	u, err := url.Parse(g.GetUrl())
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid webhook url: %q", g.GetUrl())
+	}
+	for _, t := range g.GetEventTypes() {
+		if !webhook.IsSupported(t) {
+			return nil, fmt.Errorf("unsupported event type: %q", t)
+		}
+	}
+	subscription := &model.WebhookSubscription{
+		ID:         g.GetId(),
+		UserID:     g.GetUserId(),
+		URL:        g.GetUrl(),
+		Secret:     g.GetSecret(),
+		EventTypes: g.GetEventTypes(),
+	}
+	if subscription.ID == "" {
+		subscription.ID = uuid.NewString()
+	}
+	if err := s.app.Storage.CreateWebhookSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+	return &pb.CreateWebhookResponse{
+		Webhook: s.subscriptionToGrpc(subscription),
+	}, nil
+}
+
+func (s *WebhooksService) ListWebhooks(ctx context.Context, r *pb.ListWebhooksRequest) (
+	*pb.ListWebhooksResponse, error,
+) {
+	subscriptions, err := s.app.Storage.ListWebhookSubscriptions(ctx, r.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*pb.Webhook, len(subscriptions))
+	for i, subscription := range subscriptions {
+		res[i] = s.subscriptionToGrpc(subscription)
+	}
+	return &pb.ListWebhooksResponse{
+		Webhooks: res,
+	}, nil
+}
+
+func (s *WebhooksService) DeleteWebhook(ctx context.Context, r *pb.DeleteWebhookRequest) (
+	*pb.DeleteWebhookResponse, error,
+) {
+	if err := s.app.Storage.DeleteWebhookSubscription(ctx, r.GetId()); err != nil {
+		return nil, err
+	}
+	return &pb.DeleteWebhookResponse{}, nil
+}
+
+func (s *WebhooksService) ListWebhookDeliveries(ctx context.Context, r *pb.ListWebhookDeliveriesRequest) (
+	*pb.ListWebhookDeliveriesResponse, error,
+) {
+	limit := int(r.GetLimit())
+	if limit == 0 {
+		limit = defaultDeliveriesLimit
+	}
+	deliveries, err := s.app.Storage.ListWebhookDeliveries(ctx, r.GetWebhookId(), limit)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*pb.WebhookDelivery, len(deliveries))
+	for i, delivery := range deliveries {
+		res[i] = s.deliveryToGrpc(delivery)
+	}
+	return &pb.ListWebhookDeliveriesResponse{
+		Deliveries: res,
+	}, nil
+}
+
+func (s *WebhooksService) ReplayWebhookDelivery(ctx context.Context, r *pb.ReplayWebhookDeliveryRequest) (
+	*pb.ReplayWebhookDeliveryResponse, error,
+) {
+	if s.app.Webhooks == nil {
+		return nil, errWebhooksDisabled
+	}
+	delivery, err := s.app.Webhooks.Replay(ctx, r.GetId())
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ReplayWebhookDeliveryResponse{
+		Delivery: s.deliveryToGrpc(delivery),
+	}, nil
+}
+
+// subscriptionToGrpc omits the secret, it is write-only.
+func (s *WebhooksService) subscriptionToGrpc(subscription *model.WebhookSubscription) *pb.Webhook {
+	return &pb.Webhook{
+		Id:         subscription.ID,
+		UserId:     subscription.UserID,
+		Url:        subscription.URL,
+		EventTypes: subscription.EventTypes,
+		CreatedAt:  timestamppb.New(subscription.CreatedAt),
+	}
+}
+
+func (s *WebhooksService) deliveryToGrpc(delivery *model.WebhookDelivery) *pb.WebhookDelivery {
+	var state pb.WebhookDeliveryState
+	switch delivery.State {
+	case model.WebhookDeliveryPending:
+		state = pb.WebhookDeliveryState_WEBHOOK_DELIVERY_STATE_PENDING
+	case model.WebhookDeliveryDelivered:
+		state = pb.WebhookDeliveryState_WEBHOOK_DELIVERY_STATE_DELIVERED
+	case model.WebhookDeliveryFailed:
+		state = pb.WebhookDeliveryState_WEBHOOK_DELIVERY_STATE_FAILED
+	}
+	return &pb.WebhookDelivery{
+		Id:         delivery.ID,
+		WebhookId:  delivery.SubscriptionID,
+		EventType:  delivery.EventType,
+		Payload:    string(delivery.Payload),
+		State:      state,
+		Attempts:   uint32(delivery.Attempts),   //nolint
+		StatusCode: uint32(delivery.StatusCode), //nolint
+		Error:      delivery.Error,
+		CreatedAt:  timestamppb.New(delivery.CreatedAt),
+		UpdatedAt:  timestamppb.New(delivery.UpdatedAt),
+	}
+}
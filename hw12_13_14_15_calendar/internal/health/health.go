@@ -0,0 +1,143 @@
+// Package health runs the readiness checks of a service and serves them to the orchestrator
+// over HTTP (/healthz, /readyz) and over the standard grpc.health.v1 service.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	StatusOK       = "ok"
+	StatusNotReady = "not ready"
+
+	// CheckTimeout bounds the checks, a dependency slower than that is not ready.
+	CheckTimeout = 2 * time.Second
+	// WatchInterval is how often the status of the gRPC health service is updated.
+	WatchInterval = 5 * time.Second
+)
+
+// Check returns an error when the dependency is not usable.
+type Check func(ctx context.Context) error
+
+// Report is the outcome of the checks, Checks holds "ok" or the error of every check.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Checker runs the named checks, each of them within the timeout.
+type Checker struct {
+	timeout time.Duration
+
+	mu     sync.Mutex
+	names  []string
+	checks map[string]Check
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout, checks: map[string]Check{}}
+}
+
+// Add registers the check, a check added under the same name replaces the previous one.
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.checks[name]; !ok {
+		c.names = append(c.names, name)
+	}
+	c.checks[name] = check
+}
+
+// Check runs the checks concurrently and reports whether all of them have passed.
+func (c *Checker) Check(ctx context.Context) (Report, bool) {
+	c.mu.Lock()
+	names := append([]string(nil), c.names...)
+	checks := make([]Check, len(names))
+	for i, name := range names {
+		checks[i] = c.checks[name]
+	}
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	errs := make([]error, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			errs[i] = check(ctx)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]string, len(names))}
+	for i, name := range names {
+		report.Checks[name] = StatusOK
+		if errs[i] != nil {
+			report.Checks[name] = errs[i].Error()
+			report.Status = StatusNotReady
+		}
+	}
+	return report, report.Status == StatusOK
+}
+
+// LiveHandler serves /healthz, the process is alive as long as it responds.
+func LiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeReport(w, http.StatusOK, Report{Status: StatusOK})
+	})
+}
+
+// ReadyHandler serves /readyz, it responds 200 when all checks pass and 503 otherwise,
+// the body lists the outcome of every check.
+func (c *Checker) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report, ok := c.Check(r.Context())
+		status := http.StatusOK
+		if !ok {
+			status = http.StatusServiceUnavailable
+		}
+		writeReport(w, status, report)
+	})
+}
+
+func writeReport(w http.ResponseWriter, status int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+// Watch runs the checks every interval and sets the status of the gRPC health service,
+// for the server as a whole and for each of the services, until ctx is done. Then the
+// status becomes NOT_SERVING, so the clients drain before the server stops.
+func (c *Checker) Watch(ctx context.Context, server *health.Server, interval time.Duration, services ...string) {
+	set := func(status healthpb.HealthCheckResponse_ServingStatus) {
+		server.SetServingStatus("", status)
+		for _, service := range services {
+			server.SetServingStatus(service, status)
+		}
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		status := healthpb.HealthCheckResponse_NOT_SERVING
+		if _, ok := c.Check(ctx); ok {
+			status = healthpb.HealthCheckResponse_SERVING
+		}
+		set(status)
+		select {
+		case <-ctx.Done():
+			server.Shutdown()
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestReadyHandler(t *testing.T) {
+	var busErr error
+	checker := NewChecker(time.Second)
+	checker.Add("storage", func(context.Context) error {
+		return nil
+	})
+	checker.Add("bus", func(context.Context) error {
+		return busErr
+	})
+
+	get := func(handler http.Handler) (int, Report) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		var report Report
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+		return rec.Code, report
+	}
+
+	code, report := get(checker.ReadyHandler())
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, Report{Status: StatusOK, Checks: map[string]string{"storage": StatusOK, "bus": StatusOK}}, report)
+
+	busErr = errors.New("producer is connecting")
+	code, report = get(checker.ReadyHandler())
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, StatusNotReady, report.Status)
+	require.Equal(t, "producer is connecting", report.Checks["bus"])
+
+	// liveness does not depend on the checks
+	code, report = get(LiveHandler())
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, StatusOK, report.Status)
+}
+
+func TestCheckTimeout(t *testing.T) {
+	checker := NewChecker(10 * time.Millisecond)
+	checker.Add("storage", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	report, ok := checker.Check(context.Background())
+	require.False(t, ok)
+	require.Equal(t, context.DeadlineExceeded.Error(), report.Checks["storage"])
+}
+
+func TestWatch(t *testing.T) {
+	ready := make(chan error, 1)
+	ready <- errors.New("storage is not connected")
+	checker := NewChecker(time.Second)
+	checker.Add("storage", func(context.Context) error {
+		select {
+		case err := <-ready:
+			return err
+		default:
+			return nil
+		}
+	})
+
+	server := health.NewServer()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		checker.Watch(ctx, server, 10*time.Millisecond, "event.EventService")
+		close(done)
+	}()
+
+	status := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		res, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			return healthpb.HealthCheckResponse_UNKNOWN
+		}
+		return res.GetStatus()
+	}
+	require.Eventually(t, func() bool {
+		return status("event.EventService") == healthpb.HealthCheckResponse_SERVING
+	}, time.Second, 5*time.Millisecond)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, status(""))
+
+	cancel()
+	<-done
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status("event.EventService"))
+}
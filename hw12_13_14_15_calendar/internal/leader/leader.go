@@ -0,0 +1,59 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
+)
+
+var ErrUnknownStorageType = errors.New("unknown storage type")
+
+const (
+	defaultLockKey       = 7_362_453 // arbitrary, shared by all scheduler replicas
+	defaultRetryInterval = conf.DefaultLeaderRetryInterval * time.Second
+	defaultLeaseDuration = conf.DefaultLeaderLeaseDuration * time.Second
+)
+
+// Elector elects a single leader among the replicas sharing the same lock.
+type Elector interface {
+	// Campaign acquires the leadership or renews it if it is already held.
+	// It reports whether the caller is the leader until the next call.
+	Campaign(ctx context.Context) (bool, error)
+	// Resign gives up the leadership, so a standby can take it over immediately.
+	Resign(ctx context.Context) error
+}
+
+// NewFromConfig creates an elector for the storage backend: PostgreSQL advisory lock
+// for the sql storage and an in-process lock for the memory storage.
+func NewFromConfig(storageConf *conf.StorageConf, leaderConf *conf.LeaderConf) (Elector, error) {
+	key := leaderConf.LockKey
+	if key == 0 {
+		key = defaultLockKey
+	}
+	switch storageConf.Type {
+	case "inmemory":
+		return NewMemoryElector(key), nil
+	case "sql":
+		return NewPostgresElector(storageConf.DSN, key, LeaseDuration(leaderConf)), nil
+	default:
+		return nil, ErrUnknownStorageType
+	}
+}
+
+// RetryInterval is how often replicas campaign: standbys try to acquire the lock and the leader renews it.
+func RetryInterval(c *conf.LeaderConf) time.Duration {
+	if c.RetryInterval <= 0 {
+		return defaultRetryInterval
+	}
+	return time.Duration(c.RetryInterval) * time.Second
+}
+
+// LeaseDuration is how long the leadership lasts without a successful renewal.
+func LeaseDuration(c *conf.LeaderConf) time.Duration {
+	if c.LeaseDuration <= 0 {
+		return defaultLeaseDuration
+	}
+	return time.Duration(c.LeaseDuration) * time.Second
+}
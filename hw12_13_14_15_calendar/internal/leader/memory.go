@@ -0,0 +1,43 @@
+package leader
+
+import (
+	"context"
+	"sync"
+)
+
+var (
+	memoryMu      sync.Mutex
+	memoryHolders = map[int64]*MemoryElector{}
+)
+
+// MemoryElector elects a leader among the electors of the same process.
+// It suits the memory storage, which cannot be shared between processes anyway.
+type MemoryElector struct {
+	key int64
+}
+
+func NewMemoryElector(key int64) *MemoryElector {
+	return &MemoryElector{key: key}
+}
+
+func (e *MemoryElector) Campaign(_ context.Context) (bool, error) {
+	memoryMu.Lock()
+	defer memoryMu.Unlock()
+
+	holder, ok := memoryHolders[e.key]
+	if !ok {
+		memoryHolders[e.key] = e
+		return true, nil
+	}
+	return holder == e, nil
+}
+
+func (e *MemoryElector) Resign(_ context.Context) error {
+	memoryMu.Lock()
+	defer memoryMu.Unlock()
+
+	if memoryHolders[e.key] == e {
+		delete(memoryHolders, e.key)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package leader
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryElector(t *testing.T) {
+	ctx := context.Background()
+	first := NewMemoryElector(1)
+	second := NewMemoryElector(1)
+	other := NewMemoryElector(2)
+
+	isLeader, err := first.Campaign(ctx)
+	require.NoError(t, err)
+	require.True(t, isLeader)
+
+	// the lock is taken
+	isLeader, err = second.Campaign(ctx)
+	require.NoError(t, err)
+	require.False(t, isLeader)
+
+	// renewal keeps the leadership
+	isLeader, err = first.Campaign(ctx)
+	require.NoError(t, err)
+	require.True(t, isLeader)
+
+	// different keys elect independently
+	isLeader, err = other.Campaign(ctx)
+	require.NoError(t, err)
+	require.True(t, isLeader)
+	require.NoError(t, other.Resign(ctx))
+
+	// a standby takes over after the leader resigns
+	require.NoError(t, second.Resign(ctx))
+	require.NoError(t, first.Resign(ctx))
+	isLeader, err = second.Campaign(ctx)
+	require.NoError(t, err)
+	require.True(t, isLeader)
+	require.NoError(t, second.Resign(ctx))
+}
@@ -0,0 +1,104 @@
+package leader
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// PostgresElector holds a session level advisory lock on a dedicated connection.
+//
+// The lock lives as long as the session, so the leader renews its lease by checking the session
+// every retry interval and steps down when the check does not succeed within the lease duration.
+// TCP keepalives of the session are tuned to the lease as well, so the server drops the session
+// of a vanished leader and releases the lock to a standby in about the same time.
+type PostgresElector struct {
+	dsn    string
+	key    int64
+	lease  time.Duration
+	conn   *pgx.Conn
+	leader bool
+}
+
+func NewPostgresElector(dsn string, key int64, lease time.Duration) *PostgresElector {
+	return &PostgresElector{
+		dsn:   dsn,
+		key:   key,
+		lease: lease,
+	}
+}
+
+func (e *PostgresElector) Campaign(ctx context.Context) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.lease)
+	defer cancel()
+
+	if e.conn == nil {
+		if err := e.connect(ctx); err != nil {
+			return false, err
+		}
+	}
+
+	if e.leader {
+		// the lock is bound to the session, a live session means the lock is still held
+		if err := e.conn.Ping(ctx); err != nil {
+			e.reset()
+			return false, fmt.Errorf("failed to renew leadership: %w", err)
+		}
+		return true, nil
+	}
+
+	var acquired bool
+	if err := e.conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", e.key).Scan(&acquired); err != nil {
+		e.reset()
+		return false, fmt.Errorf("failed to acquire advisory lock: %w", err)
+	}
+	e.leader = acquired
+	return acquired, nil
+}
+
+func (e *PostgresElector) Resign(ctx context.Context) error {
+	if e.conn == nil {
+		return nil
+	}
+	defer e.reset()
+	if e.leader {
+		if _, err := e.conn.Exec(ctx, "SELECT pg_advisory_unlock($1)", e.key); err != nil {
+			return fmt.Errorf("failed to release advisory lock: %w", err)
+		}
+	}
+	return nil
+}
+
+func (e *PostgresElector) connect(ctx context.Context) error {
+	config, err := pgx.ParseConfig(e.dsn)
+	if err != nil {
+		return fmt.Errorf("failed to parse dsn: %w", err)
+	}
+	// the server notices a dead leader after idle + interval * count seconds
+	probe := int(e.lease / time.Second / 2)
+	if probe < 1 {
+		probe = 1
+	}
+	config.RuntimeParams["tcp_keepalives_idle"] = strconv.Itoa(probe)
+	config.RuntimeParams["tcp_keepalives_interval"] = "1"
+	config.RuntimeParams["tcp_keepalives_count"] = strconv.Itoa(probe)
+
+	conn, err := pgx.ConnectConfig(ctx, config)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	e.conn = conn
+	return nil
+}
+
+// reset closes the session, which releases the lock if it is still held.
+func (e *PostgresElector) reset() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_ = e.conn.Close(ctx)
+	e.conn = nil
+	e.leader = false
+}
@@ -0,0 +1,53 @@
+package leader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/pgtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresElector(t *testing.T) {
+	ctx := context.Background()
+	connStr, err := pgtest.CreatePostgresContainer(ctx, t)
+	require.NoError(t, err)
+	first := NewPostgresElector(connStr, 1, 5*time.Second)
+	second := NewPostgresElector(connStr, 1, 5*time.Second)
+	other := NewPostgresElector(connStr, 2, 5*time.Second)
+	t.Cleanup(func() {
+		for _, e := range []*PostgresElector{first, second, other} {
+			require.NoError(t, e.Resign(ctx))
+		}
+	})
+
+	isLeader, err := first.Campaign(ctx)
+	require.NoError(t, err)
+	require.True(t, isLeader)
+
+	// the lock is held by the session of the first elector
+	isLeader, err = second.Campaign(ctx)
+	require.NoError(t, err)
+	require.False(t, isLeader)
+
+	// renewal keeps the leadership
+	isLeader, err = first.Campaign(ctx)
+	require.NoError(t, err)
+	require.True(t, isLeader)
+
+	// different keys elect independently
+	isLeader, err = other.Campaign(ctx)
+	require.NoError(t, err)
+	require.True(t, isLeader)
+
+	// a standby takes over after the leader resigns
+	require.NoError(t, first.Resign(ctx))
+	isLeader, err = second.Campaign(ctx)
+	require.NoError(t, err)
+	require.True(t, isLeader)
+
+	isLeader, err = first.Campaign(ctx)
+	require.NoError(t, err)
+	require.False(t, isLeader)
+}
@@ -0,0 +1,42 @@
+package loadgen
+
+import (
+	"context"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/gen/events/pb"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// APISink creates events through the gRPC API, so they go through the same path as real ones.
+type APISink struct {
+	client pb.EventServiceClient
+}
+
+func NewAPISink(client pb.EventServiceClient) *APISink {
+	return &APISink{client: client}
+}
+
+func (s *APISink) CreateEvent(ctx context.Context, event *model.Event) error {
+	_, err := s.client.CreateEvent(ctx, &pb.CreateEventRequest{Event: eventToGrpc(event)})
+	return err
+}
+
+func eventToGrpc(e *model.Event) *pb.Event {
+	event := &pb.Event{
+		Id:     e.ID,
+		Title:  e.Title,
+		Start:  timestamppb.New(e.StartTime),
+		End:    timestamppb.New(e.EndTime),
+		UserId: e.UserID,
+	}
+	for _, r := range e.Reminders {
+		event.Reminders = append(event.Reminders, &pb.Reminder{
+			Offset:  durationpb.New(r.Offset),
+			Channel: r.Channel,
+			Message: r.Message,
+		})
+	}
+	return event
+}
@@ -0,0 +1,362 @@
+package loadgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/gen/events/pb"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Operations of the load test, the http ones go through the gRPC gateway.
+const (
+	OpGRPCCreate = "grpc.create"
+	OpGRPCGet    = "grpc.get"
+	OpGRPCDay    = "grpc.day"
+	OpGRPCWeek   = "grpc.week"
+	OpGRPCMonth  = "grpc.month"
+	OpHTTPGet    = "http.get"
+	OpHTTPDay    = "http.day"
+	OpHTTPWeek   = "http.week"
+	OpHTTPMonth  = "http.month"
+)
+
+var Operations = []string{
+	OpGRPCCreate, OpGRPCGet, OpGRPCDay, OpGRPCWeek, OpGRPCMonth, OpHTTPGet, OpHTTPDay, OpHTTPWeek, OpHTTPMonth,
+}
+
+// DefaultMix is a read-heavy mix of operations, mostly day and week views.
+const DefaultMix = "grpc.day=4,grpc.week=2,grpc.month=1,grpc.get=2,grpc.create=1"
+
+var (
+	ErrBadLoadConfig = errors.New("invalid load config")
+	errNoHTTP        = errors.New("http address is not set")
+)
+
+// LoadConfig describes the load. Mix maps operations to their relative weights.
+type LoadConfig struct {
+	RPS      int
+	Duration time.Duration
+	Workers  int
+	Mix      map[string]int
+	From     time.Time // queried dates are within [From, From + Days)
+	Days     int
+	Users    int // owners of the created events
+	Seed     int64
+}
+
+// Target is the service under load. HTTP may be empty when the mix has no http operations.
+type Target struct {
+	GRPC       pb.EventServiceClient
+	HTTP       string // base URL of the HTTP gateway, e.g. http://localhost:8081
+	HTTPClient *http.Client
+}
+
+// ParseMix parses a mix given as op=weight pairs separated by commas.
+func ParseMix(s string) (map[string]int, error) {
+	mix := map[string]int{}
+	for _, pair := range strings.Split(s, ",") {
+		op, weight, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: expected op=weight, got %q", ErrBadLoadConfig, pair)
+		}
+		w, err := strconv.Atoi(weight)
+		if err != nil || w < 0 {
+			return nil, fmt.Errorf("%w: invalid weight of %s: %q", ErrBadLoadConfig, op, weight)
+		}
+		mix[op] = w
+	}
+	return mix, nil
+}
+
+// Stats are the results of one operation, latencies are sorted.
+type Stats struct {
+	Op        string
+	Count     int
+	Errors    int
+	LastError string
+	Latencies []time.Duration
+}
+
+// Percentile returns the latency that p percent of the requests did not exceed.
+func (s *Stats) Percentile(p float64) time.Duration {
+	if len(s.Latencies) == 0 {
+		return 0
+	}
+	i := int(float64(len(s.Latencies))*p/100+0.5) - 1
+	return s.Latencies[max(0, min(i, len(s.Latencies)-1))]
+}
+
+// Report summarizes a load test.
+type Report struct {
+	Elapsed time.Duration
+	// Dropped counts requests not sent because all workers were busy, the target rate
+	// was not reached then.
+	Dropped int
+	Ops     []*Stats
+}
+
+func (r *Report) Write(out io.Writer) error {
+	total := 0
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "OP\tCOUNT\tERRORS\tP50\tP90\tP99\tMAX\t")
+	for _, s := range r.Ops {
+		total += s.Count
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t\n", s.Op, s.Count, s.Errors,
+			round(s.Percentile(50)), round(s.Percentile(90)), round(s.Percentile(99)), round(s.Percentile(100)))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	for _, s := range r.Ops {
+		if s.LastError != "" {
+			fmt.Fprintf(out, "%s: last error: %s\n", s.Op, s.LastError)
+		}
+	}
+	_, err := fmt.Fprintf(out, "%d requests in %s (%.1f rps), %d dropped\n",
+		total, round(r.Elapsed), float64(total)/r.Elapsed.Seconds(), r.Dropped)
+	return err
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(10 * time.Microsecond)
+}
+
+type loader struct {
+	cfg    LoadConfig
+	target Target
+	ops    []string // every operation repeated by its weight
+	days   []time.Time
+
+	mu    sync.Mutex
+	rnd   *rand.Rand
+	ids   []string // events known to exist, for the get operations
+	stats map[string]*Stats
+}
+
+// Load sends requests at cfg.RPS until cfg.Duration passes or ctx is done.
+func Load(ctx context.Context, cfg LoadConfig, target Target) (*Report, error) {
+	l := &loader{
+		cfg:    cfg,
+		target: target,
+		rnd:    rand.New(rand.NewSource(cfg.Seed)), //nolint:gosec
+		stats:  map[string]*Stats{},
+		days:   startDays(&SeedConfig{From: cfg.From, Days: cfg.Days}),
+	}
+	if cfg.RPS <= 0 || cfg.Workers <= 0 || cfg.Duration <= 0 || len(l.days) == 0 || cfg.Users <= 0 {
+		return nil, fmt.Errorf("%w: rps, workers, duration, days and users must be positive", ErrBadLoadConfig)
+	}
+	for op, weight := range cfg.Mix {
+		if !slices.Contains(Operations, op) {
+			return nil, fmt.Errorf("%w: unknown operation %q", ErrBadLoadConfig, op)
+		}
+		if strings.HasPrefix(op, "http.") && weight > 0 && target.HTTP == "" {
+			return nil, fmt.Errorf("%w: %s: %w", ErrBadLoadConfig, op, errNoHTTP)
+		}
+		for i := 0; i < weight; i++ {
+			l.ops = append(l.ops, op)
+		}
+		l.stats[op] = &Stats{Op: op}
+	}
+	if len(l.ops) == 0 {
+		return nil, fmt.Errorf("%w: empty mix", ErrBadLoadConfig)
+	}
+	sort.Strings(l.ops) // map order must not affect the sequence of operations
+	if l.target.HTTPClient == nil {
+		l.target.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+	report := &Report{}
+	requests := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for op := range requests {
+				l.run(ctx, op)
+			}
+		}()
+	}
+
+	started := time.Now()
+	ticker := time.NewTicker(time.Second / time.Duration(cfg.RPS))
+	defer ticker.Stop()
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-ticker.C:
+			select {
+			case requests <- l.pick():
+			default:
+				report.Dropped++
+			}
+		}
+	}
+	close(requests)
+	wg.Wait()
+	report.Elapsed = time.Since(started)
+
+	for _, op := range sortedKeys(l.stats) {
+		s := l.stats[op]
+		slices.Sort(s.Latencies)
+		report.Ops = append(report.Ops, s)
+	}
+	return report, nil
+}
+
+func (l *loader) pick() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.ops[l.rnd.Intn(len(l.ops))]
+}
+
+// run performs the operation and records its latency. Requests cut off by the end
+// of the test are not counted.
+func (l *loader) run(ctx context.Context, op string) {
+	started := time.Now()
+	err := l.do(ctx, op)
+	latency := time.Since(started)
+	if ctx.Err() != nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s := l.stats[op]
+	s.Count++
+	s.Latencies = append(s.Latencies, latency)
+	if err != nil {
+		s.Errors++
+		s.LastError = err.Error()
+	}
+}
+
+// random returns a date to query and a random id of a known event, empty if there is none.
+func (l *loader) random() (time.Time, string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	date := l.days[l.rnd.Intn(len(l.days))]
+	id := ""
+	if len(l.ids) > 0 {
+		id = l.ids[l.rnd.Intn(len(l.ids))]
+	}
+	return date, id
+}
+
+// remember keeps the ids of existing events, bounded to limit the memory used by long tests.
+func (l *loader) remember(events ...*pb.Event) {
+	const maxIDs = 10000
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, e := range events {
+		if len(l.ids) < maxIDs {
+			l.ids = append(l.ids, e.GetId())
+		} else {
+			l.ids[l.rnd.Intn(maxIDs)] = e.GetId()
+		}
+	}
+}
+
+func (l *loader) newEvent() *model.Event {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	start := randomStart(l.rnd, l.days, false)
+	return &model.Event{
+		ID:        uuid.NewString(),
+		Title:     titles[l.rnd.Intn(len(titles))],
+		UserID:    fmt.Sprintf("user-%d", l.rnd.Intn(l.cfg.Users)+1),
+		StartTime: start,
+		EndTime:   start.Add(durations[l.rnd.Intn(len(durations))]),
+	}
+}
+
+func (l *loader) do(ctx context.Context, op string) error {
+	date, id := l.random()
+	day := date.UTC().Format(time.RFC3339)
+	// until an event is known, the get operations look up the events of a day instead
+	if id == "" && op == OpGRPCGet {
+		op = OpGRPCDay
+	}
+	if id == "" && op == OpHTTPGet {
+		op = OpHTTPDay
+	}
+
+	switch op {
+	case OpGRPCCreate:
+		res, err := l.target.GRPC.CreateEvent(ctx, &pb.CreateEventRequest{Event: eventToGrpc(l.newEvent())})
+		if err == nil {
+			l.remember(res.GetEvent())
+		}
+		return err
+	case OpGRPCGet:
+		_, err := l.target.GRPC.GetEvent(ctx, &pb.GetEventRequest{Id: id})
+		return err
+	case OpGRPCDay:
+		res, err := l.target.GRPC.FilterEventsByDay(ctx, &pb.FilterEventsByDayRequest{Date: timestamppb.New(date)})
+		l.remember(res.GetEvents()...)
+		return err
+	case OpGRPCWeek:
+		_, err := l.target.GRPC.FilterEventsByWeek(ctx, &pb.FilterEventsByWeekRequest{Date: timestamppb.New(date)})
+		return err
+	case OpGRPCMonth:
+		_, err := l.target.GRPC.FilterEventsByMonth(ctx, &pb.FilterEventsByMonthRequest{Date: timestamppb.New(date)})
+		return err
+	case OpHTTPGet:
+		return l.get(ctx, "/events/"+url.PathEscape(id))
+	case OpHTTPDay:
+		return l.get(ctx, "/events/date/"+day)
+	case OpHTTPWeek:
+		return l.get(ctx, "/events/week/"+day)
+	case OpHTTPMonth:
+		return l.get(ctx, "/events/month/"+day)
+	default:
+		return fmt.Errorf("unknown operation %q", op)
+	}
+}
+
+func (l *loader) get(ctx context.Context, path string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(l.target.HTTP, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	res, err := l.target.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if _, err := io.Copy(io.Discard, res.Body); err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", res.Status)
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]*Stats) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,130 @@
+package loadgen
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/gen/events/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// fakeClient serves the operations of the default mix, the rest panic.
+type fakeClient struct {
+	pb.EventServiceClient
+	mu     sync.Mutex
+	events map[string]*pb.Event
+}
+
+func (f *fakeClient) CreateEvent(_ context.Context, r *pb.CreateEventRequest, _ ...grpc.CallOption) (
+	*pb.CreateEventResponse, error,
+) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events[r.GetEvent().GetId()] = r.GetEvent()
+	return &pb.CreateEventResponse{Event: r.GetEvent()}, nil
+}
+
+func (f *fakeClient) GetEvent(_ context.Context, r *pb.GetEventRequest, _ ...grpc.CallOption) (
+	*pb.GetEventResponse, error,
+) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return &pb.GetEventResponse{Event: f.events[r.GetId()]}, nil
+}
+
+func (f *fakeClient) FilterEventsByDay(context.Context, *pb.FilterEventsByDayRequest, ...grpc.CallOption) (
+	*pb.FilterEventsByDayResponse, error,
+) {
+	return &pb.FilterEventsByDayResponse{}, nil
+}
+
+func (f *fakeClient) FilterEventsByWeek(context.Context, *pb.FilterEventsByWeekRequest, ...grpc.CallOption) (
+	*pb.FilterEventsByWeekResponse, error,
+) {
+	return &pb.FilterEventsByWeekResponse{}, nil
+}
+
+func (f *fakeClient) FilterEventsByMonth(context.Context, *pb.FilterEventsByMonthRequest, ...grpc.CallOption) (
+	*pb.FilterEventsByMonthResponse, error,
+) {
+	return &pb.FilterEventsByMonthResponse{}, nil
+}
+
+func TestLoad(t *testing.T) {
+	var httpRequests int
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		httpRequests++
+		mu.Unlock()
+		_, _ = w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	mix, err := ParseMix(DefaultMix + ",http.day=2")
+	require.NoError(t, err)
+	cfg := LoadConfig{
+		RPS:      200,
+		Duration: 500 * time.Millisecond,
+		Workers:  4,
+		Mix:      mix,
+		From:     time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC),
+		Days:     7,
+		Users:    10,
+		Seed:     1,
+	}
+	client := &fakeClient{events: map[string]*pb.Event{}}
+	report, err := Load(context.Background(), cfg, Target{GRPC: client, HTTP: server.URL})
+	require.NoError(t, err)
+
+	total := 0
+	for _, s := range report.Ops {
+		require.Zero(t, s.Errors, "%s: %s", s.Op, s.LastError)
+		require.Len(t, s.Latencies, s.Count)
+		total += s.Count
+	}
+	require.Greater(t, total, 50)
+	require.NotEmpty(t, client.events)
+	mu.Lock()
+	require.Positive(t, httpRequests)
+	mu.Unlock()
+
+	out := &bytes.Buffer{}
+	require.NoError(t, report.Write(out))
+	require.Contains(t, out.String(), OpGRPCCreate)
+
+	_, err = Load(context.Background(), cfg, Target{GRPC: client})
+	require.ErrorIs(t, err, ErrBadLoadConfig, "http operations need the http address")
+	cfg.Mix = map[string]int{"grpc.delete": 1}
+	_, err = Load(context.Background(), cfg, Target{GRPC: client, HTTP: server.URL})
+	require.ErrorIs(t, err, ErrBadLoadConfig)
+}
+
+func TestPercentile(t *testing.T) {
+	s := &Stats{}
+	require.Zero(t, s.Percentile(99))
+	for i := 1; i <= 100; i++ {
+		s.Latencies = append(s.Latencies, time.Duration(i)*time.Millisecond)
+	}
+	require.Equal(t, 50*time.Millisecond, s.Percentile(50))
+	require.Equal(t, 99*time.Millisecond, s.Percentile(99))
+	require.Equal(t, 100*time.Millisecond, s.Percentile(100))
+	require.Equal(t, time.Millisecond, s.Percentile(0))
+}
+
+func TestParseMix(t *testing.T) {
+	mix, err := ParseMix("grpc.day=3, http.get=1")
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{OpGRPCDay: 3, OpHTTPGet: 1}, mix)
+
+	for _, s := range []string{"grpc.day", "grpc.day=x", "grpc.day=-1"} {
+		_, err := ParseMix(s)
+		require.ErrorIs(t, err, ErrBadLoadConfig, s)
+	}
+}
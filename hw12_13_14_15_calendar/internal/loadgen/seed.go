@@ -0,0 +1,173 @@
+// Package loadgen generates synthetic calendar data and load for testing the service at scale.
+package loadgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+	"github.com/google/uuid"
+)
+
+// User distributions, they decide how the events are spread among the users.
+const (
+	DistributionUniform = "uniform" // every user gets about the same number of events
+	DistributionZipf    = "zipf"    // a few users own most of the events
+)
+
+var ErrBadSeedConfig = errors.New("invalid seed config")
+
+var (
+	titles    = []string{"Standup", "1:1", "Planning", "Review", "Lunch", "Gym", "Dentist", "Call", "Workshop", "Demo"}
+	channels  = []string{"", "email", "sms"}
+	durations = []time.Duration{15 * time.Minute, 30 * time.Minute, time.Hour, 90 * time.Minute, 2 * time.Hour}
+	offsets   = []time.Duration{5 * time.Minute, 15 * time.Minute, time.Hour, 24 * time.Hour}
+)
+
+// SeedConfig describes the generated data. Rates are fractions of the events, from 0 to 1.
+type SeedConfig struct {
+	Users        int
+	Events       int
+	Distribution string
+	From         time.Time // events start within [From, From + Days)
+	Days         int
+	WorkHours    bool // only on weekdays between 9:00 and 18:00
+	// RecurringRate is the share of weekly series. The storage has no recurrence rules,
+	// so a series is stored as Occurrences separate events with the same title.
+	RecurringRate float64
+	Occurrences   int
+	// OverlapRate is the share of events starting before the previous event of the user ends.
+	OverlapRate  float64
+	ReminderRate float64
+	Seed         int64
+}
+
+func (c *SeedConfig) validate() error {
+	switch {
+	case c.Users <= 0 || c.Events < 0 || c.Days <= 0:
+		return fmt.Errorf("%w: users and days must be positive", ErrBadSeedConfig)
+	case c.Distribution != DistributionUniform && c.Distribution != DistributionZipf:
+		return fmt.Errorf("%w: unknown distribution %q", ErrBadSeedConfig, c.Distribution)
+	case c.RecurringRate < 0 || c.RecurringRate > 1 || c.OverlapRate < 0 || c.OverlapRate > 1 ||
+		c.ReminderRate < 0 || c.ReminderRate > 1:
+		return fmt.Errorf("%w: rates must be between 0 and 1", ErrBadSeedConfig)
+	case c.RecurringRate > 0 && c.Occurrences < 1:
+		return fmt.Errorf("%w: occurrences must be positive", ErrBadSeedConfig)
+	}
+	return nil
+}
+
+// Generate passes cfg.Events events to emit, the same config and seed always produce the same events.
+func Generate(cfg SeedConfig, emit func(*model.Event) error) error {
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	days := startDays(&cfg)
+	if len(days) == 0 {
+		return fmt.Errorf("%w: no weekdays in the period", ErrBadSeedConfig)
+	}
+	rnd := rand.New(rand.NewSource(cfg.Seed)) //nolint:gosec
+	// deterministic IDs keep the runs reproducible
+	namespace := uuid.NewSHA1(uuid.NameSpaceOID, []byte(fmt.Sprintf("calendar-seed-%d", cfg.Seed)))
+	users := make([]string, cfg.Users)
+	for i := range users {
+		users[i] = fmt.Sprintf("user-%d", i+1)
+	}
+	var zipf *rand.Zipf
+	if cfg.Distribution == DistributionZipf && cfg.Users > 1 {
+		zipf = rand.NewZipf(rnd, 1.1, 1, uint64(cfg.Users-1))
+	}
+	// the last event of every user, overlapping events are placed relative to it
+	last := map[string]*model.Event{}
+
+	for n := 0; n < cfg.Events; {
+		user := users[rnd.Intn(cfg.Users)]
+		if zipf != nil {
+			user = users[zipf.Uint64()]
+		}
+		event := &model.Event{
+			Title:  titles[rnd.Intn(len(titles))],
+			UserID: user,
+		}
+		duration := durations[rnd.Intn(len(durations))]
+		if prev, ok := last[user]; ok && rnd.Float64() < cfg.OverlapRate {
+			event.StartTime = prev.StartTime.Add(time.Duration(rnd.Int63n(int64(prev.EndTime.Sub(prev.StartTime)))))
+		} else {
+			event.StartTime = randomStart(rnd, days, cfg.WorkHours)
+		}
+		event.EndTime = event.StartTime.Add(duration)
+		if rnd.Float64() < cfg.ReminderRate {
+			event.Reminders = []model.Reminder{{
+				Offset:  offsets[rnd.Intn(len(offsets))],
+				Channel: channels[rnd.Intn(len(channels))],
+			}}
+		}
+
+		occurrences := 1
+		if rnd.Float64() < cfg.RecurringRate {
+			occurrences = min(cfg.Occurrences, cfg.Events-n)
+		}
+		for i := 0; i < occurrences; i++ {
+			occurrence := *event
+			occurrence.ID = uuid.NewSHA1(namespace, []byte(fmt.Sprint(n))).String()
+			occurrence.StartTime = event.StartTime.AddDate(0, 0, 7*i)
+			occurrence.EndTime = event.EndTime.AddDate(0, 0, 7*i)
+			if err := emit(&occurrence); err != nil {
+				return err
+			}
+			n++
+		}
+		last[user] = event
+	}
+	return nil
+}
+
+// startDays lists the days events may start on.
+func startDays(cfg *SeedConfig) []time.Time {
+	from := time.Date(cfg.From.Year(), cfg.From.Month(), cfg.From.Day(), 0, 0, 0, 0, cfg.From.Location())
+	days := make([]time.Time, 0, cfg.Days)
+	for i := 0; i < cfg.Days; i++ {
+		day := from.AddDate(0, 0, i)
+		if cfg.WorkHours && (day.Weekday() == time.Saturday || day.Weekday() == time.Sunday) {
+			continue
+		}
+		days = append(days, day)
+	}
+	return days
+}
+
+// randomStart picks a start at a whole quarter of an hour on one of the days.
+func randomStart(rnd *rand.Rand, days []time.Time, workHours bool) time.Time {
+	day := days[rnd.Intn(len(days))]
+	if workHours {
+		return day.Add(9*time.Hour + time.Duration(rnd.Intn(9*4))*15*time.Minute)
+	}
+	return day.Add(time.Duration(rnd.Intn(24*4)) * 15 * time.Minute)
+}
+
+// Sink receives the generated events, storage.Storage is a sink as well as APISink.
+type Sink interface {
+	CreateEvent(ctx context.Context, event *model.Event) error
+}
+
+// Seed generates the events into the sink and reports the progress every 1000 events.
+func Seed(ctx context.Context, cfg SeedConfig, sink Sink, progress func(n int)) (int, error) {
+	n := 0
+	err := Generate(cfg, func(event *model.Event) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := sink.CreateEvent(ctx, event); err != nil {
+			return fmt.Errorf("failed to create event %s: %w", event.ID, err)
+		}
+		n++
+		if progress != nil && n%1000 == 0 {
+			progress(n)
+		}
+		return nil
+	})
+	return n, err
+}
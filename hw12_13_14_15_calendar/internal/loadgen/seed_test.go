@@ -0,0 +1,93 @@
+package loadgen
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	memorystorage "github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+	"github.com/stretchr/testify/require"
+)
+
+func seedConfig() SeedConfig {
+	return SeedConfig{
+		Users:         20,
+		Events:        1000,
+		Distribution:  DistributionZipf,
+		From:          time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC),
+		Days:          30,
+		WorkHours:     true,
+		RecurringRate: 0.1,
+		Occurrences:   4,
+		OverlapRate:   0.2,
+		ReminderRate:  0.5,
+		Seed:          42,
+	}
+}
+
+func generate(t *testing.T, cfg SeedConfig) []*model.Event {
+	t.Helper()
+	var events []*model.Event
+	require.NoError(t, Generate(cfg, func(event *model.Event) error {
+		events = append(events, event)
+		return nil
+	}))
+	return events
+}
+
+func TestGenerate(t *testing.T) {
+	cfg := seedConfig()
+	events := generate(t, cfg)
+	require.Len(t, events, cfg.Events)
+	require.Equal(t, events, generate(t, cfg), "the same seed must produce the same events")
+
+	ids := map[string]bool{}
+	perUser := map[string]int{}
+	reminders := 0
+	for _, e := range events {
+		require.False(t, ids[e.ID], "duplicate id %s", e.ID)
+		ids[e.ID] = true
+		perUser[e.UserID]++
+		if len(e.Reminders) > 0 {
+			reminders++
+		}
+		require.True(t, e.EndTime.After(e.StartTime))
+		require.NotEqual(t, time.Saturday, e.StartTime.Weekday())
+		require.NotEqual(t, time.Sunday, e.StartTime.Weekday())
+		require.GreaterOrEqual(t, e.StartTime.Hour(), 9)
+	}
+	require.InDelta(t, 500, reminders, 100)
+	// with zipf the first user owns far more than an equal share of events
+	require.Greater(t, perUser["user-1"], 3*cfg.Events/cfg.Users)
+}
+
+func TestGenerateInvalid(t *testing.T) {
+	for _, modify := range []func(*SeedConfig){
+		func(c *SeedConfig) { c.Users = 0 },
+		func(c *SeedConfig) { c.Distribution = "normal" },
+		func(c *SeedConfig) { c.OverlapRate = 2 },
+		func(c *SeedConfig) { c.Occurrences = 0 },
+		// a weekend only
+		func(c *SeedConfig) { c.From, c.Days = time.Date(2024, 10, 5, 0, 0, 0, 0, time.UTC), 2 },
+	} {
+		cfg := seedConfig()
+		modify(&cfg)
+		require.ErrorIs(t, Generate(cfg, func(*model.Event) error { return nil }), ErrBadSeedConfig)
+	}
+}
+
+func TestSeed(t *testing.T) {
+	cfg := seedConfig()
+	cfg.Events = 2500
+	s := memorystorage.New()
+	var progress []int
+	n, err := Seed(context.Background(), cfg, s, func(n int) { progress = append(progress, n) })
+	require.NoError(t, err)
+	require.Equal(t, cfg.Events, n)
+	require.Equal(t, []int{1000, 2000}, progress)
+
+	events, err := s.FilterEventsByMonth(context.Background(), cfg.From)
+	require.NoError(t, err)
+	require.NotEmpty(t, events)
+}
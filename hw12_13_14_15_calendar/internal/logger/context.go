@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Keys of the fields correlating the records of a request.
+const (
+	RequestIDKey = "request_id"
+	UserIDKey    = "user_id"
+	TraceIDKey   = "trace_id"
+)
+
+// RequestIDHeader carries the request ID in HTTP requests and responses and in gRPC metadata.
+const RequestIDHeader = "X-Request-ID"
+
+type fieldsKey struct{}
+
+// WithFields returns a context whose records carry the key/value pairs in addition to the fields of ctx.
+func WithFields(ctx context.Context, args ...any) context.Context {
+	fields := Fields(ctx)
+	r := slog.NewRecord(zeroTime, 0, "", 0)
+	r.Add(args...)
+	next := make([]slog.Attr, 0, len(fields)+r.NumAttrs())
+	next = append(next, fields...)
+	r.Attrs(func(a slog.Attr) bool {
+		next = append(next, a)
+		return true
+	})
+	return context.WithValue(ctx, fieldsKey{}, next)
+}
+
+// Fields returns the fields carried by ctx.
+func Fields(ctx context.Context) []slog.Attr {
+	fields, _ := ctx.Value(fieldsKey{}).([]slog.Attr)
+	return fields
+}
+
+// Field returns the value of the field carried by ctx, an empty string if there is none.
+func Field(ctx context.Context, key string) string {
+	fields := Fields(ctx)
+	for i := len(fields) - 1; i >= 0; i-- {
+		if fields[i].Key == key {
+			return fields[i].Value.String()
+		}
+	}
+	return ""
+}
+
+// contextHandler adds the fields of the context and the ID of its trace to the records.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	fields := Fields(ctx)
+	if span := trace.SpanContextFromContext(ctx); span.HasTraceID() && Field(ctx, TraceIDKey) == "" {
+		fields = append(fields[:len(fields):len(fields)], slog.String(TraceIDKey, span.TraceID().String()))
+	}
+	if len(fields) > 0 {
+		r = r.Clone()
+		r.AddAttrs(fields...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -1,8 +1,16 @@
 package logger
 
 import (
+	"context"
 	"fmt"
-	"time"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
 )
 
 type LogLevel int
@@ -14,7 +22,12 @@ const (
 	Error
 )
 
-const logTemplate = "%s [%s] %s\n"
+// Output formats.
+const (
+	FormatText   = "text"   // 2006-01-02 15:04:05.000 [INFO] message key=value
+	FormatJSON   = "json"   // one JSON object per line
+	FormatLogfmt = "logfmt" // time=... level=INFO msg=message key=value
+)
 
 func (l LogLevel) String() string {
 	switch l {
@@ -31,6 +44,19 @@ func (l LogLevel) String() string {
 	}
 }
 
+func (l LogLevel) slog() slog.Level {
+	switch l {
+	case Debug:
+		return slog.LevelDebug
+	case Info:
+		return slog.LevelInfo
+	case Warn:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
 func ParseLevel(level string) (LogLevel, error) {
 	switch level {
 	case "DEBUG":
@@ -46,44 +72,191 @@ func ParseLevel(level string) (LogLevel, error) {
 	}
 }
 
+// Logger writes structured records. Loggers derived by With and Named share the levels
+// and the output of the root one.
 type Logger struct {
-	level LogLevel
+	levels *levels
+	name   string
+	sl     *slog.Logger
+	// unnamed is sl without the logger name, so Named replaces the name instead of adding another one
+	unnamed *slog.Logger
 }
 
+// levels holds the default level and the per-package ones, they change on a config reload.
+type levels struct {
+	level    atomic.Int32
+	packages atomic.Pointer[map[string]LogLevel]
+	closer   io.Closer
+}
+
+// New returns a logger writing text to stdout.
 func New(level string) (*Logger, error) {
-	l, err := ParseLevel(level)
-	if err != nil {
+	return NewFromConfig(&conf.LoggerConf{Level: level})
+}
+
+// NewFromConfig returns a logger writing in the configured format to stdout, stderr or a file
+// rotated by size. Values of the redacted keys are replaced, the keys are matched case-insensitively.
+func NewFromConfig(config *conf.LoggerConf) (*Logger, error) {
+	l := &Logger{levels: &levels{}}
+	if err := l.Configure(config); err != nil {
 		return nil, err
 	}
-	return &Logger{
-		level: l,
-	}, nil
+
+	var w io.Writer
+	switch config.Output {
+	case "", "stdout":
+		w = stdout{}
+	case "stderr":
+		w = os.Stderr
+	default:
+		f, err := newRotatingFile(config.Output, config.MaxSize, config.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		w = f
+		l.levels.closer = f
+	}
+
+	redact := newRedactor(config.Redact)
+	var h slog.Handler
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug, ReplaceAttr: redact.replace}
+	switch config.Format {
+	case "", FormatText:
+		h = newTextHandler(w, redact)
+	case FormatJSON:
+		h = slog.NewJSONHandler(w, opts)
+	case FormatLogfmt:
+		h = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format: %s", config.Format)
+	}
+	l.sl = slog.New(contextHandler{h})
+	l.unnamed = l.sl
+	return l, nil
 }
 
-func (l Logger) Debug(msg string) {
-	if l.level <= Debug {
-		l.logf(Debug, msg)
+// Configure applies the levels of the config, it is safe to call while logging.
+func (l *Logger) Configure(config *conf.LoggerConf) error {
+	packages := make(map[string]LogLevel, len(config.Packages))
+	for name, level := range config.Packages {
+		parsed, err := ParseLevel(level)
+		if err != nil {
+			return fmt.Errorf("package %s: %w", name, err)
+		}
+		packages[name] = parsed
 	}
+	if err := l.SetLevel(config.Level); err != nil {
+		return err
+	}
+	l.levels.packages.Store(&packages)
+	return nil
 }
 
-func (l Logger) Info(msg string) {
-	if l.level <= Info {
-		l.logf(Info, msg)
+// SetLevel changes the default level, it is safe to call while logging.
+func (l *Logger) SetLevel(level string) error {
+	parsed, err := ParseLevel(level)
+	if err != nil {
+		return err
 	}
+	l.levels.level.Store(int32(parsed))
+	return nil
 }
 
-func (l Logger) Warn(msg string) {
-	if l.level <= Warn {
-		l.logf(Warn, msg)
+// Level returns the level of the logger, the one of its package if it is set.
+func (l *Logger) Level() LogLevel {
+	if packages := l.levels.packages.Load(); packages != nil {
+		if level, ok := (*packages)[l.name]; ok {
+			return level
+		}
 	}
+	return LogLevel(l.levels.level.Load())
+}
+
+// Named returns a logger of the package, its level may be set in the packages section of the config.
+// The name replaces the name of a named logger.
+func (l *Logger) Named(name string) *Logger {
+	return &Logger{levels: l.levels, name: name, sl: l.unnamed.With("logger", name), unnamed: l.unnamed}
 }
 
-func (l Logger) Error(msg string) {
-	if l.level <= Error {
-		l.logf(Error, msg)
+// With returns a logger adding the key/value pairs to every record.
+func (l *Logger) With(args ...any) *Logger {
+	return &Logger{levels: l.levels, name: l.name, sl: l.sl.With(args...), unnamed: l.unnamed.With(args...)}
+}
+
+// Close closes the log file, if any.
+func (l *Logger) Close() error {
+	if l.levels.closer == nil {
+		return nil
 	}
+	return l.levels.closer.Close()
 }
 
-func (l Logger) logf(level LogLevel, message string) {
-	fmt.Printf(logTemplate, time.Now().Format("2006-01-02 15:04:05.000"), level, message)
+func (l *Logger) Debug(msg string) {
+	l.log(context.Background(), Debug, msg)
+}
+
+func (l *Logger) Info(msg string) {
+	l.log(context.Background(), Info, msg)
+}
+
+func (l *Logger) Warn(msg string) {
+	l.log(context.Background(), Warn, msg)
+}
+
+func (l *Logger) Error(msg string) {
+	l.log(context.Background(), Error, msg)
+}
+
+// DebugContext logs the message with the key/value pairs and the fields carried by ctx, see WithFields.
+func (l *Logger) DebugContext(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, Debug, msg, args...)
+}
+
+func (l *Logger) InfoContext(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, Info, msg, args...)
+}
+
+func (l *Logger) WarnContext(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, Warn, msg, args...)
+}
+
+func (l *Logger) ErrorContext(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, Error, msg, args...)
+}
+
+func (l *Logger) log(ctx context.Context, level LogLevel, msg string, args ...any) {
+	if level < l.Level() {
+		return
+	}
+	l.sl.Log(ctx, level.slog(), msg, args...)
+}
+
+// stdout writes to the current os.Stdout, which tests may replace.
+type stdout struct{}
+
+func (stdout) Write(p []byte) (int, error) {
+	return os.Stdout.Write(p)
+}
+
+// redactor replaces the values of sensitive keys.
+type redactor map[string]bool
+
+// DefaultRedactedKeys are always redacted, the config may add more.
+var DefaultRedactedKeys = []string{"password", "secret", "token", "authorization", "api_key"}
+
+const redacted = "[REDACTED]"
+
+func newRedactor(keys []string) redactor {
+	r := redactor{}
+	for _, key := range slices.Concat(DefaultRedactedKeys, keys) {
+		r[strings.ToLower(key)] = true
+	}
+	return r
+}
+
+func (r redactor) replace(_ []string, a slog.Attr) slog.Attr {
+	if r[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, redacted)
+	}
+	return a
 }
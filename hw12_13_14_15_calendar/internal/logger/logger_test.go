@@ -1,10 +1,16 @@
 package logger
 
 import (
+	"context"
+	"encoding/json"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestLogger(t *testing.T) {
@@ -50,3 +56,129 @@ func TestLogger(t *testing.T) {
 		})
 	}
 }
+
+func TestSetLevel(t *testing.T) {
+	logger, err := New("INFO")
+	require.NoError(t, err)
+	require.Equal(t, Info, logger.Level())
+
+	require.NoError(t, logger.SetLevel("ERROR"))
+	require.Equal(t, Error, logger.Level())
+
+	require.Error(t, logger.SetLevel("LOUD"))
+	require.Equal(t, Error, logger.Level())
+}
+
+func TestJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calendar.log")
+	logg, err := NewFromConfig(&conf.LoggerConf{
+		Level: "INFO", Format: FormatJSON, Output: path, Redact: []string{"email"},
+	})
+	require.NoError(t, err)
+
+	ctx := WithFields(context.Background(), RequestIDKey, "req-1", UserIDKey, "user-1")
+	// the name of a named logger is replaced, not repeated
+	logg.Named("app").Named("http").InfoContext(ctx, "request", "status", 200, "Password", "qwerty", "email", "a@b.c")
+	logg.Debug("not logged")
+	require.NoError(t, logg.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 1)
+	var record map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	require.Equal(t, "INFO", record["level"])
+	require.Equal(t, "request", record["msg"])
+	require.Equal(t, "http", record["logger"])
+	require.Equal(t, "req-1", record[RequestIDKey])
+	require.Equal(t, "user-1", record[UserIDKey])
+	require.InDelta(t, 200, record["status"], 0)
+	require.Equal(t, "[REDACTED]", record["Password"])
+	require.Equal(t, "[REDACTED]", record["email"])
+}
+
+func TestText(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calendar.log")
+	logg, err := NewFromConfig(&conf.LoggerConf{Level: "INFO", Output: path})
+	require.NoError(t, err)
+
+	ctx := WithFields(context.Background(), RequestIDKey, "req-1")
+	logg.With("component", "test").InfoContext(ctx, "event created", "title", "team sync", "token", "abc")
+	require.NoError(t, logg.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(data),
+		`[INFO] event created component=test title="team sync" token=[REDACTED] request_id=req-1`)
+}
+
+func TestPackageLevels(t *testing.T) {
+	logg, err := NewFromConfig(&conf.LoggerConf{
+		Level: "ERROR", Output: filepath.Join(t.TempDir(), "calendar.log"),
+		Packages: map[string]string{"bus": "DEBUG"},
+	})
+	require.NoError(t, err)
+	defer logg.Close()
+
+	require.Equal(t, Error, logg.Level())
+	require.Equal(t, Debug, logg.Named("bus").Level())
+	require.Equal(t, Error, logg.Named("http").Level())
+
+	// a reload changes the levels of the loggers already handed out
+	bus := logg.Named("bus")
+	require.NoError(t, logg.Configure(&conf.LoggerConf{Level: "INFO"}))
+	require.Equal(t, Info, bus.Level())
+
+	require.Error(t, logg.Configure(&conf.LoggerConf{Level: "INFO", Packages: map[string]string{"bus": "LOUD"}}))
+}
+
+func TestFields(t *testing.T) {
+	ctx := WithFields(context.Background(), RequestIDKey, "req-1")
+	ctx = WithFields(ctx, RequestIDKey, "req-2", UserIDKey, "user-1")
+	require.Equal(t, "req-2", Field(ctx, RequestIDKey))
+	require.Equal(t, "user-1", Field(ctx, UserIDKey))
+	require.Empty(t, Field(ctx, TraceIDKey))
+	require.Empty(t, Field(context.Background(), RequestIDKey))
+}
+
+func TestRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calendar.log")
+	f, err := newRotatingFile(path, 1, 2)
+	require.NoError(t, err)
+	defer f.Close()
+
+	chunk := []byte(strings.Repeat("x", 700<<10))
+	for i := 0; i < 4; i++ {
+		_, err := f.Write(chunk)
+		require.NoError(t, err)
+	}
+
+	// every write over a megabyte rotates, only two backups are kept
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(name)
+		require.NoError(t, err)
+		require.Equal(t, int64(len(chunk)), info.Size(), name)
+	}
+	require.NoFileExists(t, path+".3")
+}
+
+func TestTraceID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calendar.log")
+	logg, err := NewFromConfig(&conf.LoggerConf{Level: "INFO", Format: FormatJSON, Output: path})
+	require.NoError(t, err)
+
+	traceID := trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+	}))
+	logg.InfoContext(ctx, "traced")
+	require.NoError(t, logg.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(data, &record))
+	require.Equal(t, traceID.String(), record[TraceIDKey])
+}
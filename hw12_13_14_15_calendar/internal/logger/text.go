@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var zeroTime time.Time
+
+// textHandler writes records in the format the service has always used, with the attributes
+// appended as key=value: 2006-01-02 15:04:05.000 [INFO] message key=value.
+type textHandler struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	redact redactor
+	attrs  string // preformatted attributes added by WithAttrs
+	group  string // prefix of the keys added by WithGroup
+}
+
+func newTextHandler(w io.Writer, redact redactor) *textHandler {
+	return &textHandler{mu: &sync.Mutex{}, w: w, redact: redact}
+}
+
+func (h *textHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *textHandler) Handle(_ context.Context, r slog.Record) error {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%s [%s] %s", r.Time.Format("2006-01-02 15:04:05.000"), levelName(r.Level), r.Message)
+	b.WriteString(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		h.appendAttr(b, h.group, a)
+		return true
+	})
+	b.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *textHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	b := &strings.Builder{}
+	for _, a := range attrs {
+		h.appendAttr(b, h.group, a)
+	}
+	next := *h
+	next.attrs += b.String()
+	return &next
+}
+
+func (h *textHandler) WithGroup(name string) slog.Handler {
+	next := *h
+	next.group += name + "."
+	return &next
+}
+
+func (h *textHandler) appendAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		for _, ga := range a.Value.Group() {
+			h.appendAttr(b, prefix+a.Key+".", ga)
+		}
+		return
+	}
+	a = h.redact.replace(nil, a)
+	value := a.Value.String()
+	if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+		value = strconv.Quote(value)
+	}
+	fmt.Fprintf(b, " %s%s=%s", prefix, a.Key, value)
+}
+
+func levelName(level slog.Level) string {
+	switch {
+	case level < slog.LevelInfo:
+		return Debug.String()
+	case level < slog.LevelWarn:
+		return Info.String()
+	case level < slog.LevelError:
+		return Warn.String()
+	default:
+		return Error.String()
+	}
+}
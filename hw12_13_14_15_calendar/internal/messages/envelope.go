@@ -0,0 +1,138 @@
+package messages
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/gen/notifications/pb"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+
+	// TypeReminder is the envelope type of a Notification.
+	TypeReminder = "notification.reminder"
+	// SchemaVersion is the version of the payloads produced by this build, see api/notifications.
+	SchemaVersion = "1.0"
+	schemaMajor   = 1
+)
+
+var (
+	ErrUnsupportedContentType = errors.New("unsupported content type")
+	ErrUnsupportedVersion     = errors.New("unsupported schema version")
+	ErrUnexpectedType         = errors.New("unexpected message type")
+)
+
+// Encode wraps the notification into a versioned envelope encoded according to contentType.
+// The traceContext is propagated to the consumer, it may be nil.
+func Encode(notification *Notification, contentType string, traceContext map[string]string) ([]byte, error) {
+	envelope := &pb.Envelope{
+		Id:            notification.ID,
+		Type:          TypeReminder,
+		SchemaVersion: SchemaVersion,
+		Timestamp:     timestamppb.Now(),
+		TraceContext:  traceContext,
+		Payload:       &pb.Envelope_Notification{Notification: toProto(notification)},
+	}
+	switch contentType {
+	case "", ContentTypeJSON:
+		return protojson.Marshal(envelope)
+	case ContentTypeProtobuf:
+		return proto.Marshal(envelope)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedContentType, contentType)
+	}
+}
+
+// Decode unwraps a notification from an envelope encoded according to contentType.
+// Envelopes of an unknown major schema version are rejected, unknown fields of newer minor
+// versions are ignored. JSON notifications published before the envelope are accepted as well.
+func Decode(body []byte, contentType string) (*Notification, *pb.Envelope, error) {
+	envelope := &pb.Envelope{}
+	switch contentType {
+	case "", ContentTypeJSON:
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(body, envelope); err != nil {
+			return nil, nil, fmt.Errorf("failed to unmarshal envelope: %w", err)
+		}
+		if envelope.GetSchemaVersion() == "" {
+			return decodeLegacy(body)
+		}
+	case ContentTypeProtobuf:
+		if err := (proto.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(body, envelope); err != nil {
+			return nil, nil, fmt.Errorf("failed to unmarshal envelope: %w", err)
+		}
+	default:
+		return nil, nil, fmt.Errorf("%w: %q", ErrUnsupportedContentType, contentType)
+	}
+
+	if err := checkVersion(envelope.GetSchemaVersion()); err != nil {
+		return nil, nil, err
+	}
+	if envelope.GetType() != TypeReminder || envelope.GetNotification() == nil {
+		return nil, nil, fmt.Errorf("%w: %q", ErrUnexpectedType, envelope.GetType())
+	}
+	return fromProto(envelope.GetNotification()), envelope, nil
+}
+
+func checkVersion(version string) error {
+	major, _, _ := strings.Cut(version, ".")
+	n, err := strconv.Atoi(major)
+	if err != nil || n != schemaMajor {
+		return fmt.Errorf("%w: %q", ErrUnsupportedVersion, version)
+	}
+	return nil
+}
+
+// decodeLegacy decodes a bare JSON notification, as published before the envelope was introduced.
+func decodeLegacy(body []byte) (*Notification, *pb.Envelope, error) {
+	notification := &Notification{}
+	if err := json.Unmarshal(body, notification); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal message: %w", err)
+	}
+	return notification, &pb.Envelope{Id: notification.ID, Type: TypeReminder}, nil
+}
+
+func toProto(n *Notification) *pb.Notification {
+	return &pb.Notification{
+		Id:             n.ID,
+		IdempotencyKey: n.IdempotencyKey,
+		EventId:        n.EventID,
+		Title:          n.Title,
+		UserId:         n.UserID,
+		StartTime:      timestamppb.New(n.StartTime),
+		NotifyAt:       timestamppb.New(n.NotifyAt),
+		Channel:        n.Channel,
+		Message:        n.Message,
+		Late:           n.Late,
+	}
+}
+
+func fromProto(n *pb.Notification) *Notification {
+	return &Notification{
+		ID:             n.GetId(),
+		IdempotencyKey: n.GetIdempotencyKey(),
+		EventID:        n.GetEventId(),
+		Title:          n.GetTitle(),
+		UserID:         n.GetUserId(),
+		StartTime:      asTime(n.GetStartTime()),
+		NotifyAt:       asTime(n.GetNotifyAt()),
+		Channel:        n.GetChannel(),
+		Message:        n.GetMessage(),
+		Late:           n.GetLate(),
+	}
+}
+
+func asTime(t *timestamppb.Timestamp) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.AsTime()
+}
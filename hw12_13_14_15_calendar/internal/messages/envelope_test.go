@@ -0,0 +1,104 @@
+package messages
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/gen/notifications/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+func testNotification() *Notification {
+	return &Notification{
+		ID:             "n-1",
+		IdempotencyKey: "key-1",
+		EventID:        "event-1",
+		Title:          "Kickoff meeting",
+		UserID:         "user-1",
+		StartTime:      time.Date(2024, 10, 1, 13, 0, 0, 0, time.UTC),
+		NotifyAt:       time.Date(2024, 10, 1, 12, 50, 0, 0, time.UTC),
+		Channel:        "email",
+		Late:           true,
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	for _, contentType := range []string{ContentTypeJSON, ContentTypeProtobuf} {
+		t.Run(contentType, func(t *testing.T) {
+			body, err := Encode(testNotification(), contentType, map[string]string{"traceparent": "00-abc-def-01"})
+			require.NoError(t, err)
+
+			notification, envelope, err := Decode(body, contentType)
+			require.NoError(t, err)
+			require.Equal(t, testNotification(), notification)
+			require.Equal(t, TypeReminder, envelope.GetType())
+			require.Equal(t, SchemaVersion, envelope.GetSchemaVersion())
+			require.Equal(t, "n-1", envelope.GetId())
+			require.Equal(t, "00-abc-def-01", envelope.GetTraceContext()["traceparent"])
+		})
+	}
+
+	_, err := Encode(testNotification(), "text/plain", nil)
+	require.ErrorIs(t, err, ErrUnsupportedContentType)
+}
+
+func TestDecodeVersions(t *testing.T) {
+	envelope := func(version string) *pb.Envelope {
+		return &pb.Envelope{
+			Type:          TypeReminder,
+			SchemaVersion: version,
+			Payload:       &pb.Envelope_Notification{Notification: &pb.Notification{Id: "n-1"}},
+		}
+	}
+
+	testData := []struct {
+		version string
+		err     error
+	}{
+		{version: "1.0"},
+		{version: "1.7"},
+		{version: "2.0", err: ErrUnsupportedVersion},
+		{version: "0.9", err: ErrUnsupportedVersion},
+		{version: "next", err: ErrUnsupportedVersion},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.version, func(t *testing.T) {
+			jsonBody, err := protojson.Marshal(envelope(tt.version))
+			require.NoError(t, err)
+			protoBody, err := proto.Marshal(envelope(tt.version))
+			require.NoError(t, err)
+
+			for contentType, body := range map[string][]byte{ContentTypeJSON: jsonBody, ContentTypeProtobuf: protoBody} {
+				notification, _, err := Decode(body, contentType)
+				if tt.err != nil {
+					require.ErrorIs(t, err, tt.err)
+					continue
+				}
+				require.NoError(t, err)
+				require.Equal(t, "n-1", notification.ID)
+			}
+		})
+	}
+}
+
+func TestDecodeIgnoresUnknownFields(t *testing.T) {
+	body := []byte(`{"id":"n-1","type":"notification.reminder","schemaVersion":"1.1",` +
+		`"notification":{"id":"n-1","priority":"high"},"replyTo":"x"}`)
+
+	notification, _, err := Decode(body, ContentTypeJSON)
+	require.NoError(t, err)
+	require.Equal(t, "n-1", notification.ID)
+}
+
+func TestDecodeLegacyJSON(t *testing.T) {
+	body, err := json.Marshal(testNotification())
+	require.NoError(t, err)
+
+	notification, _, err := Decode(body, ContentTypeJSON)
+	require.NoError(t, err)
+	require.Equal(t, testNotification(), notification)
+}
@@ -3,7 +3,14 @@ package messages
 import "time"
 
 type Notification struct {
-	Title     string    `json:"title"`
-	UserID    string    `json:"userId"`
-	StartTime time.Time `json:"startTime"`
+	ID             string    `json:"id"`
+	IdempotencyKey string    `json:"idempotencyKey"`
+	EventID        string    `json:"eventId"`
+	Title          string    `json:"title"`
+	UserID         string    `json:"userId"`
+	StartTime      time.Time `json:"startTime"`
+	NotifyAt       time.Time `json:"notifyAt"`
+	Channel        string    `json:"channel,omitempty"`
+	Message        string    `json:"message,omitempty"`
+	Late           bool      `json:"late,omitempty"` // sent after the maximum lateness, e.g. when catching up
 }
@@ -0,0 +1,48 @@
+// Package pgtest starts PostgreSQL in a container for the tests that need a real database.
+package pgtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+const (
+	testDBName     = "test-db"
+	testDBUser     = "postgres"
+	testDBPassword = "postgres"
+	testDBImage    = "postgres:16"
+)
+
+// CreatePostgresContainer starts an empty database, which is terminated when the test ends,
+// and returns its connection string. It fails the test if the container cannot be started.
+func CreatePostgresContainer(ctx context.Context, t *testing.T) (string, error) {
+	t.Helper()
+	pgContainer, err := postgres.Run(ctx,
+		testDBImage,
+		postgres.WithDatabase(testDBName),
+		postgres.WithUsername(testDBUser),
+		postgres.WithPassword(testDBPassword),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).WithStartupTimeout(5*time.Second)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := pgContainer.Terminate(ctx); err != nil {
+			t.Fatalf("failed to terminate pgContainer: %s", err)
+		}
+	})
+	connStr, err := pgContainer.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return connStr, err
+}
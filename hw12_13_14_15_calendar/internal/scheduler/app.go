@@ -3,39 +3,65 @@ package scheduler
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
-	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/amqp"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/bus"
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/health"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/leader"
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/logger"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/server/admin"
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/tracing"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/webhook"
 )
 
-type Logger interface {
-	Info(msg string)
-	Error(msg string)
-}
-
 type App struct {
 	storage  storage.Storage
-	logger   Logger
+	logger   *logger.Logger
 	config   *conf.SchedulerConfig
-	producer *amqp.Producer
+	bus      bus.MessageBus
+	webhooks *webhook.Dispatcher
+	elector  leader.Elector
+	// leaderUntil is the end of the lease, the scheduler works only while it is the leader
+	leaderUntil time.Time
+	// scanFrom is the watermark, all reminders before it have been enqueued,
+	// it is persisted to survive restarts and failovers
+	scanFrom time.Time
+	// timers hold the reminders loaded at reconciledAt, timer fires at the earliest of them
+	timers       *timerQueue
+	timer        *time.Timer
+	reconciledAt time.Time
+	// changes receives IDs of events changed in the storage
+	changes chan string
+	// reloads receives reloaded configs, see Reload
+	reloads chan *conf.SchedulerConfig
 }
 
-func New(config *conf.SchedulerConfig) *App {
+func New(logg *logger.Logger, config *conf.SchedulerConfig) *App {
 	return &App{
-		config: config,
+		logger:  logg.Named("scheduler"),
+		config:  config,
+		timers:  newTimerQueue(),
+		changes: make(chan string, changesBufferSize),
+		reloads: make(chan *conf.SchedulerConfig, 1),
 	}
 }
 
 func (a *App) Run(ctx context.Context) error {
-	// create logger
-	logg, err := logger.New(a.config.Logger.Level)
+	// set up tracing
+	shutdownTracing, err := tracing.Setup(ctx, &a.config.Tracing)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to set up tracing: %w", err)
 	}
-	a.logger = logg
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			a.logger.Error(err.Error())
+		}
+	}()
 
 	// create storage
 	s, closeFunc, err := storage.NewFromConfig(&a.config.Storage)
@@ -47,63 +73,189 @@ func (a *App) Run(ctx context.Context) error {
 			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 			defer cancel()
 			if err := closeFunc(ctx); err != nil {
-				logg.Error(fmt.Sprintf("failed to close storage: %s", err))
+				a.logger.ErrorContext(ctx, "failed to close storage", "error", err)
 			}
 		}()
 	}
 	a.storage = s
 
-	// create producer
-	producer, err := amqp.NewProducer(&a.config.AMQP)
+	// create leader elector
+	elector, err := leader.NewFromConfig(&a.config.Storage, &a.config.Leader)
 	if err != nil {
-		return fmt.Errorf("failed to create producer: %w", err)
+		return fmt.Errorf("failed to create leader elector: %w", err)
 	}
-	a.producer = producer
+	a.elector = elector
 	defer func() {
-		if err := producer.Close(); err != nil {
-			logg.Error(fmt.Sprintf("failed to close producer: %s", err))
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := elector.Resign(ctx); err != nil {
+			a.logger.ErrorContext(ctx, "failed to resign leadership", "error", err)
 		}
 	}()
 
+	// start webhook dispatcher
+	a.webhooks = webhook.NewDispatcher(s, a.logger.Named("webhook"), &a.config.Webhooks)
+	a.webhooks.Start()
+	defer a.webhooks.Stop()
+
+	// create message bus
+	messageBus, err := bus.NewFromConfig(a.logger.Named("bus"), &a.config.Bus, &a.config.AMQP, &a.config.Storage)
+	if err != nil {
+		return fmt.Errorf("failed to create message bus: %w", err)
+	}
+	a.bus = messageBus
+	defer func() {
+		if err := messageBus.Close(); err != nil {
+			a.logger.ErrorContext(ctx, "failed to close message bus", "error", err)
+		}
+	}()
+
+	// serve metrics and health probes, readiness needs the storage and the message bus
+	checker := health.NewChecker(health.CheckTimeout)
+	checker.Add("storage", s.Ping)
+	checker.Add("bus", func(context.Context) error {
+		return messageBus.Ready()
+	})
+	if addr := a.config.Admin.BindAddr; addr != "" {
+		go func() {
+			if err := admin.NewServer(a.logger.Named("admin"), addr, checker).Run(ctx); err != nil {
+				a.logger.Error(err.Error())
+			}
+		}()
+	}
+
 	a.logger.Info("starting scheduler")
+	go a.listenChanges(ctx)
 	return a.runInternal(ctx)
 }
 
 func (a *App) runInternal(ctx context.Context) error {
-	scheduleTicker := time.NewTicker(time.Duration(a.config.ScanInterval) * time.Second)
-	defer scheduleTicker.Stop()
+	reconcileTicker := time.NewTicker(a.scanInterval())
+	defer reconcileTicker.Stop()
+
+	a.timer = time.NewTimer(0)
+	a.stopTimer()
+	defer a.timer.Stop()
+
+	relayTicker := time.NewTicker(time.Duration(a.config.RelayInterval) * time.Second)
+	defer relayTicker.Stop()
 
 	cleanTicker := time.NewTicker(time.Duration(a.config.CleanInterval) * time.Second)
 	defer cleanTicker.Stop()
 
+	// the elector is used by the campaign goroutine only, it has to exit before the elector resigns
+	campaigns := make(chan campaignResult)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		a.campaignLoop(ctx, leader.RetryInterval(&a.config.Leader), campaigns)
+	}()
+	defer wg.Wait()
+
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
-		case <-scheduleTicker.C:
-			a.logger.Info("scheduling events")
-			now := time.Now()
-
-			// filter events that belong to range now <= event.StartTime - event.NotifyDelay < now + ScanInterval
-			rangeStart := now
-			rangeEnd := now.Add(time.Duration(a.config.ScanInterval) * time.Second)
-			n, err := a.scanAndSendEvents(rangeStart, rangeEnd)
-			if err != nil {
-				a.logger.Error(fmt.Sprintf("failed to scan events: %s", err))
-			} else {
-				a.logger.Info(fmt.Sprintf("sent %d notifications", n))
+		case result := <-campaigns:
+			if a.applyCampaign(ctx, result) {
+				a.schedule(ctx)
+			}
+		case <-reconcileTicker.C:
+			if !a.isLeader() {
+				continue
+			}
+			a.schedule(ctx)
+		case eventID := <-a.changes:
+			if !a.isLeader() {
+				continue
 			}
+			a.rescheduleChanged(ctx, eventID)
+		case config := <-a.reloads:
+			a.applyConfig(config, reconcileTicker, relayTicker, cleanTicker)
+		case <-a.timer.C:
+			if !a.isLeader() {
+				continue
+			}
+			a.fireDue(ctx, time.Now())
+		case <-relayTicker.C:
+			if !a.isLeader() {
+				continue
+			}
+			a.relay(ctx)
 		case <-cleanTicker.C:
+			if !a.isLeader() {
+				continue
+			}
 			if err := a.cleanOldEvents(ctx); err != nil {
-				a.logger.Error(fmt.Sprintf("failed to clean old events: %s", err))
+				a.logger.ErrorContext(ctx, "failed to clean old events", "error", err)
 			}
 		}
 	}
 }
 
+// schedule reloads the timers from the storage and fires the reminders that are already due.
+func (a *App) schedule(ctx context.Context) {
+	now := time.Now()
+	err := a.reconcile(ctx, now)
+	scanDuration.Observe(time.Since(now).Seconds())
+	if err != nil {
+		a.logger.ErrorContext(ctx, "failed to reconcile timers", "error", err)
+		return
+	}
+	a.logger.InfoContext(ctx, "reminders are scheduled", "count", a.timers.Len())
+	a.fireDue(ctx, now)
+}
+
+// fireDue fires the reminders due before until and rearms the timer for the next one.
+func (a *App) fireDue(ctx context.Context, until time.Time) {
+	n, err := a.fire(ctx, until)
+	if err != nil {
+		a.logger.ErrorContext(ctx, "failed to fire reminders", "error", err)
+	}
+	remindersEnqueued.Add(float64(n))
+	if n > 0 {
+		a.logger.InfoContext(ctx, "enqueued notifications", "count", n)
+		a.relay(ctx)
+	}
+	a.resetTimer()
+}
+
+func (a *App) resetTimer() {
+	a.stopTimer()
+	if next, ok := a.timers.next(); ok {
+		a.timer.Reset(time.Until(next))
+	}
+}
+
+func (a *App) stopTimer() {
+	if !a.timer.Stop() {
+		// drain the channel if the timer has fired but has not been received
+		select {
+		case <-a.timer.C:
+		default:
+		}
+	}
+}
+
+func (a *App) scanInterval() time.Duration {
+	return time.Duration(a.config.ScanInterval) * time.Second
+}
+
+func (a *App) relay(ctx context.Context) {
+	n, err := a.relayOutbox(ctx)
+	if err != nil {
+		a.logger.ErrorContext(ctx, "failed to relay outbox", "error", err)
+	}
+	remindersSent.Add(float64(n))
+	if n > 0 {
+		a.logger.InfoContext(ctx, "sent notifications", "count", n)
+	}
+}
+
 func (a *App) Stop() error {
-	if err := a.producer.Close(); err != nil {
-		return fmt.Errorf("failed to close producer: %w", err)
+	if err := a.bus.Close(); err != nil {
+		return fmt.Errorf("failed to close message bus: %w", err)
 	}
 	return nil
 }
@@ -0,0 +1,75 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/leader"
+)
+
+// campaignResult is the outcome of a campaign, the lease is counted from its start.
+type campaignResult struct {
+	isLeader bool
+	started  time.Time
+}
+
+// campaignLoop campaigns every interval and passes the outcomes to results until ctx is done.
+// A campaign may block for up to the lease duration, so it runs apart from the scheduler loop.
+func (a *App) campaignLoop(ctx context.Context, interval time.Duration, results chan<- campaignResult) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		started := time.Now()
+		isLeader, err := a.elector.Campaign(ctx)
+		if err != nil && ctx.Err() == nil {
+			a.logger.ErrorContext(ctx, "failed to campaign for leadership", "error", err)
+		}
+		select {
+		case results <- campaignResult{isLeader: isLeader, started: started}:
+		case <-ctx.Done():
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// applyCampaign updates the leadership state of the scheduler loop, a successful campaign
+// extends the lease. It reports whether the scheduler has just become the leader.
+func (a *App) applyCampaign(ctx context.Context, result campaignResult) bool {
+	wasLeader := a.isLeader()
+	leaderUntil := result.started.Add(leader.LeaseDuration(&a.config.Leader))
+	// a campaign that has outlasted the lease cannot vouch for the leadership
+	isLeader := result.isLeader && time.Now().Before(leaderUntil)
+	switch {
+	case isLeader && !wasLeader:
+		// continue from where the previous leader, or this scheduler before a restart, stopped
+		watermark, err := a.loadWatermark(ctx)
+		if err != nil {
+			a.logger.ErrorContext(ctx, "failed to take over the leadership", "error", err)
+			return false
+		}
+		a.scanFrom = watermark
+		a.leaderUntil = leaderUntil
+		a.logger.InfoContext(ctx, "became the leader")
+		return true
+	case isLeader:
+		a.leaderUntil = leaderUntil
+	case wasLeader:
+		a.leaderUntil = time.Time{}
+		a.scanFrom = time.Time{}
+		a.timers.reset(nil)
+		a.stopTimer()
+		a.logger.InfoContext(ctx, "lost the leadership, standing by")
+	}
+	return false
+}
+
+// isLeader reports whether the lease is still valid. The lease expires on its own if
+// renewals are late, so a stalled leader stops before a standby takes over.
+func (a *App) isLeader() bool {
+	return time.Now().Before(a.leaderUntil)
+}
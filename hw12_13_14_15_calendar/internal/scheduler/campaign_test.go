@@ -0,0 +1,80 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/logger"
+	memorystorage "github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingElector holds every campaign until it is released.
+type blockingElector struct {
+	release chan bool
+}
+
+func (e *blockingElector) Campaign(ctx context.Context) (bool, error) {
+	select {
+	case isLeader := <-e.release:
+		return isLeader, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+func (e *blockingElector) Resign(_ context.Context) error {
+	return nil
+}
+
+func TestCampaignDoesNotBlockLoop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logg, err := logger.New("ERROR")
+	require.NoError(t, err)
+	a := New(logg, &conf.SchedulerConfig{ScanInterval: 30})
+	elector := &blockingElector{release: make(chan bool)}
+	a.elector = elector
+
+	results := make(chan campaignResult)
+	go a.campaignLoop(ctx, time.Millisecond, results)
+
+	// the loop is free while the campaign is pending
+	select {
+	case <-results:
+		t.Fatal("the campaign has not finished yet")
+	case <-time.After(20 * time.Millisecond):
+	}
+	elector.release <- true
+	result := <-results
+	require.True(t, result.isLeader)
+}
+
+func TestApplyCampaign(t *testing.T) {
+	ctx := context.Background()
+	logg, err := logger.New("ERROR")
+	require.NoError(t, err)
+	a := New(logg, &conf.SchedulerConfig{ScanInterval: 30, Leader: conf.LeaderConf{LeaseDuration: 10}})
+	a.storage = memorystorage.New()
+	a.timer = time.NewTimer(time.Hour)
+	defer a.timer.Stop()
+
+	now := time.Now()
+	require.True(t, a.applyCampaign(ctx, campaignResult{isLeader: true, started: now}))
+	require.True(t, a.isLeader())
+	require.Equal(t, now.Add(10*time.Second), a.leaderUntil)
+
+	// a renewal extends the lease without taking over again
+	require.False(t, a.applyCampaign(ctx, campaignResult{isLeader: true, started: now.Add(time.Second)}))
+	require.Equal(t, now.Add(11*time.Second), a.leaderUntil)
+
+	require.False(t, a.applyCampaign(ctx, campaignResult{isLeader: false, started: now.Add(2 * time.Second)}))
+	require.False(t, a.isLeader())
+
+	// a campaign that took longer than the lease does not make a leader
+	require.False(t, a.applyCampaign(ctx, campaignResult{isLeader: true, started: now.Add(-time.Minute)}))
+	require.False(t, a.isLeader())
+}
@@ -0,0 +1,58 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+)
+
+const (
+	// LatePolicyDrop skips reminders missed by more than MaxLateness.
+	LatePolicyDrop = "drop"
+	// LatePolicySend sends reminders missed by more than MaxLateness marked as late.
+	LatePolicySend = "send"
+
+	watermarkName = "scan"
+)
+
+// scanRange returns the range of reminders to load into the timers. The range continues
+// from the watermark, so reminders missed while the scheduler was down are caught up,
+// as far as the late policy allows.
+func (a *App) scanRange(ctx context.Context, now time.Time) (rangeStart, rangeEnd time.Time) {
+	rangeStart = now
+	if !a.scanFrom.IsZero() {
+		rangeStart = a.scanFrom
+	}
+	// the range overlaps the next reconciliation, so a late tick does not leave a gap
+	rangeEnd = now.Add(2 * a.scanInterval())
+
+	lateBefore := a.lateBefore(now)
+	if a.config.LatePolicy == LatePolicyDrop && rangeStart.Before(lateBefore) {
+		a.logger.InfoContext(ctx, "dropping late reminders", "from", rangeStart, "until", lateBefore)
+		rangeStart = lateBefore
+	}
+	return rangeStart, rangeEnd
+}
+
+// lateBefore returns the time before which reminders fired at now are late, zero time if there is no limit.
+func (a *App) lateBefore(now time.Time) time.Time {
+	if a.config.MaxLateness <= 0 {
+		return time.Time{}
+	}
+	return now.Add(-time.Duration(a.config.MaxLateness) * time.Second)
+}
+
+// loadWatermark returns the end of the range scanned last, or zero time if nothing has been scanned yet.
+func (a *App) loadWatermark(ctx context.Context) (time.Time, error) {
+	watermark, err := a.storage.GetSchedulerWatermark(ctx, watermarkName)
+	if errors.Is(err, model.ErrWatermarkNotFound) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to load watermark: %w", err)
+	}
+	return watermark, nil
+}
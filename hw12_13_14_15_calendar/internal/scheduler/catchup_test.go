@@ -0,0 +1,133 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/logger"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/messages"
+	memorystorage "github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanRange(t *testing.T) {
+	now := time.Date(2024, 10, 1, 13, 0, 0, 0, time.UTC)
+	// the scheduler was down for ten minutes
+	watermark := now.Add(-10 * time.Minute)
+
+	testData := []struct {
+		name       string
+		config     conf.SchedulerConfig
+		scanFrom   time.Time
+		rangeStart time.Time
+		lateBefore time.Time
+	}{
+		{
+			name:       "first scan starts now",
+			config:     conf.SchedulerConfig{ScanInterval: 30},
+			rangeStart: now,
+		},
+		{
+			name:       "catch up without limit",
+			config:     conf.SchedulerConfig{ScanInterval: 30},
+			scanFrom:   watermark,
+			rangeStart: watermark,
+		},
+		{
+			name:       "late reminders are dropped",
+			config:     conf.SchedulerConfig{ScanInterval: 30, MaxLateness: 60, LatePolicy: LatePolicyDrop},
+			scanFrom:   watermark,
+			rangeStart: now.Add(-time.Minute),
+			lateBefore: now.Add(-time.Minute),
+		},
+		{
+			name:       "late reminders are sent",
+			config:     conf.SchedulerConfig{ScanInterval: 30, MaxLateness: 60, LatePolicy: LatePolicySend},
+			scanFrom:   watermark,
+			rangeStart: watermark,
+			lateBefore: now.Add(-time.Minute),
+		},
+	}
+
+	logg, err := logger.New("ERROR")
+	require.NoError(t, err)
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &App{config: &tt.config, logger: logg, scanFrom: tt.scanFrom}
+			rangeStart, rangeEnd := a.scanRange(context.Background(), now)
+			require.Equal(t, tt.rangeStart, rangeStart)
+			// the range overlaps the next reconciliation
+			require.Equal(t, now.Add(time.Minute), rangeEnd)
+			require.Equal(t, tt.lateBefore, a.lateBefore(now))
+		})
+	}
+}
+
+func TestOutboxMessagesLatePolicy(t *testing.T) {
+	start := time.Date(2024, 10, 1, 13, 0, 0, 0, time.UTC)
+	event := &model.Event{
+		ID:        "1",
+		StartTime: start,
+		Reminders: []model.Reminder{
+			{Offset: time.Hour, Channel: "email"},
+			{Offset: 10 * time.Minute, Channel: "webhook"},
+		},
+	}
+	due := dueReminders([]*model.Event{event}, start.Add(-2*time.Hour), start)
+	lateBefore := start.Add(-30 * time.Minute)
+
+	outbox, dropped, err := outboxMessages(due, lateBefore, false)
+	require.NoError(t, err)
+	require.Zero(t, dropped)
+	require.Len(t, outbox, 2)
+	for i, late := range []bool{true, false} {
+		notification := messages.Notification{}
+		require.NoError(t, json.Unmarshal(outbox[i].Payload, &notification))
+		require.Equal(t, late, notification.Late, notification.Channel)
+	}
+
+	outbox, dropped, err = outboxMessages(due, lateBefore, true)
+	require.NoError(t, err)
+	require.Equal(t, 1, dropped)
+	require.Len(t, outbox, 1)
+}
+
+func TestCatchUpAfterDowntime(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	s := memorystorage.NewWithEvents([]*model.Event{
+		// due while the scheduler was down
+		{ID: "missed", StartTime: now.Add(-5 * time.Minute), Reminders: []model.Reminder{{Offset: time.Minute}}},
+		// due before the previous run, already sent
+		{ID: "sent", StartTime: now.Add(-20 * time.Minute), Reminders: []model.Reminder{{Offset: time.Minute}}},
+		{ID: "upcoming", StartTime: now.Add(time.Hour), Reminders: []model.Reminder{{Offset: time.Minute}}},
+	})
+	require.NoError(t, s.SaveSchedulerWatermark(ctx, watermarkName, now.Add(-10*time.Minute)))
+
+	logg, err := logger.New("ERROR")
+	require.NoError(t, err)
+	a := New(logg, &conf.SchedulerConfig{ScanInterval: 30})
+	a.storage = s
+
+	a.scanFrom, err = a.loadWatermark(ctx)
+	require.NoError(t, err)
+	require.NoError(t, a.reconcile(ctx, now))
+	n, err := a.fire(ctx, now)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), n)
+
+	pending, err := s.FetchPendingOutboxMessages(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	notification := messages.Notification{}
+	require.NoError(t, json.Unmarshal(pending[0].Payload, &notification))
+	require.Equal(t, "missed", notification.EventID)
+
+	watermark, err := s.GetSchedulerWatermark(ctx, watermarkName)
+	require.NoError(t, err)
+	require.Equal(t, now, watermark)
+}
@@ -0,0 +1,56 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	changesBufferSize   = 100
+	listenRetryInterval = 5 * time.Second
+)
+
+// listenChanges forwards IDs of changed events to a.changes until ctx is done,
+// reconnecting when the listener fails. Periodic reconciliation covers the time
+// the listener is down and the changes dropped on a full buffer.
+func (a *App) listenChanges(ctx context.Context) {
+	for {
+		err := a.storage.ListenEventChanges(ctx, func(eventID string) {
+			select {
+			case a.changes <- eventID:
+			default:
+			}
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			a.logger.ErrorContext(ctx, "failed to listen for event changes", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetryInterval):
+		}
+	}
+}
+
+// rescheduleChanged reloads the timers after the event has changed. Changes that arrived
+// meanwhile are handled by the same reload.
+func (a *App) rescheduleChanged(ctx context.Context, eventID string) {
+	n := 1
+	for drained := false; !drained; {
+		select {
+		case <-a.changes:
+			n++
+		default:
+			drained = true
+		}
+	}
+	if n == 1 {
+		a.logger.InfoContext(ctx, "event changed, rescheduling", "event_id", eventID)
+	} else {
+		a.logger.InfoContext(ctx, "events changed, rescheduling", "count", n)
+	}
+	a.schedule(ctx)
+}
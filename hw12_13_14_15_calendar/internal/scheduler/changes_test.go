@@ -0,0 +1,50 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/logger"
+	memorystorage "github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRescheduleOnChange(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logg, err := logger.New("ERROR")
+	require.NoError(t, err)
+	a := New(logg, &conf.SchedulerConfig{ScanInterval: 30})
+	a.storage = memorystorage.New()
+	a.timer = time.NewTimer(time.Hour)
+	defer a.timer.Stop()
+	go a.listenChanges(ctx)
+
+	// created right after a reconciliation, the reminder is due long before the next one
+	event := &model.Event{
+		ID:        "1",
+		StartTime: time.Now().Add(2 * time.Minute),
+		Reminders: []model.Reminder{{Offset: time.Minute + 50*time.Second}},
+	}
+	require.Eventually(t, func() bool {
+		// the listener registers asynchronously, repeat the change until it is seen
+		if err := a.storage.UpdateEvent(ctx, event); err != nil {
+			require.NoError(t, a.storage.CreateEvent(ctx, event))
+		}
+		select {
+		case eventID := <-a.changes:
+			a.rescheduleChanged(ctx, eventID)
+			return true
+		default:
+			return false
+		}
+	}, time.Second, 10*time.Millisecond)
+
+	next, ok := a.timers.next()
+	require.True(t, ok)
+	require.Equal(t, event.Reminders[0].NotifyAt(event), next)
+}
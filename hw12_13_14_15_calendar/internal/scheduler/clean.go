@@ -4,21 +4,49 @@ import (
 	"context"
 	"fmt"
 	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
 )
 
+// cleanOldEvents moves old events into the archive, they stay searchable and restorable there.
 func (a *App) cleanOldEvents(ctx context.Context) error {
-	threshold := time.Now().AddDate(0, 0, -a.config.CleanThresholdDays)
-	a.logger.Info(fmt.Sprintf("removing events older than %s", threshold))
+	now := time.Now()
+	retention := model.Retention{
+		Before: archiveBefore(now, a.config.CleanThresholdDays),
+		Users:  make(map[string]time.Time, len(a.config.Retention.Users)),
+	}
+	for userID, days := range a.config.Retention.Users {
+		retention.Users[userID] = archiveBefore(now, days)
+	}
+	a.logger.InfoContext(ctx, "archiving old events",
+		"threshold_days", a.config.CleanThresholdDays, "users_with_retention", len(retention.Users))
 
-	deletedCount, err := a.storage.DeleteEventsOlderThan(ctx, threshold)
+	archivedCount, err := a.storage.ArchiveEvents(ctx, retention)
 	if err != nil {
-		return fmt.Errorf("failed to clean old events: %w", err)
+		return fmt.Errorf("failed to archive old events: %w", err)
 	}
 
-	if deletedCount > 0 {
-		a.logger.Info(fmt.Sprintf("removed %d old events", deletedCount))
+	if archivedCount > 0 {
+		a.logger.InfoContext(ctx, "archived old events", "count", archivedCount)
 	} else {
-		a.logger.Info("no old events to remove")
+		a.logger.InfoContext(ctx, "no old events to archive")
+	}
+
+	outboxThreshold := time.Now().AddDate(0, 0, -a.config.OutboxRetentionDays)
+	deletedCount, err := a.storage.DeleteSentOutboxMessagesOlderThan(ctx, outboxThreshold)
+	if err != nil {
+		return fmt.Errorf("failed to clean outbox: %w", err)
+	}
+	if deletedCount > 0 {
+		a.logger.InfoContext(ctx, "removed sent outbox messages", "count", deletedCount)
 	}
 	return nil
 }
+
+// archiveBefore returns the start time before which events are archived, zero days keep them.
+func archiveBefore(now time.Time, days int) time.Time {
+	if days <= 0 {
+		return time.Time{}
+	}
+	return now.AddDate(0, 0, -days)
+}
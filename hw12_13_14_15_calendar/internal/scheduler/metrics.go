@@ -0,0 +1,34 @@
+package scheduler
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	scanDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "calendar",
+		Subsystem: "scheduler",
+		Name:      "scan_duration_seconds",
+		Help:      "Time spent loading the upcoming reminders from the storage.",
+		Buckets:   prometheus.DefBuckets,
+	})
+	remindersEnqueued = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "calendar",
+		Subsystem: "scheduler",
+		Name:      "reminders_enqueued_total",
+		Help:      "Number of due reminders written to the outbox.",
+	})
+	remindersSent = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "calendar",
+		Subsystem: "scheduler",
+		Name:      "reminders_sent_total",
+		Help:      "Number of reminders relayed from the outbox to the message bus.",
+	})
+	relayFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "calendar",
+		Subsystem: "scheduler",
+		Name:      "relay_failures_total",
+		Help:      "Number of outbox messages that failed to be published, they are retried.",
+	})
+)
@@ -0,0 +1,114 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/bus"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/logger"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/messages"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/tracing"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/webhook"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/scheduler")
+
+// relayOutbox publishes pending outbox messages and marks them as sent.
+// A message is marked only after the broker has confirmed it, so a crash in between
+// leads to a repeated delivery rather than a lost one (at-least-once).
+func (a *App) relayOutbox(ctx context.Context) (int, error) {
+	messages, err := a.storage.FetchPendingOutboxMessages(ctx, a.config.RelayBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch outbox messages: %w", err)
+	}
+
+	n := 0
+	for _, m := range messages {
+		sent, err := a.relayMessage(ctx, m)
+		if err != nil {
+			return n, err
+		}
+		if sent {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// relayMessage publishes the outbox message in a span of its own, the sender continues the trace.
+// A malformed message is marked as failed and skipped, retrying does not help it.
+func (a *App) relayMessage(ctx context.Context, m *model.OutboxMessage) (sent bool, err error) {
+	ctx = logger.WithFields(ctx, "outbox_message_id", m.ID)
+	ctx, span := tracer.Start(ctx, "scheduler.publish", trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attribute.String("messaging.message.id", m.IdempotencyKey)))
+	defer func() {
+		tracing.End(span, err)
+	}()
+
+	msg, notification, err := a.busMessage(ctx, m.IdempotencyKey, m.Payload)
+	if err != nil {
+		relayFailures.Inc()
+		tracing.Fail(span, err)
+		a.logger.ErrorContext(ctx, "failed to encode outbox message", "error", err)
+		if markErr := a.storage.MarkOutboxMessageFailed(ctx, m.ID, err.Error()); markErr != nil {
+			a.logger.ErrorContext(ctx, "failed to mark outbox message as failed", "error", markErr)
+		}
+		return false, nil
+	}
+	// the delay tells whether a late reminder was late before it left the scheduler
+	span.SetAttributes(
+		attribute.String("notification.id", notification.ID),
+		attribute.String("event.id", notification.EventID),
+		attribute.String("user.id", notification.UserID),
+		attribute.String("reminder.notify_at", notification.NotifyAt.Format(time.RFC3339)),
+		attribute.Int64("reminder.delay_ms", time.Since(notification.NotifyAt).Milliseconds()),
+	)
+	if err := a.bus.Publish(ctx, msg); err != nil {
+		relayFailures.Inc()
+		if markErr := a.storage.MarkOutboxMessageFailed(ctx, m.ID, err.Error()); markErr != nil {
+			a.logger.ErrorContext(ctx, "failed to mark outbox message as failed", "error", markErr)
+		}
+		// the broker is most likely unavailable, the rest is retried on the next tick
+		return false, fmt.Errorf("failed to publish outbox message %s: %w", m.ID, err)
+	}
+	if err := a.storage.MarkOutboxMessageSent(ctx, m.ID, time.Now()); err != nil {
+		return false, fmt.Errorf("failed to mark outbox message %s as sent: %w", m.ID, err)
+	}
+	a.publishReminderFired(ctx, notification)
+	return true, nil
+}
+
+// busMessage wraps the notification stored in the outbox into a versioned envelope,
+// the envelope is encoded as configured for the bus. The trace context of ctx is passed
+// both in the envelope and in the message headers, the headers are lost by some transports.
+func (a *App) busMessage(
+	ctx context.Context, messageID string, payload []byte,
+) (bus.Message, *messages.Notification, error) {
+	var notification messages.Notification
+	if err := json.Unmarshal(payload, &notification); err != nil {
+		return bus.Message{}, nil, fmt.Errorf("failed to unmarshal notification: %w", err)
+	}
+	contentType := a.config.Bus.ContentType
+	if contentType == "" {
+		contentType = messages.ContentTypeJSON
+	}
+	traceContext := tracing.Inject(ctx)
+	body, err := messages.Encode(&notification, contentType, traceContext)
+	if err != nil {
+		return bus.Message{}, nil, err
+	}
+	return bus.Message{ID: messageID, ContentType: contentType, Body: body, Headers: traceContext}, &notification, nil
+}
+
+func (a *App) publishReminderFired(ctx context.Context, notification *messages.Notification) {
+	if a.webhooks == nil {
+		return
+	}
+	a.webhooks.Publish(ctx, webhook.ReminderFired, notification.UserID, notification)
+}
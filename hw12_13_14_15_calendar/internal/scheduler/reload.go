@@ -0,0 +1,34 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
+)
+
+// Reload passes a reloaded config to the scheduler loop, it is meant to be subscribed to conf.Reloader.
+// The settings needing a restart are the same in the new config, the loop applies the rest:
+// the log level, the intervals, the cleanup threshold and retention, and the handling of late reminders.
+func (a *App) Reload(config *conf.SchedulerConfig) {
+	select {
+	case <-a.reloads:
+		// the previous config has not been applied yet, the new one supersedes it
+	default:
+	}
+	a.reloads <- config
+}
+
+func (a *App) applyConfig(config *conf.SchedulerConfig, reconcile, relay, clean *time.Ticker) {
+	// the named logger shares the levels with the root one
+	if err := a.logger.Configure(&config.Logger); err != nil {
+		a.logger.ErrorContext(context.Background(), "failed to configure logger", "error", err)
+	}
+	a.config = config
+	reconcile.Reset(a.scanInterval())
+	relay.Reset(time.Duration(config.RelayInterval) * time.Second)
+	clean.Reset(time.Duration(config.CleanInterval) * time.Second)
+	a.logger.InfoContext(context.Background(), "config reloaded",
+		"scan_interval", config.ScanInterval, "relay_interval", config.RelayInterval,
+		"clean_interval", config.CleanInterval, "clean_threshold_days", config.CleanThresholdDays)
+}
@@ -0,0 +1,41 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/logger"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReload(t *testing.T) {
+	logg, err := logger.New("INFO")
+	require.NoError(t, err)
+	a := New(logg, &conf.SchedulerConfig{ScanInterval: 30, RelayInterval: 5, CleanInterval: 3600})
+
+	// only the latest config is applied when the loop falls behind
+	first := &conf.SchedulerConfig{ScanInterval: 20, RelayInterval: 5, CleanInterval: 3600}
+	second := &conf.SchedulerConfig{
+		ScanInterval: 10, RelayInterval: 1, CleanInterval: 60, CleanThresholdDays: 30,
+		Logger: conf.LoggerConf{Level: "ERROR"},
+	}
+	a.Reload(first)
+	a.Reload(second)
+	config := <-a.reloads
+	require.Same(t, second, config)
+
+	reconcile, relay, clean := time.NewTicker(time.Hour), time.NewTicker(time.Hour), time.NewTicker(time.Hour)
+	defer reconcile.Stop()
+	defer relay.Stop()
+	defer clean.Stop()
+	a.applyConfig(config, reconcile, relay, clean)
+	require.Equal(t, 10*time.Second, a.scanInterval())
+	require.Equal(t, 30, a.config.CleanThresholdDays)
+	require.Equal(t, logger.Error, logg.Level())
+	select {
+	case <-relay.C:
+	case <-time.After(3 * time.Second):
+		t.Fatal("relay ticker was not reset")
+	}
+}
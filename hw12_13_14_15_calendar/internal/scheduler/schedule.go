@@ -2,51 +2,139 @@ package scheduler
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/messages"
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+	"github.com/google/uuid"
 )
 
-func (a *App) sendNotification(event *model.Event) error {
+// idempotencyKey identifies a single reminder of the event, so overlapping scans
+// produce the same key and the outbox keeps only one copy.
+func idempotencyKey(event *model.Event, reminder model.Reminder) string {
+	return fmt.Sprintf("%s:%d:%s", event.ID, reminder.NotifyAt(event).Unix(), reminder.Channel)
+}
+
+func newOutboxMessage(event *model.Event, reminder model.Reminder, late bool) (*model.OutboxMessage, error) {
+	id := uuid.NewString()
+	key := idempotencyKey(event, reminder)
 	notification := messages.Notification{
-		Title:     event.Title,
-		UserID:    event.UserID,
-		StartTime: event.StartTime,
+		ID:             id,
+		IdempotencyKey: key,
+		EventID:        event.ID,
+		Title:          event.Title,
+		UserID:         event.UserID,
+		StartTime:      event.StartTime,
+		NotifyAt:       reminder.NotifyAt(event),
+		Channel:        reminder.Channel,
+		Message:        reminder.Message,
+		Late:           late,
 	}
-	if err := a.producer.Publish(&notification); err != nil {
-		return fmt.Errorf("failed to publish message: %w", err)
+	payload, err := json.Marshal(&notification)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal notification: %w", err)
 	}
-	return nil
+	return &model.OutboxMessage{
+		ID:             id,
+		IdempotencyKey: key,
+		Payload:        payload,
+		CreatedAt:      time.Now(),
+	}, nil
+}
+
+// dueReminders returns the reminders of the events that fire within [rangeStart, rangeEnd).
+func dueReminders(events []*model.Event, rangeStart, rangeEnd time.Time) []*dueReminder {
+	var due []*dueReminder
+	for _, event := range events {
+		for _, reminder := range event.Reminders {
+			notifyAt := reminder.NotifyAt(event)
+			if notifyAt.Before(rangeStart) || !notifyAt.Before(rangeEnd) {
+				continue
+			}
+			due = append(due, &dueReminder{
+				event:    event,
+				reminder: reminder,
+				notifyAt: notifyAt,
+				key:      idempotencyKey(event, reminder),
+			})
+		}
+	}
+	return due
+}
+
+// outboxMessages prepares notifications of the fired reminders. Reminders that should have fired
+// before lateBefore are marked as late, or skipped if dropLate is set.
+func outboxMessages(due []*dueReminder, lateBefore time.Time, dropLate bool) ([]*model.OutboxMessage, int, error) {
+	var outbox []*model.OutboxMessage
+	dropped := 0
+	for _, d := range due {
+		late := d.notifyAt.Before(lateBefore)
+		if late && dropLate {
+			dropped++
+			continue
+		}
+		m, err := newOutboxMessage(d.event, d.reminder, late)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to prepare notification for event %s: %w", d.event.ID, err)
+		}
+		outbox = append(outbox, m)
+	}
+	return outbox, dropped, nil
 }
 
-func (a *App) scanAndSendEvents(rangeStart, rangeEnd time.Time) (int, error) {
-	ctx, cancel := context.WithTimeout(
-		context.Background(),
-		time.Duration(a.config.ScanInterval)*time.Second-100*time.Millisecond,
-	)
+// reconcile reloads the timers from the storage. The loaded range starts at the watermark,
+// so reminders of events created or moved since the previous reconciliation are not missed,
+// and reaches a reconciliation interval beyond the next one.
+func (a *App) reconcile(ctx context.Context, now time.Time) error {
+	rangeStart, rangeEnd := a.scanRange(ctx, now)
+	ctx, cancel := context.WithTimeout(ctx, a.scanInterval())
 	defer cancel()
 
-	// we'll use FilterEventsByDay to get all events for the day
-	// and then filter them by time range
-	// just for the sake of simplicity
-	n := 0
-	events, err := a.storage.FilterEventsByDay(ctx, rangeStart)
+	// the storage returns events with all their reminders, the due ones are picked below
+	events, err := a.storage.FindEventsToNotify(ctx, rangeStart, rangeEnd)
 	if err != nil {
-		return n, fmt.Errorf("failed to get events: %w", err)
+		return fmt.Errorf("failed to find events to notify: %w", err)
 	}
+	a.timers.reset(dueReminders(events, rangeStart, rangeEnd))
+	a.reconciledAt = now
+	return nil
+}
 
-	for _, event := range events {
-		adjustedTime := event.StartTime.Add(-time.Duration(event.NotifyDelta) * time.Second)
-		if !(adjustedTime.After(rangeStart) && adjustedTime.Before(rangeEnd)) {
-			continue
+// fire enqueues the reminders due before until and advances the watermark.
+func (a *App) fire(ctx context.Context, until time.Time) (int64, error) {
+	due := a.timers.popDue(until)
+	var n int64
+	if len(due) > 0 {
+		outbox, dropped, err := outboxMessages(due, a.lateBefore(until), a.config.LatePolicy == LatePolicyDrop)
+		if err != nil {
+			return 0, err
+		}
+		if dropped > 0 {
+			a.logger.InfoContext(ctx, "dropped late reminders", "count", dropped)
 		}
-		if err := a.sendNotification(event); err != nil {
-			return n, fmt.Errorf("failed to send for event %s: %w", event.ID, err)
+		if len(outbox) > 0 {
+			// notifications already enqueued, e.g. before a restart, are skipped by the storage
+			n, err = a.storage.AddOutboxMessages(ctx, outbox)
+			if err != nil {
+				// fire them again on the next attempt
+				a.timers.add(due...)
+				return 0, fmt.Errorf("failed to enqueue notifications: %w", err)
+			}
 		}
-		n++
 	}
 
+	// events created after the last reconciliation are unknown yet, so the watermark does not
+	// pass it, and the next reconciliation picks such reminders up
+	watermark := until
+	if a.reconciledAt.Before(watermark) {
+		watermark = a.reconciledAt
+	}
+	a.scanFrom = watermark
+	if err := a.storage.SaveSchedulerWatermark(ctx, watermarkName, watermark); err != nil {
+		// the reminders are in the outbox already, a stale watermark only makes the next leader reload them
+		a.logger.ErrorContext(ctx, "failed to save watermark", "error", err)
+	}
 	return n, nil
 }
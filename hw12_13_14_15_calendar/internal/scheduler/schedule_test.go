@@ -0,0 +1,94 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/messages"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDueReminders(t *testing.T) {
+	start := time.Date(2024, 10, 1, 13, 0, 0, 0, time.UTC)
+	event := &model.Event{
+		ID:        "1",
+		Title:     "Kickoff meeting",
+		StartTime: start,
+		Reminders: []model.Reminder{
+			{Offset: 10 * time.Minute, Channel: "email"},
+			{Offset: time.Hour, Channel: "webhook", Message: "prepare slides"},
+		},
+	}
+
+	testData := []struct {
+		name       string
+		rangeStart time.Time
+		rangeEnd   time.Time
+		channels   []string
+	}{
+		{
+			name:       "nothing due",
+			rangeStart: start.Add(-2 * time.Hour),
+			rangeEnd:   start.Add(-time.Hour),
+		},
+		{
+			name:       "range start is inclusive",
+			rangeStart: start.Add(-time.Hour),
+			rangeEnd:   start.Add(-time.Hour + time.Minute),
+			channels:   []string{"webhook"},
+		},
+		{
+			name:       "range end is exclusive",
+			rangeStart: start.Add(-time.Hour + time.Minute),
+			rangeEnd:   start.Add(-10 * time.Minute),
+		},
+		{
+			name:       "both reminders",
+			rangeStart: start.Add(-2 * time.Hour),
+			rangeEnd:   start,
+			channels:   []string{"email", "webhook"},
+		},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			due := dueReminders([]*model.Event{event}, tt.rangeStart, tt.rangeEnd)
+			require.Len(t, due, len(tt.channels))
+			for i, d := range due {
+				require.Equal(t, tt.channels[i], d.reminder.Channel)
+				require.Equal(t, d.reminder.NotifyAt(event), d.notifyAt)
+				require.Equal(t, idempotencyKey(event, d.reminder), d.key)
+			}
+
+			outbox, _, err := outboxMessages(due, time.Time{}, false)
+			require.NoError(t, err)
+			for i, m := range outbox {
+				notification := messages.Notification{}
+				require.NoError(t, json.Unmarshal(m.Payload, &notification))
+				require.Equal(t, tt.channels[i], notification.Channel)
+				require.Equal(t, m.IdempotencyKey, notification.IdempotencyKey)
+				require.Equal(t, event.ID, notification.EventID)
+			}
+		})
+	}
+}
+
+func TestIdempotencyKeyIsStable(t *testing.T) {
+	event := &model.Event{ID: "1", StartTime: time.Date(2024, 10, 1, 13, 0, 0, 0, time.UTC)}
+	reminder := model.Reminder{Offset: 10 * time.Minute, Channel: "email"}
+
+	first, err := newOutboxMessage(event, reminder, false)
+	require.NoError(t, err)
+	second, err := newOutboxMessage(event, reminder, false)
+	require.NoError(t, err)
+
+	// overlapping scans must produce the same key, so the reminder is enqueued once
+	require.NotEqual(t, first.ID, second.ID)
+	require.Equal(t, first.IdempotencyKey, second.IdempotencyKey)
+
+	other, err := newOutboxMessage(event, model.Reminder{Offset: 10 * time.Minute, Channel: "webhook"}, false)
+	require.NoError(t, err)
+	require.NotEqual(t, first.IdempotencyKey, other.IdempotencyKey)
+}
@@ -0,0 +1,79 @@
+package scheduler
+
+import (
+	"container/heap"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+)
+
+// dueReminder is a single reminder of the event waiting for its notify time.
+type dueReminder struct {
+	event    *model.Event
+	reminder model.Reminder
+	notifyAt time.Time
+	key      string
+}
+
+// timerQueue is a min-heap of reminders ordered by notify time.
+// A reminder is kept once, a repeated push of the same idempotency key is ignored.
+type timerQueue struct {
+	items []*dueReminder
+	keys  map[string]struct{}
+}
+
+func newTimerQueue() *timerQueue {
+	return &timerQueue{keys: make(map[string]struct{})}
+}
+
+func (q *timerQueue) Len() int           { return len(q.items) }
+func (q *timerQueue) Less(i, j int) bool { return q.items[i].notifyAt.Before(q.items[j].notifyAt) }
+func (q *timerQueue) Swap(i, j int)      { q.items[i], q.items[j] = q.items[j], q.items[i] }
+
+func (q *timerQueue) Push(x any) {
+	q.items = append(q.items, x.(*dueReminder))
+}
+
+func (q *timerQueue) Pop() any {
+	n := len(q.items)
+	item := q.items[n-1]
+	q.items[n-1] = nil
+	q.items = q.items[:n-1]
+	return item
+}
+
+func (q *timerQueue) add(items ...*dueReminder) {
+	for _, item := range items {
+		if _, ok := q.keys[item.key]; ok {
+			continue
+		}
+		q.keys[item.key] = struct{}{}
+		heap.Push(q, item)
+	}
+}
+
+// popDue removes and returns the reminders to fire before until.
+func (q *timerQueue) popDue(until time.Time) []*dueReminder {
+	var due []*dueReminder
+	for q.Len() > 0 && q.items[0].notifyAt.Before(until) {
+		item := heap.Pop(q).(*dueReminder)
+		delete(q.keys, item.key)
+		due = append(due, item)
+	}
+	return due
+}
+
+// next returns the notify time of the earliest reminder.
+func (q *timerQueue) next() (time.Time, bool) {
+	if q.Len() == 0 {
+		return time.Time{}, false
+	}
+	return q.items[0].notifyAt, true
+}
+
+// reset replaces all reminders.
+func (q *timerQueue) reset(items []*dueReminder) {
+	q.items = q.items[:0]
+	q.keys = make(map[string]struct{}, len(items))
+	q.add(items...)
+}
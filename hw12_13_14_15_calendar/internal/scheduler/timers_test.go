@@ -0,0 +1,43 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimerQueue(t *testing.T) {
+	now := time.Date(2024, 10, 1, 13, 0, 0, 0, time.UTC)
+	q := newTimerQueue()
+	q.add(
+		&dueReminder{key: "c", notifyAt: now.Add(3 * time.Second)},
+		&dueReminder{key: "a", notifyAt: now.Add(time.Second)},
+		&dueReminder{key: "b", notifyAt: now.Add(2 * time.Second)},
+		// the same reminder loaded twice
+		&dueReminder{key: "a", notifyAt: now.Add(time.Second)},
+	)
+	require.Equal(t, 3, q.Len())
+
+	next, ok := q.next()
+	require.True(t, ok)
+	require.Equal(t, now.Add(time.Second), next)
+
+	// until is exclusive
+	due := q.popDue(now.Add(2 * time.Second))
+	require.Len(t, due, 1)
+	require.Equal(t, "a", due[0].key)
+
+	// a fired reminder can be added again, e.g. when enqueuing it has failed
+	q.add(due...)
+	due = q.popDue(now.Add(time.Hour))
+	require.Len(t, due, 3)
+	for i, key := range []string{"a", "b", "c"} {
+		require.Equal(t, key, due[i].key)
+	}
+	_, ok = q.next()
+	require.False(t, ok)
+
+	q.reset([]*dueReminder{{key: "d", notifyAt: now}})
+	require.Equal(t, 1, q.Len())
+}
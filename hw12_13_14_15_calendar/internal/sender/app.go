@@ -2,47 +2,61 @@ package sender
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"time"
 
-	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/amqp"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/bus"
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/health"
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/logger"
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/messages"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/server/admin"
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-type Logger interface {
-	Info(msg string)
-	Error(msg string)
-}
+var tracer = otel.Tracer("github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/sender")
 
 type App struct {
-	storage  storage.Storage
-	logger   Logger
-	config   *conf.SenderConfig
-	consumer *amqp.Consumer
+	storage storage.Storage
+	logger  *logger.Logger
+	config  *conf.SenderConfig
+	bus     bus.MessageBus
+	dedup   *dedup
+	router  *Router
 }
 
-func New(config *conf.SenderConfig) *App {
+func New(logg *logger.Logger, config *conf.SenderConfig) *App {
 	return &App{
+		logger: logg.Named("sender"),
 		config: config,
+		dedup:  newDedup(config.DedupCacheSize),
+		router: NewRouter(NewNotifiers(config), config.Channels.Default, config.Users, config.DedupCacheSize),
 	}
 }
 
 func (a *App) Run(ctx context.Context) error {
-	// create logger
-	logg, err := logger.New(a.config.Logger.Level)
+	// set up tracing
+	shutdownTracing, err := tracing.Setup(ctx, &a.config.Tracing)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to set up tracing: %w", err)
 	}
-	a.logger = logg
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			a.logger.Error(err.Error())
+		}
+	}()
 
 	// create storage
 	s, closeFunc, err := storage.NewFromConfig(&a.config.Storage)
 	if err != nil {
-		logg.Error(fmt.Sprintf("failed to create storage: %s", err))
+		a.logger.ErrorContext(ctx, "failed to create storage", "error", err)
 		return err
 	}
 	if closeFunc != nil {
@@ -50,34 +64,149 @@ func (a *App) Run(ctx context.Context) error {
 			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 			defer cancel()
 			if err := closeFunc(ctx); err != nil {
-				logg.Error(fmt.Sprintf("failed to close storage: %s", err))
+				a.logger.ErrorContext(ctx, "failed to close storage", "error", err)
 			}
 		}()
 	}
 	a.storage = s
 
-	// create consumer
-	consumer, err := amqp.NewConsumer(logg, &a.config.AMQP, a.handleNotification)
+	// create message bus
+	messageBus, err := bus.NewFromConfig(a.logger.Named("bus"), &a.config.Bus, &a.config.AMQP, &a.config.Storage)
 	if err != nil {
-		logg.Error(fmt.Sprintf("failed to create consumer: %s", err))
+		a.logger.ErrorContext(ctx, "failed to create message bus", "error", err)
 		return err
 	}
-	a.consumer = consumer
-	return consumer.Consume(ctx)
+	// serve metrics and health probes, readiness needs the storage and the message bus
+	checker := health.NewChecker(health.CheckTimeout)
+	checker.Add("storage", s.Ping)
+	checker.Add("bus", func(context.Context) error {
+		return messageBus.Ready()
+	})
+	if addr := a.config.Admin.BindAddr; addr != "" {
+		go func() {
+			if err := admin.NewServer(a.logger.Named("admin"), addr, checker).Run(ctx); err != nil {
+				a.logger.Error(err.Error())
+			}
+		}()
+	}
+
+	a.bus = messageBus
+	return messageBus.Consume(ctx, a.handleNotification)
 }
 
-func (a *App) handleNotification(msg []byte) error {
-	notification := &messages.Notification{}
-	if err := json.Unmarshal(msg, notification); err != nil {
-		return fmt.Errorf("failed to unmarshal message: %w", err)
+// Reload applies the log levels of a reloaded config, it is meant to be subscribed to conf.Reloader.
+// The other settings of the sender need a restart.
+func (a *App) Reload(config *conf.SenderConfig) {
+	// the named logger shares the levels with the root one
+	if err := a.logger.Configure(&config.Logger); err != nil {
+		a.logger.ErrorContext(context.Background(), "failed to configure logger", "error", err)
+		return
+	}
+	a.logger.InfoContext(context.Background(), "config reloaded")
+}
+
+func (a *App) handleNotification(msg bus.Message) error {
+	start := time.Now()
+	result, err := a.handle(msg)
+	handleDuration.WithLabelValues(result).Observe(time.Since(start).Seconds())
+	return err
+}
+
+// handle delivers the notification and returns the result for the metrics. The handling
+// continues the trace of the scheduler, its context is taken from the message headers
+// or, if the transport has dropped them, from the envelope.
+func (a *App) handle(msg bus.Message) (result string, err error) {
+	ctx := tracing.Extract(context.Background(), msg.Headers)
+	notification, envelope, err := messages.Decode(msg.Body, msg.ContentType)
+	if err == nil && !trace.SpanContextFromContext(ctx).IsValid() {
+		ctx = tracing.Extract(ctx, envelope.GetTraceContext())
+	}
+	ctx, span := tracer.Start(ctx, "sender.handle", trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.message.id", msg.ID),
+			attribute.Int("messaging.attempt", msg.Attempt),
+		))
+	defer func() {
+		span.SetAttributes(attribute.String("result", result))
+		tracing.End(span, err)
+	}()
+	if err != nil {
+		// an unknown version or type does not decode on another attempt, the message goes to the dead letters
+		return resultInvalid, fmt.Errorf("%w: %w", bus.ErrPermanent, err)
+	}
+	span.SetAttributes(
+		attribute.String("notification.id", notification.ID),
+		attribute.String("event.id", notification.EventID),
+		attribute.String("user.id", notification.UserID),
+		attribute.Int64("reminder.delay_ms", time.Since(notification.NotifyAt).Milliseconds()),
+	)
+	ctx = logger.WithFields(ctx,
+		"notification_id", notification.ID, "event_id", notification.EventID, logger.UserIDKey, notification.UserID)
+	// the outbox relay delivers at least once, so the same notification may arrive twice
+	if notification.IdempotencyKey != "" && a.dedup.contains(notification.IdempotencyKey) {
+		a.logger.InfoContext(ctx, "dropping duplicate notification", "idempotency_key", notification.IdempotencyKey)
+		return resultDuplicate, nil
+	}
+
+	err = a.deliver(ctx, notification)
+	retryable := Retryable(err)
+	a.saveStatus(ctx, notification, err, msg.FinalAttempt() || !retryable)
+	if err != nil && !retryable {
+		// the channels that are not configured cannot succeed on a retry
+		a.logger.ErrorContext(ctx, "failed to deliver notification", "error", err)
+		return resultFailed, nil
+	}
+	if err != nil {
+		return resultFailed, fmt.Errorf("failed to deliver notification %s: %w", notification.ID, err)
+	}
+	if notification.IdempotencyKey != "" {
+		a.dedup.add(notification.IdempotencyKey)
+	}
+	return resultDelivered, nil
+}
+
+func (a *App) deliver(ctx context.Context, notification *messages.Notification) (err error) {
+	a.logger.InfoContext(ctx, "received notification", "channel", notification.Channel, "late", notification.Late)
+	ctx, span := tracer.Start(ctx, "sender.deliver")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	return a.router.Notify(ctx, notification)
+}
+
+// saveStatus writes the delivery outcome back to the storage, so it can be queried through the API.
+func (a *App) saveStatus(
+	ctx context.Context, notification *messages.Notification, deliveryErr error, finalAttempt bool,
+) {
+	if notification.ID == "" {
+		return
+	}
+	status := &model.NotificationStatus{
+		NotificationID: notification.ID,
+		EventID:        notification.EventID,
+		State:          model.NotificationDelivered,
+		UpdatedAt:      time.Now(),
+	}
+	if deliveryErr != nil {
+		status.State = model.NotificationRetrying
+		if finalAttempt {
+			status.State = model.NotificationFailed
+		}
+		status.Error = deliveryErr.Error()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := a.storage.SaveNotificationStatus(ctx, status); err != nil {
+		a.logger.ErrorContext(ctx, "failed to save notification status", "state", status.State, "error", err)
 	}
-	a.logger.Info(fmt.Sprintf("received notification: %v", notification))
-	return nil
 }
 
 func (a *App) Stop() error {
-	if err := a.consumer.Stop(); err != nil {
-		return fmt.Errorf("failed to close consumer: %w", err)
+	if err := a.bus.Close(); err != nil {
+		return fmt.Errorf("failed to close message bus: %w", err)
 	}
 	return nil
 }
@@ -0,0 +1,64 @@
+package sender
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/amqp"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/bus"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/logger"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/messages"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleRejectsUndecodableMessages(t *testing.T) {
+	logg, err := logger.New("ERROR")
+	require.NoError(t, err)
+	a := &App{logger: logg}
+	policy := amqp.RetryPolicy{MaxAttempts: 3, InitialDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+	b := bus.NewMemoryBus(logg, t.Name(), policy)
+	t.Cleanup(func() {
+		require.NoError(t, b.Close())
+	})
+
+	ctx := context.Background()
+	for _, msg := range []bus.Message{
+		{ID: "next-major", ContentType: messages.ContentTypeJSON,
+			Body: []byte(`{"type":"notification.reminder","schemaVersion":"2.0"}`)},
+		{ID: "other-type", ContentType: messages.ContentTypeJSON,
+			Body: []byte(`{"type":"notification.digest","schemaVersion":"1.0"}`)},
+		{ID: "text", ContentType: "text/plain", Body: []byte("remind me")},
+	} {
+		require.NoError(t, b.Publish(ctx, msg))
+	}
+
+	attempts := make(chan bus.Message, 10)
+	go func() {
+		_ = b.Consume(ctx, func(msg bus.Message) error {
+			err := a.handleNotification(msg)
+			assert.ErrorIs(t, err, bus.ErrPermanent)
+			attempts <- msg
+			return err
+		})
+	}()
+
+	got := map[string][]int{}
+	for i := 0; i < 3; i++ {
+		select {
+		case msg := <-attempts:
+			got[msg.ID] = append(got[msg.ID], msg.Attempt)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out, got %v", got)
+		}
+	}
+	require.Equal(t, map[string][]int{"next-major": {1}, "other-type": {1}, "text": {1}}, got)
+
+	// the messages are dead-lettered without retries
+	select {
+	case msg := <-attempts:
+		t.Fatalf("unexpected attempt %d of %q", msg.Attempt, msg.ID)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
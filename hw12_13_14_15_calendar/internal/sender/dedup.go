@@ -0,0 +1,47 @@
+package sender
+
+import "sync"
+
+const defaultDedupSize = 10000
+
+// dedup remembers the last size idempotency keys to drop repeated deliveries.
+type dedup struct {
+	mu    sync.Mutex
+	keys  map[string]struct{}
+	order []string
+	next  int
+}
+
+func newDedup(size int) *dedup {
+	if size <= 0 {
+		size = defaultDedupSize
+	}
+	return &dedup{
+		keys:  make(map[string]struct{}, size),
+		order: make([]string, size),
+	}
+}
+
+func (d *dedup) contains(key string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	_, ok := d.keys[key]
+	return ok
+}
+
+// add remembers the key evicting the oldest one when the buffer is full.
+func (d *dedup) add(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.keys[key]; ok {
+		return
+	}
+	if evicted := d.order[d.next]; evicted != "" {
+		delete(d.keys, evicted)
+	}
+	d.order[d.next] = key
+	d.next = (d.next + 1) % len(d.order)
+	d.keys[key] = struct{}{}
+}
@@ -0,0 +1,24 @@
+package sender
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDedup(t *testing.T) {
+	d := newDedup(2)
+
+	require.False(t, d.contains("a"))
+	d.add("a")
+	require.True(t, d.contains("a"))
+	d.add("b")
+	d.add("b")
+	require.True(t, d.contains("a"))
+
+	// "a" is evicted by "c"
+	d.add("c")
+	require.False(t, d.contains("a"))
+	require.True(t, d.contains("b"))
+	require.True(t, d.contains("c"))
+}
@@ -0,0 +1,35 @@
+package sender
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/messages"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileNotifier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications.jsonl")
+	n := NewFileNotifier(path)
+
+	require.NoError(t, n.Notify(context.Background(), &messages.Notification{ID: "1", Title: "first"}))
+	require.NoError(t, n.Notify(context.Background(), &messages.Notification{ID: "2", Title: "second"}))
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var ids []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		notification := messages.Notification{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &notification))
+		ids = append(ids, notification.ID)
+	}
+	require.NoError(t, scanner.Err())
+	require.Equal(t, []string{"1", "2"}, ids)
+}
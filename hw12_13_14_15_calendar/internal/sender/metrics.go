@@ -0,0 +1,22 @@
+package sender
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Results of handled notifications.
+const (
+	resultDelivered = "delivered"
+	resultDuplicate = "duplicate"
+	resultFailed    = "failed"
+	resultInvalid   = "invalid"
+)
+
+var handleDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "calendar",
+	Subsystem: "sender",
+	Name:      "handle_duration_seconds",
+	Help:      "Latency of handling notifications by result: delivered, duplicate, failed or invalid.",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"result"})
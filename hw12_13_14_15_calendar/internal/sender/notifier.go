@@ -0,0 +1,141 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/messages"
+)
+
+const (
+	ChannelStdout  = "stdout"
+	ChannelFile    = "file"
+	ChannelWebhook = "webhook"
+	ChannelEmail   = "email"
+)
+
+var ErrUnknownChannel = errors.New("unknown notification channel")
+
+// Notifier delivers a notification through a single channel.
+type Notifier interface {
+	Notify(ctx context.Context, notification *messages.Notification) error
+}
+
+// NewNotifiers creates notifiers of the configured channels keyed by the channel name.
+func NewNotifiers(config *conf.SenderConfig) map[string]Notifier {
+	notifiers := map[string]Notifier{
+		ChannelStdout: NewStdoutNotifier(),
+	}
+	if config.Channels.File.Path != "" {
+		notifiers[ChannelFile] = NewFileNotifier(config.Channels.File.Path)
+	}
+	if config.Channels.Webhook.URL != "" {
+		timeout := time.Duration(config.Channels.Webhook.Timeout) * time.Second
+		notifiers[ChannelWebhook] = NewWebhookNotifier(config.Channels.Webhook.URL, config.Channels.Webhook.Secret, timeout)
+	}
+	if config.Channels.SMTP.Addr != "" {
+		emails := make(map[string]string, len(config.Users))
+		for userID, prefs := range config.Users {
+			if prefs.Email != "" {
+				emails[userID] = prefs.Email
+			}
+		}
+		notifiers[ChannelEmail] = NewSMTPNotifier(&config.Channels.SMTP, emails)
+	}
+	return notifiers
+}
+
+// Router sends notifications to the channels chosen for them.
+type Router struct {
+	notifiers map[string]Notifier
+	defaults  []string
+	users     map[string]conf.UserPreferences
+	// delivered remembers the channels a notification has been delivered to,
+	// so a redelivered notification is sent only to the channels that failed
+	delivered *dedup
+}
+
+// NewRouter creates a router that remembers the deliveries of the last dedupSize notification channels.
+func NewRouter(
+	notifiers map[string]Notifier, defaults []string, users map[string]conf.UserPreferences, dedupSize int,
+) *Router {
+	if len(defaults) == 0 {
+		defaults = []string{ChannelStdout}
+	}
+	return &Router{
+		notifiers: notifiers,
+		defaults:  defaults,
+		users:     users,
+		delivered: newDedup(dedupSize),
+	}
+}
+
+// Channels returns the channels of the notification: the one set in the reminder,
+// otherwise the channels preferred by the user, otherwise the default ones.
+func (r *Router) Channels(notification *messages.Notification) []string {
+	if notification.Channel != "" {
+		return []string{notification.Channel}
+	}
+	if prefs, ok := r.users[notification.UserID]; ok && len(prefs.Channels) > 0 {
+		return prefs.Channels
+	}
+	return r.defaults
+}
+
+// Notify sends the notification to its channels and returns the joined errors of the failed ones.
+// The channels that have already delivered the notification are skipped.
+func (r *Router) Notify(ctx context.Context, notification *messages.Notification) error {
+	var errs []error
+	for _, channel := range r.Channels(notification) {
+		notifier, ok := r.notifiers[channel]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrUnknownChannel, channel))
+			continue
+		}
+		key := deliveryKey(notification, channel)
+		if key != "" && r.delivered.contains(key) {
+			continue
+		}
+		if err := notifier.Notify(ctx, notification); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", channel, err))
+			continue
+		}
+		if key != "" {
+			r.delivered.add(key)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Retryable reports whether a retry may deliver the notification that Notify has failed with err.
+// It is false when only unknown channels have failed, a retry cannot fix the config.
+func Retryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	joined, ok := err.(interface{ Unwrap() []error }) //nolint:errorlint // inspects the errors joined by Notify
+	if !ok {
+		return !errors.Is(err, ErrUnknownChannel)
+	}
+	for _, e := range joined.Unwrap() {
+		if !errors.Is(e, ErrUnknownChannel) {
+			return true
+		}
+	}
+	return false
+}
+
+// deliveryKey identifies the delivery of the notification to the channel.
+func deliveryKey(notification *messages.Notification, channel string) string {
+	key := notification.IdempotencyKey
+	if key == "" {
+		key = notification.ID
+	}
+	if key == "" {
+		return ""
+	}
+	return key + "/" + channel
+}
@@ -0,0 +1,104 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/messages"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingNotifier struct {
+	received []*messages.Notification
+	err      error
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, notification *messages.Notification) error {
+	n.received = append(n.received, notification)
+	return n.err
+}
+
+func TestRouterChannels(t *testing.T) {
+	router := NewRouter(nil, []string{ChannelStdout}, map[string]conf.UserPreferences{
+		"user-1": {Channels: []string{ChannelEmail, ChannelWebhook}},
+	}, 0)
+
+	testData := []struct {
+		name         string
+		notification *messages.Notification
+		channels     []string
+	}{
+		{
+			name:         "reminder channel",
+			notification: &messages.Notification{UserID: "user-1", Channel: ChannelFile},
+			channels:     []string{ChannelFile},
+		},
+		{
+			name:         "user preferences",
+			notification: &messages.Notification{UserID: "user-1"},
+			channels:     []string{ChannelEmail, ChannelWebhook},
+		},
+		{
+			name:         "default channels",
+			notification: &messages.Notification{UserID: "user-2"},
+			channels:     []string{ChannelStdout},
+		},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.channels, router.Channels(tt.notification))
+		})
+	}
+}
+
+func TestRouterNotify(t *testing.T) {
+	email := &recordingNotifier{}
+	webhook := &recordingNotifier{err: errors.New("connection refused")}
+	router := NewRouter(map[string]Notifier{
+		ChannelEmail:   email,
+		ChannelWebhook: webhook,
+	}, []string{ChannelEmail, ChannelWebhook, "pager"}, nil, 0)
+	notification := &messages.Notification{ID: "1", IdempotencyKey: "event-1:1"}
+
+	err := router.Notify(context.Background(), notification)
+	require.ErrorIs(t, err, ErrUnknownChannel)
+	require.ErrorContains(t, err, "webhook: connection refused")
+	require.True(t, Retryable(err))
+	require.Len(t, email.received, 1)
+	require.Len(t, webhook.received, 1)
+
+	// the redelivered notification is sent only to the failed channel
+	webhook.err = nil
+	err = router.Notify(context.Background(), notification)
+	require.ErrorIs(t, err, ErrUnknownChannel)
+	require.False(t, Retryable(err))
+	require.Len(t, email.received, 1)
+	require.Len(t, webhook.received, 2)
+
+	err = router.Notify(context.Background(), notification)
+	require.False(t, Retryable(err))
+	require.Len(t, email.received, 1)
+	require.Len(t, webhook.received, 2)
+}
+
+func TestStdoutNotifier(t *testing.T) {
+	out := &bytes.Buffer{}
+	n := &StdoutNotifier{out: out}
+
+	err := n.Notify(context.Background(), &messages.Notification{
+		UserID:    "user-1",
+		Title:     "Kickoff meeting",
+		StartTime: time.Date(2024, 10, 1, 13, 0, 0, 0, time.UTC),
+		Message:   "prepare slides",
+	})
+	require.NoError(t, err)
+	require.Equal(t,
+		"[notification] user=user-1 event=\"Kickoff meeting\" starts at 2024-10-01T13:00:00Z: prepare slides\n",
+		out.String(),
+	)
+}
@@ -0,0 +1,109 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/messages"
+)
+
+var ErrNoEmail = errors.New("no email address for user")
+
+// headerReplacer prevents injection of extra headers through the event title.
+var headerReplacer = strings.NewReplacer("\r", " ", "\n", " ")
+
+// SMTPNotifier sends notifications by email. Addresses are taken from the user preferences.
+type SMTPNotifier struct {
+	config *conf.SMTPConf
+	emails map[string]string
+}
+
+func NewSMTPNotifier(config *conf.SMTPConf, emails map[string]string) *SMTPNotifier {
+	return &SMTPNotifier{
+		config: config,
+		emails: emails,
+	}
+}
+
+func (n *SMTPNotifier) Notify(ctx context.Context, notification *messages.Notification) error {
+	to, ok := n.emails[notification.UserID]
+	if !ok {
+		return fmt.Errorf("%w %s", ErrNoEmail, notification.UserID)
+	}
+	host, _, err := net.SplitHostPort(n.config.Addr)
+	if err != nil {
+		return fmt.Errorf("invalid smtp address: %w", err)
+	}
+
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", n.config.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start smtp session: %w", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}); err != nil {
+			return fmt.Errorf("failed to start tls: %w", err)
+		}
+	}
+	if n.config.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", n.config.Username, n.config.Password, host)); err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+	if err := c.Mail(n.config.From); err != nil {
+		return fmt.Errorf("MAIL: %w", err)
+	}
+	if err := c.Rcpt(to); err != nil {
+		return fmt.Errorf("RCPT: %w", err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("DATA: %w", err)
+	}
+	if _, err := w.Write(n.message(to, notification)); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return c.Quit()
+}
+
+func (n *SMTPNotifier) message(to string, notification *messages.Notification) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", n.config.From)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	subject := "Reminder"
+	if notification.Late {
+		subject = "Late reminder"
+	}
+	fmt.Fprintf(&b, "Subject: %s: %s\r\n", subject, headerReplacer.Replace(notification.Title))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&b, "%s starts at %s.\r\n", notification.Title, notification.StartTime.Format(time.RFC1123))
+	if notification.Message != "" {
+		fmt.Fprintf(&b, "\r\n%s\r\n", notification.Message)
+	}
+	return b.Bytes()
+}
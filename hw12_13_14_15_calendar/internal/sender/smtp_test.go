@@ -0,0 +1,109 @@
+package sender
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/messages"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpStub is a minimal in-process SMTP server accepting a single message.
+type smtpStub struct {
+	lsn  net.Listener
+	rcpt chan string
+	data chan string
+}
+
+func newSMTPStub(t *testing.T) *smtpStub {
+	t.Helper()
+	lsn, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &smtpStub{lsn: lsn, rcpt: make(chan string, 1), data: make(chan string, 1)}
+	t.Cleanup(func() { lsn.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *smtpStub) serve() {
+	conn, err := s.lsn.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { fmt.Fprintf(conn, "%s\r\n", line) }
+	reply("220 localhost ESMTP stub")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "MAIL FROM"):
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO"):
+			s.rcpt <- strings.TrimSpace(line[len("RCPT TO:"):])
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 end data with <CR><LF>.<CR><LF>")
+			var b strings.Builder
+			for {
+				dataLine, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				b.WriteString(dataLine)
+			}
+			s.data <- b.String()
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	stub := newSMTPStub(t)
+	n := NewSMTPNotifier(
+		&conf.SMTPConf{Addr: stub.lsn.Addr().String(), From: "calendar@example.com"},
+		map[string]string{"user-1": "alice@example.com"},
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := n.Notify(ctx, &messages.Notification{
+		UserID:    "user-1",
+		Title:     "Kickoff meeting",
+		StartTime: time.Date(2024, 10, 1, 13, 0, 0, 0, time.UTC),
+		Message:   "prepare slides",
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, "<alice@example.com>", <-stub.rcpt)
+	data := <-stub.data
+	require.Contains(t, data, "Subject: Reminder: Kickoff meeting\r\n")
+	require.Contains(t, data, "prepare slides")
+}
+
+func TestSMTPNotifierUnknownUser(t *testing.T) {
+	n := NewSMTPNotifier(&conf.SMTPConf{Addr: "127.0.0.1:25"}, nil)
+	err := n.Notify(context.Background(), &messages.Notification{UserID: "user-2"})
+	require.ErrorIs(t, err, ErrNoEmail)
+}
@@ -0,0 +1,42 @@
+package sender
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/messages"
+)
+
+// StdoutNotifier prints notifications in a human readable form.
+type StdoutNotifier struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func NewStdoutNotifier() *StdoutNotifier {
+	return &StdoutNotifier{out: os.Stdout}
+}
+
+func (n *StdoutNotifier) Notify(_ context.Context, notification *messages.Notification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	tag := "[notification]"
+	if notification.Late {
+		tag = "[late notification]"
+	}
+	_, err := fmt.Fprintf(n.out, "%s user=%s event=%q starts at %s%s\n", tag,
+		notification.UserID, notification.Title, notification.StartTime.Format(time.RFC3339), suffix(notification.Message))
+	return err
+}
+
+func suffix(message string) string {
+	if message == "" {
+		return ""
+	}
+	return ": " + message
+}
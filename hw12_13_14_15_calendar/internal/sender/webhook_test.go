@@ -0,0 +1,50 @@
+package sender
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/messages"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/signature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	const secret = "s3cr3t"
+	received := make(chan messages.Notification, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		timestamp := r.Header.Get(signature.TimestampHeader)
+		assert.True(t, signature.Verify(secret, timestamp, body, r.Header.Get(signature.Header)))
+
+		notification := messages.Notification{}
+		assert.NoError(t, json.Unmarshal(body, &notification))
+		received <- notification
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	n := NewWebhookNotifier(server.URL, secret, time.Second)
+	err := n.Notify(context.Background(), &messages.Notification{ID: "1", Title: "Kickoff meeting"})
+	require.NoError(t, err)
+	require.Equal(t, "1", (<-received).ID)
+}
+
+func TestWebhookNotifierFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	n := NewWebhookNotifier(server.URL, "", time.Second)
+	err := n.Notify(context.Background(), &messages.Notification{ID: "1"})
+	require.ErrorContains(t, err, "502")
+}
@@ -0,0 +1,65 @@
+// Package admin serves the operational endpoints of the services without an HTTP API.
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/health"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const shutdownTimeout = 5 * time.Second
+
+type Logger interface {
+	InfoContext(ctx context.Context, msg string, args ...any)
+}
+
+// Server serves /metrics in the Prometheus text format and the health probes /healthz and /readyz.
+type Server struct {
+	logger     Logger
+	httpServer *http.Server
+}
+
+// NewServer returns the server, the readiness probe runs the checks of the checker.
+func NewServer(logger Logger, bindAddr string, checker *health.Checker) *Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.Handle("/healthz", health.LiveHandler())
+	mux.Handle("/readyz", checker.ReadyHandler())
+	return &Server{
+		logger: logger,
+		httpServer: &http.Server{
+			Addr:              bindAddr,
+			ReadHeaderTimeout: 5 * time.Second,
+			Handler:           mux,
+		},
+	}
+}
+
+// Run serves until ctx is done, then shuts the server down.
+func (s *Server) Run(ctx context.Context) error {
+	errs := make(chan error, 1)
+	go func() {
+		errs <- s.httpServer.ListenAndServe()
+	}()
+	s.logger.InfoContext(ctx, "admin server is running", "addr", s.httpServer.Addr)
+
+	select {
+	case err := <-errs:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return fmt.Errorf("failed to start admin server: %w", err)
+	case <-ctx.Done():
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to stop admin server: %w", err)
+	}
+	return nil
+}
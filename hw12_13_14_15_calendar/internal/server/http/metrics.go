@@ -0,0 +1,63 @@
+package internalhttp
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc/metadata"
+)
+
+var (
+	requestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "calendar",
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "calendar",
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of HTTP requests by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+// unmatchedRoute is the route of the requests no handler has matched, e.g. the 404s,
+// so that the paths made up by clients are not label values of their own.
+const unmatchedRoute = "unmatched"
+
+type routeKey struct{}
+
+// withRoute returns the context holding the route of the request, the handler matching the request sets it.
+func withRoute(ctx context.Context) (context.Context, *string) {
+	route := new(string)
+	return context.WithValue(ctx, routeKey{}, route), route
+}
+
+// SetRoute records the pattern the request has been matched to as its route.
+func SetRoute(ctx context.Context, pattern string) {
+	if route, ok := ctx.Value(routeKey{}).(*string); ok {
+		*route = pattern
+	}
+}
+
+// GatewayRoute records the path pattern the gateway has matched the request to,
+// it is passed to the gateway mux with runtime.WithMetadata.
+func GatewayRoute(ctx context.Context, _ *http.Request) metadata.MD {
+	if pattern, ok := runtime.HTTPPathPattern(ctx); ok {
+		SetRoute(ctx, pattern)
+	}
+	return nil
+}
+
+// routed records the pattern of a handler registered on the server mux as the route.
+func routed(pattern string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		SetRoute(r.Context(), pattern)
+		next(w, r)
+	}
+}
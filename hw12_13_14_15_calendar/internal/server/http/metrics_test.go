@@ -0,0 +1,42 @@
+package internalhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/gen/events/pb"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type discardLogger struct{}
+
+func (discardLogger) InfoContext(context.Context, string, ...any) {}
+
+func TestRoute(t *testing.T) {
+	gwmux := runtime.NewServeMux(runtime.WithMetadata(GatewayRoute))
+	require.NoError(t, pb.RegisterEventServiceHandlerServer(context.Background(), gwmux, pb.UnimplementedEventServiceServer{}))
+	server := NewServer(discardLogger{}, gwmux.ServeHTTP, nil, nil, "")
+	handler := &LoggingMiddleware{logger: discardLogger{}, next: server.mux}
+
+	testData := []struct {
+		path, route string
+		status      int
+	}{
+		{"/events/2f1c0b6e-62f6-4c2b-9a7e-3d1b2a4c5d6e", "/events/{id}", http.StatusNotImplemented},
+		{"/events/e1/notifications", "/events/{eventId}/notifications", http.StatusNotImplemented},
+		{"/hello", "/hello", http.StatusOK},
+		{"/no-such-path/42", unmatchedRoute, http.StatusNotFound},
+		{"/events/e1/no-such-path", unmatchedRoute, http.StatusNotFound},
+	}
+	for _, tt := range testData {
+		counter := requestsTotal.WithLabelValues(http.MethodGet, tt.route, strconv.Itoa(tt.status))
+		before := testutil.ToFloat64(counter)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+		require.Equal(t, before+1, testutil.ToFloat64(counter), tt.path)
+	}
+}
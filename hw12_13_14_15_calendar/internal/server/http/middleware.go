@@ -1,9 +1,13 @@
 package internalhttp
 
 import (
-	"fmt"
 	"net/http"
+	"strconv"
 	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/logger"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type LoggingMiddleware struct {
@@ -21,14 +25,40 @@ func (w *responseWriter) WriteHeader(code int) {
 	w.ResponseWriter.WriteHeader(code)
 }
 
+// ServeHTTP assigns the request an ID, taken from the X-Request-ID header or generated,
+// returns it in the response and adds it to the fields logged while handling the request.
+// The outcome of the request is logged and recorded in the request metrics, unless the request
+// is a scrape or a probe, the route it is recorded with is the pattern of the matched handler.
 func (l *LoggingMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
+	requestID := r.Header.Get(logger.RequestIDHeader)
+	if requestID == "" {
+		requestID = uuid.NewString()
+	}
+	w.Header().Set(logger.RequestIDHeader, requestID)
+	ctx := logger.WithFields(r.Context(), logger.RequestIDKey, requestID)
+	ctx, route := withRoute(ctx)
+
 	rw := &responseWriter{w, http.StatusOK}
-	l.next.ServeHTTP(rw, r)
-	latency := time.Since(start)
-	msg := fmt.Sprintf(
-		"[http] %s %s %d %s (%s, User agent: %s, IP: %s)",
-		r.Method, r.URL.Path, rw.status, latency, r.Proto, r.UserAgent(), r.RemoteAddr,
+	l.next.ServeHTTP(rw, r.WithContext(ctx))
+	if operational(r.URL.Path) {
+		return
+	}
+	duration := time.Since(start)
+	if *route == "" {
+		*route = unmatchedRoute
+	}
+	// the route is known only once the request is matched, after the span has been started
+	trace.SpanFromContext(ctx).SetName(r.Method + " " + *route)
+	requestsTotal.WithLabelValues(r.Method, *route, strconv.Itoa(rw.status)).Inc()
+	requestDuration.WithLabelValues(r.Method, *route).Observe(duration.Seconds())
+	l.logger.InfoContext(ctx, "http request",
+		"method", r.Method,
+		"path", r.URL.Path,
+		"status", rw.status,
+		"duration", duration,
+		"proto", r.Proto,
+		"user_agent", r.UserAgent(),
+		"remote_addr", r.RemoteAddr,
 	)
-	l.logger.Info(msg)
 }
@@ -3,10 +3,13 @@ package internalhttp
 import (
 	"context"
 	"errors"
-	"fmt"
 	"net"
 	"net/http"
 	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/health"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 type Server struct {
@@ -18,17 +21,24 @@ type Server struct {
 }
 
 type Logger interface {
-	Info(msg string)
-	Error(msg string)
+	InfoContext(ctx context.Context, msg string, args ...any)
 }
 
 type Application interface{}
 
-func NewServer(logger Logger, gRPCHandler http.HandlerFunc, app Application, bindAddr string) *Server {
+// NewServer serves the gateway to the gRPC services, the metrics and the health probes,
+// the readiness probe runs the checks of the checker. The gateway mux records the routes of
+// its requests with GatewayRoute.
+func NewServer(
+	logger Logger, gRPCHandler http.HandlerFunc, app Application, checker *health.Checker, bindAddr string,
+) *Server {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/", gRPCHandler)
-	mux.HandleFunc("/hello", HelloHandler)
+	mux.HandleFunc("/hello", routed("/hello", HelloHandler))
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.Handle("/healthz", health.LiveHandler())
+	mux.Handle("/readyz", checker.ReadyHandler())
 	return &Server{
 		logger:   logger,
 		app:      app,
@@ -41,12 +51,12 @@ func (s *Server) Start(ctx context.Context) error {
 	s.httpServer = &http.Server{
 		Addr:              s.bindAddr,
 		ReadHeaderTimeout: 5 * time.Second,
-		Handler:           &LoggingMiddleware{logger: s.logger, next: s.mux},
+		Handler:           tracingHandler(&LoggingMiddleware{logger: s.logger, next: s.mux}),
 		BaseContext: func(_ net.Listener) context.Context {
 			return ctx
 		},
 	}
-	s.logger.Info(fmt.Sprintf("http server is running on %s", s.bindAddr))
+	s.logger.InfoContext(ctx, "http server is running", "addr", s.bindAddr)
 	err := s.httpServer.ListenAndServe()
 	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return err
@@ -54,6 +64,26 @@ func (s *Server) Start(ctx context.Context) error {
 	return nil
 }
 
+// operational reports whether the path is scraped or probed every few seconds,
+// such requests are neither logged, traced nor counted in the request metrics.
+func operational(path string) bool {
+	return path == "/metrics" || path == "/healthz" || path == "/readyz"
+}
+
+// tracingHandler starts a span for every request but the operational ones,
+// or continues the trace of the caller passed in the traceparent header.
+// The span is renamed after the route by LoggingMiddleware.
+func tracingHandler(next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "http",
+		otelhttp.WithFilter(func(r *http.Request) bool {
+			return !operational(r.URL.Path)
+		}),
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method
+		}),
+	)
+}
+
 func (s *Server) Stop(ctx context.Context) error {
 	err := s.httpServer.Shutdown(ctx)
 	return err
@@ -0,0 +1,27 @@
+package signature
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+const (
+	Header          = "X-Calendar-Signature"
+	TimestampHeader = "X-Calendar-Timestamp"
+)
+
+// Sign returns the HMAC-SHA256 signature of "<timestamp>.<body>" in the form "sha256=<hex>".
+// Receivers recompute it with the shared secret to verify the payload and its timestamp.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether the signature matches the body sent at the timestamp.
+func Verify(secret, timestamp string, body []byte, sig string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(sig))
+}
@@ -0,0 +1,256 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var operationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "calendar",
+	Subsystem: "storage",
+	Name:      "operation_duration_seconds",
+	Help:      "Latency of storage operations by operation and result (ok or error).",
+	Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+}, []string{"operation", "result"})
+
+// instrumented records the timings of the operations of the storage it wraps and traces them.
+type instrumented struct {
+	next Storage
+}
+
+var tracer = otel.Tracer("github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage")
+
+func observe(ctx context.Context, operation string, fn func() error) error {
+	_, err := observeResult(ctx, operation, func() (struct{}, error) {
+		return struct{}{}, fn()
+	})
+	return err
+}
+
+func observeResult[T any](ctx context.Context, operation string, fn func() (T, error)) (T, error) {
+	_, span := tracer.Start(ctx, "storage."+operation, trace.WithSpanKind(trace.SpanKindClient))
+	start := time.Now()
+	res, err := fn()
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	operationDuration.WithLabelValues(operation, result).Observe(time.Since(start).Seconds())
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s instrumented) CreateEvent(ctx context.Context, event *model.Event) error {
+	return observe(ctx, "create_event", func() error {
+		return s.next.CreateEvent(ctx, event)
+	})
+}
+
+func (s instrumented) UpdateEvent(ctx context.Context, event *model.Event) error {
+	return observe(ctx, "update_event", func() error {
+		return s.next.UpdateEvent(ctx, event)
+	})
+}
+
+func (s instrumented) RemoveEvent(ctx context.Context, eventID string) error {
+	return observe(ctx, "remove_event", func() error {
+		return s.next.RemoveEvent(ctx, eventID)
+	})
+}
+
+func (s instrumented) GetEvent(ctx context.Context, eventID string) (*model.Event, error) {
+	return observeResult(ctx, "get_event", func() (*model.Event, error) {
+		return s.next.GetEvent(ctx, eventID)
+	})
+}
+
+func (s instrumented) FilterEventsByDay(ctx context.Context, date time.Time) ([]*model.Event, error) {
+	return observeResult(ctx, "filter_events_by_day", func() ([]*model.Event, error) {
+		return s.next.FilterEventsByDay(ctx, date)
+	})
+}
+
+func (s instrumented) FilterEventsByWeek(ctx context.Context, weekStart time.Time) ([]*model.Event, error) {
+	return observeResult(ctx, "filter_events_by_week", func() ([]*model.Event, error) {
+		return s.next.FilterEventsByWeek(ctx, weekStart)
+	})
+}
+
+func (s instrumented) FilterEventsByMonth(ctx context.Context, monthStart time.Time) ([]*model.Event, error) {
+	return observeResult(ctx, "filter_events_by_month", func() ([]*model.Event, error) {
+		return s.next.FilterEventsByMonth(ctx, monthStart)
+	})
+}
+
+func (s instrumented) DeleteEventsOlderThan(ctx context.Context, threshold time.Time) (int64, error) {
+	return observeResult(ctx, "delete_events_older_than", func() (int64, error) {
+		return s.next.DeleteEventsOlderThan(ctx, threshold)
+	})
+}
+
+func (s instrumented) FindEventsToNotify(ctx context.Context, from, to time.Time) ([]*model.Event, error) {
+	return observeResult(ctx, "find_events_to_notify", func() ([]*model.Event, error) {
+		return s.next.FindEventsToNotify(ctx, from, to)
+	})
+}
+
+// ListenEventChanges is not timed, it runs until ctx is done.
+func (s instrumented) ListenEventChanges(ctx context.Context, handler func(eventID string)) error {
+	return s.next.ListenEventChanges(ctx, handler)
+}
+
+// Ping is neither timed nor traced, the readiness probes would drown the other operations.
+func (s instrumented) Ping(ctx context.Context) error {
+	return s.next.Ping(ctx)
+}
+
+func (s instrumented) ArchiveEvents(ctx context.Context, retention model.Retention) (int64, error) {
+	return observeResult(ctx, "archive_events", func() (int64, error) {
+		return s.next.ArchiveEvents(ctx, retention)
+	})
+}
+
+func (s instrumented) SearchArchivedEvents(
+	ctx context.Context, filter model.ArchiveFilter,
+) ([]*model.ArchivedEvent, error) {
+	return observeResult(ctx, "search_archived_events", func() ([]*model.ArchivedEvent, error) {
+		return s.next.SearchArchivedEvents(ctx, filter)
+	})
+}
+
+func (s instrumented) RestoreArchivedEvent(ctx context.Context, id string) (*model.Event, error) {
+	return observeResult(ctx, "restore_archived_event", func() (*model.Event, error) {
+		return s.next.RestoreArchivedEvent(ctx, id)
+	})
+}
+
+func (s instrumented) AddOutboxMessages(ctx context.Context, messages []*model.OutboxMessage) (int64, error) {
+	return observeResult(ctx, "add_outbox_messages", func() (int64, error) {
+		return s.next.AddOutboxMessages(ctx, messages)
+	})
+}
+
+func (s instrumented) FetchPendingOutboxMessages(ctx context.Context, limit int) ([]*model.OutboxMessage, error) {
+	return observeResult(ctx, "fetch_pending_outbox_messages", func() ([]*model.OutboxMessage, error) {
+		return s.next.FetchPendingOutboxMessages(ctx, limit)
+	})
+}
+
+func (s instrumented) MarkOutboxMessageSent(ctx context.Context, id string, sentAt time.Time) error {
+	return observe(ctx, "mark_outbox_message_sent", func() error {
+		return s.next.MarkOutboxMessageSent(ctx, id, sentAt)
+	})
+}
+
+func (s instrumented) MarkOutboxMessageFailed(ctx context.Context, id string, reason string) error {
+	return observe(ctx, "mark_outbox_message_failed", func() error {
+		return s.next.MarkOutboxMessageFailed(ctx, id, reason)
+	})
+}
+
+func (s instrumented) DeleteSentOutboxMessagesOlderThan(ctx context.Context, threshold time.Time) (int64, error) {
+	return observeResult(ctx, "delete_sent_outbox_messages_older_than", func() (int64, error) {
+		return s.next.DeleteSentOutboxMessagesOlderThan(ctx, threshold)
+	})
+}
+
+func (s instrumented) SaveNotificationStatus(ctx context.Context, status *model.NotificationStatus) error {
+	return observe(ctx, "save_notification_status", func() error {
+		return s.next.SaveNotificationStatus(ctx, status)
+	})
+}
+
+func (s instrumented) GetNotificationStatus(
+	ctx context.Context, notificationID string,
+) (*model.NotificationStatus, error) {
+	return observeResult(ctx, "get_notification_status", func() (*model.NotificationStatus, error) {
+		return s.next.GetNotificationStatus(ctx, notificationID)
+	})
+}
+
+func (s instrumented) ListNotificationStatuses(
+	ctx context.Context, eventID string,
+) ([]*model.NotificationStatus, error) {
+	return observeResult(ctx, "list_notification_statuses", func() ([]*model.NotificationStatus, error) {
+		return s.next.ListNotificationStatuses(ctx, eventID)
+	})
+}
+
+func (s instrumented) GetSchedulerWatermark(ctx context.Context, name string) (time.Time, error) {
+	return observeResult(ctx, "get_scheduler_watermark", func() (time.Time, error) {
+		return s.next.GetSchedulerWatermark(ctx, name)
+	})
+}
+
+func (s instrumented) SaveSchedulerWatermark(ctx context.Context, name string, watermark time.Time) error {
+	return observe(ctx, "save_scheduler_watermark", func() error {
+		return s.next.SaveSchedulerWatermark(ctx, name, watermark)
+	})
+}
+
+func (s instrumented) CreateWebhookSubscription(ctx context.Context, subscription *model.WebhookSubscription) error {
+	return observe(ctx, "create_webhook_subscription", func() error {
+		return s.next.CreateWebhookSubscription(ctx, subscription)
+	})
+}
+
+func (s instrumented) GetWebhookSubscription(ctx context.Context, id string) (*model.WebhookSubscription, error) {
+	return observeResult(ctx, "get_webhook_subscription", func() (*model.WebhookSubscription, error) {
+		return s.next.GetWebhookSubscription(ctx, id)
+	})
+}
+
+func (s instrumented) ListWebhookSubscriptions(
+	ctx context.Context, userID string,
+) ([]*model.WebhookSubscription, error) {
+	return observeResult(ctx, "list_webhook_subscriptions", func() ([]*model.WebhookSubscription, error) {
+		return s.next.ListWebhookSubscriptions(ctx, userID)
+	})
+}
+
+func (s instrumented) DeleteWebhookSubscription(ctx context.Context, id string) error {
+	return observe(ctx, "delete_webhook_subscription", func() error {
+		return s.next.DeleteWebhookSubscription(ctx, id)
+	})
+}
+
+func (s instrumented) SaveWebhookDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	return observe(ctx, "save_webhook_delivery", func() error {
+		return s.next.SaveWebhookDelivery(ctx, delivery)
+	})
+}
+
+func (s instrumented) GetWebhookDelivery(ctx context.Context, id string) (*model.WebhookDelivery, error) {
+	return observeResult(ctx, "get_webhook_delivery", func() (*model.WebhookDelivery, error) {
+		return s.next.GetWebhookDelivery(ctx, id)
+	})
+}
+
+func (s instrumented) ListWebhookDeliveries(
+	ctx context.Context, subscriptionID string, limit int,
+) ([]*model.WebhookDelivery, error) {
+	return observeResult(ctx, "list_webhook_deliveries", func() ([]*model.WebhookDelivery, error) {
+		return s.next.ListWebhookDeliveries(ctx, subscriptionID, limit)
+	})
+}
+
+func (s instrumented) ClaimPendingWebhookDeliveries(
+	ctx context.Context, owner string, until time.Time, limit int,
+) ([]*model.WebhookDelivery, error) {
+	return observeResult(ctx, "claim_pending_webhook_deliveries", func() ([]*model.WebhookDelivery, error) {
+		return s.next.ClaimPendingWebhookDeliveries(ctx, owner, until, limit)
+	})
+}
+
+func (s instrumented) RenewWebhookDeliveryClaim(ctx context.Context, id, owner string, until time.Time) error {
+	return observe(ctx, "renew_webhook_delivery_claim", func() error {
+		return s.next.RenewWebhookDeliveryClaim(ctx, id, owner, until)
+	})
+}
@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstrumented(t *testing.T) {
+	count := func(operation, result string) uint64 {
+		var m dto.Metric
+		require.NoError(t, operationDuration.WithLabelValues(operation, result).(prometheus.Metric).Write(&m))
+		return m.GetHistogram().GetSampleCount()
+	}
+	created, found, missing := count("create_event", "ok"), count("get_event", "ok"), count("get_event", "error")
+
+	s, _, err := NewFromConfig(&conf.StorageConf{Type: "inmemory"})
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, s.CreateEvent(ctx, &model.Event{ID: "1", Title: "test"}))
+	_, err = s.GetEvent(ctx, "1")
+	require.NoError(t, err)
+	_, err = s.GetEvent(ctx, "2")
+	require.ErrorIs(t, err, model.ErrEventNotFound)
+
+	require.Equal(t, created+1, count("create_event", "ok"))
+	require.Equal(t, found+1, count("get_event", "ok"))
+	require.Equal(t, missing+1, count("get_event", "error"))
+}
@@ -0,0 +1,82 @@
+package memorystorage
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+)
+
+const defaultArchiveLimit = 100
+
+func (s *Storage) ArchiveEvents(_ context.Context, retention model.Retention) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var archived []string
+	for id, event := range s.events {
+		if event.StartTime.Before(retention.ArchiveBefore(event.UserID)) {
+			s.archive[id] = &model.ArchivedEvent{Event: *event, ArchivedAt: now}
+			delete(s.events, id)
+			archived = append(archived, id)
+		}
+	}
+	s.reminders.remove(archived...)
+	return int64(len(archived)), nil
+}
+
+func (s *Storage) SearchArchivedEvents(_ context.Context, filter model.ArchiveFilter) ([]*model.ArchivedEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	title := strings.ToLower(filter.Title)
+	events := make([]*model.ArchivedEvent, 0)
+	for _, event := range s.archive {
+		switch {
+		case filter.UserID != "" && event.UserID != filter.UserID,
+			title != "" && !strings.Contains(strings.ToLower(event.Title), title),
+			!filter.From.IsZero() && event.StartTime.Before(filter.From),
+			!filter.To.IsZero() && !event.StartTime.Before(filter.To):
+			continue
+		}
+		archived := *event
+		events = append(events, &archived)
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].StartTime.Equal(events[j].StartTime) {
+			return events[i].StartTime.After(events[j].StartTime)
+		}
+		return events[i].ID < events[j].ID
+	})
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultArchiveLimit
+	}
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+func (s *Storage) RestoreArchivedEvent(_ context.Context, id string) (*model.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	archived, ok := s.archive[id]
+	if !ok {
+		return nil, model.ErrArchivedEventNotFound
+	}
+	if _, ok := s.events[id]; ok {
+		return nil, model.ErrAlreadyExists
+	}
+	event := archived.Event
+	s.events[id] = &event
+	s.reminders.add(&event)
+	delete(s.archive, id)
+	s.notifyEventChange(id)
+	return &event, nil
+}
@@ -0,0 +1,57 @@
+package memorystorage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+)
+
+func (s *Storage) SaveNotificationStatus(_ context.Context, status *model.NotificationStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if status.NotificationID == "" {
+		return model.ErrEmptyID
+	}
+	stored := *status
+	if stored.UpdatedAt.IsZero() {
+		stored.UpdatedAt = time.Now()
+	}
+	stored.Attempts = 1
+	if prev, ok := s.status[status.NotificationID]; ok {
+		stored.Attempts = prev.Attempts + 1
+	}
+	s.status[stored.NotificationID] = &stored
+	return nil
+}
+
+func (s *Storage) GetNotificationStatus(_ context.Context, notificationID string) (*model.NotificationStatus, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	status, ok := s.status[notificationID]
+	if !ok {
+		return nil, model.ErrNotificationStatusNotFound
+	}
+	res := *status
+	return &res, nil
+}
+
+func (s *Storage) ListNotificationStatuses(_ context.Context, eventID string) ([]*model.NotificationStatus, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var statuses []*model.NotificationStatus
+	for _, status := range s.status {
+		if status.EventID == eventID {
+			res := *status
+			statuses = append(statuses, &res)
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].UpdatedAt.Before(statuses[j].UpdatedAt)
+	})
+	return statuses, nil
+}
@@ -0,0 +1,38 @@
+package memorystorage
+
+import "context"
+
+const changesBufferSize = 100
+
+// ListenEventChanges calls the handler with IDs of created, updated and removed events until ctx is done.
+// Changes made while the handler is busy and the buffer is full are dropped.
+func (s *Storage) ListenEventChanges(ctx context.Context, handler func(eventID string)) error {
+	changes := make(chan string, changesBufferSize)
+	s.mu.Lock()
+	s.listeners[changes] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.listeners, changes)
+		s.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case eventID := <-changes:
+			handler(eventID)
+		}
+	}
+}
+
+// notifyEventChange must be called with the lock held.
+func (s *Storage) notifyEventChange(eventID string) {
+	for changes := range s.listeners {
+		select {
+		case changes <- eventID:
+		default:
+		}
+	}
+}
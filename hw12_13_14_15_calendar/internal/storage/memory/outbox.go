@@ -0,0 +1,98 @@
+package memorystorage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+)
+
+func (s *Storage) AddOutboxMessages(_ context.Context, messages []*model.OutboxMessage) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make(map[string]struct{}, len(s.outbox))
+	for _, m := range s.outbox {
+		keys[m.IdempotencyKey] = struct{}{}
+	}
+
+	var n int64
+	for _, m := range messages {
+		if m.ID == "" {
+			return n, model.ErrEmptyID
+		}
+		if _, ok := keys[m.IdempotencyKey]; ok {
+			continue
+		}
+		stored := *m
+		if stored.CreatedAt.IsZero() {
+			stored.CreatedAt = time.Now()
+		}
+		s.outbox[stored.ID] = &stored
+		keys[stored.IdempotencyKey] = struct{}{}
+		n++
+	}
+	return n, nil
+}
+
+func (s *Storage) FetchPendingOutboxMessages(_ context.Context, limit int) ([]*model.OutboxMessage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var messages []*model.OutboxMessage
+	for _, m := range s.outbox {
+		if m.SentAt.IsZero() {
+			stored := *m
+			messages = append(messages, &stored)
+		}
+	}
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+	})
+	if limit > 0 && len(messages) > limit {
+		messages = messages[:limit]
+	}
+	return messages, nil
+}
+
+func (s *Storage) MarkOutboxMessageSent(_ context.Context, id string, sentAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.outbox[id]
+	if !ok {
+		return model.ErrOutboxMessageNotFound
+	}
+	m.SentAt = sentAt
+	m.Attempts++
+	m.LastError = ""
+	return nil
+}
+
+func (s *Storage) MarkOutboxMessageFailed(_ context.Context, id string, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.outbox[id]
+	if !ok {
+		return model.ErrOutboxMessageNotFound
+	}
+	m.Attempts++
+	m.LastError = reason
+	return nil
+}
+
+func (s *Storage) DeleteSentOutboxMessagesOlderThan(_ context.Context, threshold time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int64
+	for id, m := range s.outbox {
+		if !m.SentAt.IsZero() && m.SentAt.Before(threshold) {
+			delete(s.outbox, id)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,73 @@
+package memorystorage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+)
+
+type reminderEntry struct {
+	notifyAt time.Time
+	eventID  string
+}
+
+// reminderIndex keeps notify times of all reminders sorted, so due reminders are found by a binary search.
+type reminderIndex []reminderEntry
+
+func (idx *reminderIndex) add(event *model.Event) {
+	for _, reminder := range event.Reminders {
+		entry := reminderEntry{notifyAt: reminder.NotifyAt(event), eventID: event.ID}
+		i := sort.Search(len(*idx), func(i int) bool {
+			return (*idx)[i].notifyAt.After(entry.notifyAt)
+		})
+		*idx = append(*idx, reminderEntry{})
+		copy((*idx)[i+1:], (*idx)[i:])
+		(*idx)[i] = entry
+	}
+}
+
+func (idx *reminderIndex) remove(eventIDs ...string) {
+	removed := make(map[string]struct{}, len(eventIDs))
+	for _, id := range eventIDs {
+		removed[id] = struct{}{}
+	}
+	entries := (*idx)[:0]
+	for _, entry := range *idx {
+		if _, ok := removed[entry.eventID]; !ok {
+			entries = append(entries, entry)
+		}
+	}
+	*idx = entries
+}
+
+// find returns IDs of the events with a reminder within [from, to), without duplicates.
+func (idx reminderIndex) find(from, to time.Time) []string {
+	i := sort.Search(len(idx), func(i int) bool {
+		return !idx[i].notifyAt.Before(from)
+	})
+	seen := make(map[string]struct{})
+	var ids []string
+	for ; i < len(idx) && idx[i].notifyAt.Before(to); i++ {
+		if _, ok := seen[idx[i].eventID]; ok {
+			continue
+		}
+		seen[idx[i].eventID] = struct{}{}
+		ids = append(ids, idx[i].eventID)
+	}
+	return ids
+}
+
+// FindEventsToNotify returns events having a reminder that fires within [from, to).
+// The events come with all their reminders, not only the due ones.
+func (s *Storage) FindEventsToNotify(_ context.Context, from, to time.Time) ([]*model.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var events []*model.Event
+	for _, id := range s.reminders.find(from, to) {
+		events = append(events, s.events[id])
+	}
+	return events, nil
+}
@@ -0,0 +1,27 @@
+package memorystorage
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+)
+
+func (s *Storage) GetSchedulerWatermark(_ context.Context, name string) (time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	watermark, ok := s.watermarks[name]
+	if !ok {
+		return time.Time{}, model.ErrWatermarkNotFound
+	}
+	return watermark, nil
+}
+
+func (s *Storage) SaveSchedulerWatermark(_ context.Context, name string, watermark time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.watermarks[name] = watermark
+	return nil
+}
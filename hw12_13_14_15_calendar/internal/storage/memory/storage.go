@@ -9,24 +9,38 @@ import (
 )
 
 type Storage struct {
-	events map[string]*model.Event
-	mu     sync.RWMutex
+	events     map[string]*model.Event
+	outbox     map[string]*model.OutboxMessage
+	status     map[string]*model.NotificationStatus
+	webhooks   map[string]*model.WebhookSubscription
+	deliveries map[string]*model.WebhookDelivery
+	watermarks map[string]time.Time
+	archive    map[string]*model.ArchivedEvent
+	reminders  reminderIndex
+	listeners  map[chan string]struct{}
+	mu         sync.RWMutex
 }
 
 func New() *Storage {
 	return &Storage{
-		events: make(map[string]*model.Event),
+		events:     make(map[string]*model.Event),
+		outbox:     make(map[string]*model.OutboxMessage),
+		status:     make(map[string]*model.NotificationStatus),
+		webhooks:   make(map[string]*model.WebhookSubscription),
+		deliveries: make(map[string]*model.WebhookDelivery),
+		watermarks: make(map[string]time.Time),
+		archive:    make(map[string]*model.ArchivedEvent),
+		listeners:  make(map[chan string]struct{}),
 	}
 }
 
 func NewWithEvents(events []*model.Event) *Storage {
-	eventsMap := make(map[string]*model.Event)
+	s := New()
 	for _, event := range events {
-		eventsMap[event.ID] = event
-	}
-	return &Storage{
-		events: eventsMap,
+		s.events[event.ID] = event
+		s.reminders.add(event)
 	}
+	return s
 }
 
 func (s *Storage) CreateEvent(_ context.Context, event *model.Event) error {
@@ -39,6 +53,8 @@ func (s *Storage) CreateEvent(_ context.Context, event *model.Event) error {
 		return model.ErrAlreadyExists
 	}
 	s.events[event.ID] = event
+	s.reminders.add(event)
+	s.notifyEventChange(event.ID)
 	return nil
 }
 
@@ -52,6 +68,9 @@ func (s *Storage) UpdateEvent(_ context.Context, event *model.Event) error {
 		return model.ErrEventNotFound
 	}
 	s.events[event.ID] = event
+	s.reminders.remove(event.ID)
+	s.reminders.add(event)
+	s.notifyEventChange(event.ID)
 	return nil
 }
 
@@ -62,9 +81,26 @@ func (s *Storage) RemoveEvent(_ context.Context, eventID string) error {
 		return model.ErrEventNotFound
 	}
 	delete(s.events, eventID)
+	s.reminders.remove(eventID)
+	s.notifyEventChange(eventID)
+	return nil
+}
+
+// Ping always succeeds, the storage lives in the process.
+func (s *Storage) Ping(_ context.Context) error {
 	return nil
 }
 
+func (s *Storage) GetEvent(_ context.Context, eventID string) (*model.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	event, ok := s.events[eventID]
+	if !ok {
+		return nil, model.ErrEventNotFound
+	}
+	return event, nil
+}
+
 func (s *Storage) FilterEventsByDay(_ context.Context, date time.Time) ([]*model.Event, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -114,12 +150,13 @@ func (s *Storage) DeleteEventsOlderThan(_ context.Context, threshold time.Time)
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	var n int64
+	var removed []string
 	for id, event := range s.events {
 		if event.StartTime.Before(threshold) {
 			delete(s.events, id)
-			n++
+			removed = append(removed, id)
 		}
 	}
-	return n, nil
+	s.reminders.remove(removed...)
+	return int64(len(removed)), nil
 }
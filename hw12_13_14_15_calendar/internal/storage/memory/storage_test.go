@@ -76,6 +76,26 @@ func TestRemove(t *testing.T) {
 	}
 }
 
+func TestGet(t *testing.T) {
+	s := NewWithEvents([]*model.Event{{
+		ID:    "1",
+		Title: "test",
+	}})
+
+	event, err := s.GetEvent(context.TODO(), "1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if event.Title != "test" {
+		t.Fatalf("unexpected event: %v", event)
+	}
+
+	_, err = s.GetEvent(context.TODO(), "2")
+	if !errors.Is(err, model.ErrEventNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
 func TestFilterByDay(t *testing.T) {
 	s := NewWithEvents([]*model.Event{
 		{
@@ -176,3 +196,352 @@ func TestFilterByMonth(t *testing.T) {
 		t.Fatalf("unexpected events count: %v", len(events))
 	}
 }
+
+func TestOutbox(t *testing.T) {
+	s := New()
+	ctx := context.TODO()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	n, err := s.AddOutboxMessages(ctx, []*model.OutboxMessage{
+		{ID: "1", IdempotencyKey: "event-1:1", CreatedAt: now},
+		{ID: "2", IdempotencyKey: "event-2:1", CreatedAt: now.Add(time.Second)},
+		// duplicate key produced by an overlapping scan
+		{ID: "3", IdempotencyKey: "event-1:1", CreatedAt: now.Add(2 * time.Second)},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("unexpected inserted count: %v", n)
+	}
+
+	if err := s.MarkOutboxMessageSent(ctx, "1", now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.MarkOutboxMessageFailed(ctx, "2", "broker is down"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pending, err := s.FetchPendingOutboxMessages(ctx, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pending) != 1 || pending[0].ID != "2" || pending[0].Attempts != 1 {
+		t.Fatalf("unexpected pending messages: %v", pending)
+	}
+
+	deleted, err := s.DeleteSentOutboxMessagesOlderThan(ctx, now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if deleted != 1 {
+		t.Fatalf("unexpected deleted count: %v", deleted)
+	}
+
+	if err := s.MarkOutboxMessageSent(ctx, "1", now); !errors.Is(err, model.ErrOutboxMessageNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestNotificationStatus(t *testing.T) {
+	s := New()
+	ctx := context.TODO()
+
+	_, err := s.GetNotificationStatus(ctx, "1")
+	if !errors.Is(err, model.ErrNotificationStatusNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = s.SaveNotificationStatus(ctx, &model.NotificationStatus{
+		NotificationID: "1",
+		EventID:        "event-1",
+		State:          model.NotificationRetrying,
+		Error:          "smtp timeout",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = s.SaveNotificationStatus(ctx, &model.NotificationStatus{
+		NotificationID: "1",
+		EventID:        "event-1",
+		State:          model.NotificationDelivered,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	status, err := s.GetNotificationStatus(ctx, "1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status.State != model.NotificationDelivered || status.Attempts != 2 || status.Error != "" {
+		t.Fatalf("unexpected status: %v", status)
+	}
+
+	statuses, err := s.ListNotificationStatuses(ctx, "event-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(statuses) != 1 {
+		t.Fatalf("unexpected statuses count: %v", len(statuses))
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	s := New()
+	ctx := context.TODO()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, subscription := range []*model.WebhookSubscription{
+		{ID: "admin", URL: "http://example.com/admin", CreatedAt: now},
+		{ID: "user", UserID: "user-1", URL: "http://example.com/user", CreatedAt: now.Add(time.Second)},
+	} {
+		if err := s.CreateWebhookSubscription(ctx, subscription); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	subscriptions, err := s.ListWebhookSubscriptions(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(subscriptions) != 1 || subscriptions[0].ID != "user" {
+		t.Fatalf("unexpected subscriptions: %v", subscriptions)
+	}
+	subscriptions, err = s.ListWebhookSubscriptions(ctx, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(subscriptions) != 2 {
+		t.Fatalf("unexpected subscriptions: %v", subscriptions)
+	}
+
+	for i, id := range []string{"1", "2"} {
+		err := s.SaveWebhookDelivery(ctx, &model.WebhookDelivery{
+			ID:             id,
+			SubscriptionID: "admin",
+			State:          model.WebhookDeliveryPending,
+			CreatedAt:      now.Add(time.Duration(i) * time.Second),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	err = s.SaveWebhookDelivery(ctx, &model.WebhookDelivery{
+		ID:             "1",
+		SubscriptionID: "admin",
+		State:          model.WebhookDeliveryDelivered,
+		Attempts:       1,
+		StatusCode:     200,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	deliveries, err := s.ListWebhookDeliveries(ctx, "admin", 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(deliveries) != 2 || deliveries[0].ID != "2" || deliveries[1].State != model.WebhookDeliveryDelivered {
+		t.Fatalf("unexpected deliveries: %v", deliveries)
+	}
+	if !deliveries[1].CreatedAt.Equal(now) {
+		t.Fatalf("creation time must be kept on update: %v", deliveries[1].CreatedAt)
+	}
+	pending, err := s.ClaimPendingWebhookDeliveries(ctx, "a", time.Now().Add(time.Minute), 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pending) != 1 || pending[0].ID != "2" || pending[0].ClaimedBy != "a" {
+		t.Fatalf("unexpected pending deliveries: %v", pending)
+	}
+	// a claimed delivery is left to its owner
+	if pending, _ = s.ClaimPendingWebhookDeliveries(ctx, "b", time.Now().Add(time.Minute), 10); len(pending) != 0 {
+		t.Fatalf("claimed deliveries must not be claimed again: %v", pending)
+	}
+	if err := s.RenewWebhookDeliveryClaim(ctx, "2", "b", time.Now().Add(time.Minute)); !errors.Is(err, model.ErrWebhookDeliveryNotClaimed) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// an expired claim is taken over
+	if err := s.RenewWebhookDeliveryClaim(ctx, "2", "a", time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pending, _ = s.ClaimPendingWebhookDeliveries(ctx, "b", time.Now().Add(time.Minute), 10); len(pending) != 1 {
+		t.Fatalf("expired claims must be claimed again: %v", pending)
+	}
+	if err := s.RenewWebhookDeliveryClaim(ctx, "2", "a", time.Now().Add(time.Minute)); !errors.Is(err, model.ErrWebhookDeliveryNotClaimed) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := s.DeleteWebhookSubscription(ctx, "admin"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := s.GetWebhookDelivery(ctx, "1"); !errors.Is(err, model.ErrWebhookDeliveryNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.DeleteWebhookSubscription(ctx, "admin"); !errors.Is(err, model.ErrWebhookNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestFindEventsToNotify(t *testing.T) {
+	midnight := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	s := NewWithEvents([]*model.Event{
+		// the reminder fires the day before the event
+		{ID: "1", StartTime: midnight.Add(30 * time.Minute), Reminders: []model.Reminder{{Offset: time.Hour}}},
+		{ID: "2", StartTime: midnight.Add(2 * time.Hour), Reminders: []model.Reminder{
+			{Offset: 2*time.Hour + 45*time.Minute},
+			{Offset: 2*time.Hour + 15*time.Minute},
+		}},
+		{ID: "3", StartTime: midnight.Add(time.Hour), Reminders: []model.Reminder{{Offset: 10 * time.Minute}}},
+		{ID: "4", StartTime: midnight.Add(-time.Hour)},
+	})
+	ctx := context.TODO()
+
+	events, err := s.FindEventsToNotify(ctx, midnight.Add(-time.Hour), midnight)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// the event with two due reminders is returned once, sorted by the first reminder
+	if len(events) != 2 || events[0].ID != "2" || events[1].ID != "1" {
+		t.Fatalf("unexpected events: %v", events)
+	}
+
+	// the index follows updates and removals
+	err = s.UpdateEvent(ctx, &model.Event{
+		ID:        "3",
+		StartTime: midnight,
+		Reminders: []model.Reminder{{Offset: time.Minute}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.RemoveEvent(ctx, "2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	events, err = s.FindEventsToNotify(ctx, midnight.Add(-time.Hour), midnight)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 2 || events[0].ID != "1" || events[1].ID != "3" {
+		t.Fatalf("unexpected events: %v", events)
+	}
+}
+
+func TestSchedulerWatermark(t *testing.T) {
+	s := New()
+	ctx := context.TODO()
+
+	if _, err := s.GetSchedulerWatermark(ctx, "scan"); !errors.Is(err, model.ErrWatermarkNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	watermark := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := s.SaveSchedulerWatermark(ctx, "scan", watermark); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := s.GetSchedulerWatermark(ctx, "scan")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !got.Equal(watermark) {
+		t.Fatalf("unexpected watermark: %v", got)
+	}
+}
+
+func TestListenEventChanges(t *testing.T) {
+	s := New()
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan string, 1)
+	done := make(chan error)
+	go func() {
+		done <- s.ListenEventChanges(ctx, func(eventID string) {
+			changes <- eventID
+		})
+	}()
+
+	// the listener registers asynchronously
+	for registered := false; !registered; time.Sleep(time.Millisecond) {
+		s.mu.RLock()
+		registered = len(s.listeners) == 1
+		s.mu.RUnlock()
+	}
+
+	if err := s.CreateEvent(ctx, &model.Event{ID: "1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.RemoveEvent(ctx, "1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case eventID := <-changes:
+			if eventID != "1" {
+				t.Fatalf("unexpected event: %v", eventID)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("no change received")
+		}
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestArchive(t *testing.T) {
+	ctx := context.TODO()
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	old := &model.Event{
+		ID: "old", Title: "Quarterly Review", UserID: "user-1", StartTime: now.AddDate(0, 0, -40),
+		Reminders: []model.Reminder{{Offset: time.Hour, Channel: "email"}},
+	}
+	recent := &model.Event{ID: "recent", Title: "Retro", UserID: "user-1", StartTime: now.AddDate(0, 0, -5)}
+	kept := &model.Event{ID: "kept", Title: "Audit", UserID: "user-2", StartTime: now.AddDate(0, 0, -400)}
+	s := NewWithEvents([]*model.Event{old, recent, kept})
+
+	// user-2 keeps everything
+	archived, err := s.ArchiveEvents(ctx, model.Retention{
+		Before: now.AddDate(0, 0, -30),
+		Users:  map[string]time.Time{"user-2": {}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if archived != 1 {
+		t.Fatalf("expected 1 archived event, got %d", archived)
+	}
+	if _, ok := s.events["old"]; ok {
+		t.Fatalf("archived event is still in the calendar")
+	}
+	if len(s.reminders.find(now.AddDate(0, 0, -41), now)) != 0 {
+		t.Fatalf("reminders of the archived event are still indexed")
+	}
+
+	found, err := s.SearchArchivedEvents(ctx, model.ArchiveFilter{UserID: "user-1", Title: "review"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(found) != 1 || found[0].ID != "old" || len(found[0].Reminders) != 1 {
+		t.Fatalf("unexpected search result: %v", found)
+	}
+	found, err = s.SearchArchivedEvents(ctx, model.ArchiveFilter{From: now.AddDate(0, 0, -30)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(found) != 0 {
+		t.Fatalf("unexpected search result: %v", found)
+	}
+
+	restored, err := s.RestoreArchivedEvent(ctx, "old")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if restored.Title != old.Title || len(restored.Reminders) != 1 {
+		t.Fatalf("unexpected restored event: %v", restored)
+	}
+	if _, ok := s.events["old"]; !ok {
+		t.Fatalf("restored event is not in the calendar")
+	}
+	if _, err := s.RestoreArchivedEvent(ctx, "old"); !errors.Is(err, model.ErrArchivedEventNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
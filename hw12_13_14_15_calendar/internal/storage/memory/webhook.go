@@ -0,0 +1,165 @@
+package memorystorage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+)
+
+func (s *Storage) CreateWebhookSubscription(_ context.Context, subscription *model.WebhookSubscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if subscription.ID == "" {
+		return model.ErrEmptyID
+	}
+	if _, ok := s.webhooks[subscription.ID]; ok {
+		return model.ErrAlreadyExists
+	}
+	stored := *subscription
+	if stored.CreatedAt.IsZero() {
+		stored.CreatedAt = time.Now()
+	}
+	s.webhooks[stored.ID] = &stored
+	return nil
+}
+
+func (s *Storage) GetWebhookSubscription(_ context.Context, id string) (*model.WebhookSubscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	subscription, ok := s.webhooks[id]
+	if !ok {
+		return nil, model.ErrWebhookNotFound
+	}
+	res := *subscription
+	return &res, nil
+}
+
+func (s *Storage) ListWebhookSubscriptions(_ context.Context, userID string) ([]*model.WebhookSubscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var subscriptions []*model.WebhookSubscription
+	for _, subscription := range s.webhooks {
+		if userID == "" || subscription.UserID == userID {
+			res := *subscription
+			subscriptions = append(subscriptions, &res)
+		}
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].CreatedAt.Before(subscriptions[j].CreatedAt)
+	})
+	return subscriptions, nil
+}
+
+func (s *Storage) DeleteWebhookSubscription(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.webhooks[id]; !ok {
+		return model.ErrWebhookNotFound
+	}
+	delete(s.webhooks, id)
+	for deliveryID, delivery := range s.deliveries {
+		if delivery.SubscriptionID == id {
+			delete(s.deliveries, deliveryID)
+		}
+	}
+	return nil
+}
+
+func (s *Storage) SaveWebhookDelivery(_ context.Context, delivery *model.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if delivery.ID == "" {
+		return model.ErrEmptyID
+	}
+	stored := *delivery
+	if prev, ok := s.deliveries[delivery.ID]; ok {
+		stored.CreatedAt = prev.CreatedAt
+	} else if stored.CreatedAt.IsZero() {
+		stored.CreatedAt = time.Now()
+	}
+	stored.UpdatedAt = time.Now()
+	s.deliveries[stored.ID] = &stored
+	return nil
+}
+
+func (s *Storage) GetWebhookDelivery(_ context.Context, id string) (*model.WebhookDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	delivery, ok := s.deliveries[id]
+	if !ok {
+		return nil, model.ErrWebhookDeliveryNotFound
+	}
+	res := *delivery
+	return &res, nil
+}
+
+func (s *Storage) ListWebhookDeliveries(
+	_ context.Context, subscriptionID string, limit int,
+) ([]*model.WebhookDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var deliveries []*model.WebhookDelivery
+	for _, delivery := range s.deliveries {
+		if delivery.SubscriptionID == subscriptionID {
+			res := *delivery
+			deliveries = append(deliveries, &res)
+		}
+	}
+	// the most recent deliveries go first
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+	})
+	if limit > 0 && len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+func (s *Storage) ClaimPendingWebhookDeliveries(
+	_ context.Context, owner string, until time.Time, limit int,
+) ([]*model.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var claimed []*model.WebhookDelivery
+	for _, delivery := range s.deliveries {
+		if delivery.State == model.WebhookDeliveryPending && !delivery.ClaimedUntil.After(now) {
+			claimed = append(claimed, delivery)
+		}
+	}
+	sort.Slice(claimed, func(i, j int) bool {
+		return claimed[i].CreatedAt.Before(claimed[j].CreatedAt)
+	})
+	if limit > 0 && len(claimed) > limit {
+		claimed = claimed[:limit]
+	}
+	deliveries := make([]*model.WebhookDelivery, len(claimed))
+	for i, delivery := range claimed {
+		delivery.ClaimedBy, delivery.ClaimedUntil = owner, until
+		res := *delivery
+		deliveries[i] = &res
+	}
+	return deliveries, nil
+}
+
+func (s *Storage) RenewWebhookDeliveryClaim(_ context.Context, id, owner string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delivery, ok := s.deliveries[id]
+	if !ok || delivery.ClaimedBy != owner || delivery.State != model.WebhookDeliveryPending {
+		return model.ErrWebhookDeliveryNotClaimed
+	}
+	delivery.ClaimedUntil = until
+	return nil
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrArchivedEventNotFound = errors.New("archived event not found")
+
+// ArchivedEvent is an event moved out of the calendar by the retention policy.
+type ArchivedEvent struct {
+	Event
+	ArchivedAt time.Time
+}
+
+// Retention selects the events to archive. Events of a user listed in Users are archived if they
+// start before the user's time, events of other users if they start before Before.
+// A zero time keeps the events.
+type Retention struct {
+	Before time.Time
+	Users  map[string]time.Time
+}
+
+// ArchiveBefore returns the time events of the user are archived before.
+func (r Retention) ArchiveBefore(userID string) time.Time {
+	if before, ok := r.Users[userID]; ok {
+		return before
+	}
+	return r.Before
+}
+
+// ArchiveFilter selects archived events, empty fields match everything.
+type ArchiveFilter struct {
+	UserID string
+	Title  string    // case-insensitive substring of the title
+	From   time.Time // events starting at or after
+	To     time.Time // events starting before
+	Limit  int
+}
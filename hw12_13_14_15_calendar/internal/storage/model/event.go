@@ -17,5 +17,18 @@ type Event struct {
 	StartTime   time.Time
 	EndTime     time.Time
 	UserID      string
-	NotifyDelta int // in minutes
+	NotifyDelta int // in minutes, kept for compatibility, use Reminders instead
+	Reminders   []Reminder
+}
+
+// Reminder describes a single notification sent Offset before the start of the event.
+type Reminder struct {
+	Offset  time.Duration
+	Channel string // empty means the default channel of the sender
+	Message string // optional custom text of the notification
+}
+
+// NotifyAt returns the time the reminder should fire for the given event.
+func (r Reminder) NotifyAt(event *Event) time.Time {
+	return event.StartTime.Add(-r.Offset)
 }
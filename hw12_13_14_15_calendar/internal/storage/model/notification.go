@@ -0,0 +1,26 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrNotificationStatusNotFound = errors.New("notification status not found")
+
+type NotificationState string
+
+const (
+	NotificationDelivered NotificationState = "delivered"
+	NotificationFailed    NotificationState = "failed"
+	NotificationRetrying  NotificationState = "retrying"
+)
+
+// NotificationStatus is the last known delivery outcome of a notification reported by the sender.
+type NotificationStatus struct {
+	NotificationID string
+	EventID        string
+	State          NotificationState
+	Attempts       int
+	Error          string
+	UpdatedAt      time.Time
+}
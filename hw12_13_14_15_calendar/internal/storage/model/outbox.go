@@ -0,0 +1,20 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrOutboxMessageNotFound = errors.New("outbox message not found")
+
+// OutboxMessage is a message waiting in the outbox to be published to the queue.
+// IdempotencyKey is unique across the outbox, so a message can be enqueued only once.
+type OutboxMessage struct {
+	ID             string
+	IdempotencyKey string
+	Payload        []byte
+	CreatedAt      time.Time
+	SentAt         time.Time // zero until the message is published
+	Attempts       int
+	LastError      string
+}
@@ -0,0 +1,5 @@
+package model
+
+import "errors"
+
+var ErrWatermarkNotFound = errors.New("scheduler watermark not found")
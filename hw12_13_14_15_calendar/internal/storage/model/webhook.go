@@ -0,0 +1,50 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook subscription not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	// ErrWebhookDeliveryNotClaimed is returned when the delivery is claimed by another dispatcher.
+	ErrWebhookDeliveryNotClaimed = errors.New("webhook delivery is not claimed by the dispatcher")
+)
+
+// WebhookSubscription is a registered endpoint called on event lifecycle changes.
+// A subscription without UserID belongs to an admin and receives changes of all users.
+type WebhookSubscription struct {
+	ID         string
+	UserID     string
+	URL        string
+	Secret     string
+	EventTypes []string // empty means all types
+	CreatedAt  time.Time
+}
+
+type WebhookDeliveryState string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryState = "pending"
+	WebhookDeliveryDelivered WebhookDeliveryState = "delivered"
+	WebhookDeliveryFailed    WebhookDeliveryState = "failed"
+)
+
+// WebhookDelivery is a delivery log entry of a single payload to a subscription.
+type WebhookDelivery struct {
+	ID             string
+	SubscriptionID string
+	EventType      string
+	Payload        []byte
+	State          WebhookDeliveryState
+	Attempts       int
+	StatusCode     int
+	Error          string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	// ClaimedBy is the dispatcher delivering the pending delivery, the others
+	// do not resume it until ClaimedUntil
+	ClaimedBy    string
+	ClaimedUntil time.Time
+}
@@ -0,0 +1,170 @@
+package sqlstorage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+	"github.com/jackc/pgx/v5"
+)
+
+const defaultArchiveLimit = 100
+
+// archivedReminder is the JSON form of a reminder in events_archive.reminders.
+type archivedReminder struct {
+	OffsetSeconds int64  `json:"offsetSeconds"`
+	Channel       string `json:"channel"`
+	Message       string `json:"message"`
+}
+
+// ArchiveEvents moves the events selected by the retention with their reminders into events_archive
+// in a single statement, so an event is never lost or duplicated halfway.
+func (s *Storage) ArchiveEvents(ctx context.Context, retention model.Retention) (int64, error) {
+	userIDs := make([]string, 0, len(retention.Users))
+	befores := make([]time.Time, 0, len(retention.Users))
+	for userID, before := range retention.Users {
+		userIDs = append(userIDs, userID)
+		befores = append(befores, before)
+	}
+
+	result, err := s.Pool.Exec(ctx, `
+WITH rules AS (SELECT * FROM unnest($1::text[], $2::timestamptz[]) AS r (user_id, archive_before)),
+     moved AS (
+         DELETE FROM events e
+         WHERE e.start_time < COALESCE((SELECT r.archive_before FROM rules r WHERE r.user_id = e.user_id), $3)
+         RETURNING e.id, e.title, e.start_time, e.end_time, e.user_id, e.notify_delta)
+INSERT INTO events_archive (id, title, start_time, end_time, user_id, notify_delta, reminders, archived_at)
+SELECT m.id, m.title, m.start_time, m.end_time, m.user_id, m.notify_delta,
+       COALESCE((SELECT jsonb_agg(jsonb_build_object(
+                                      'offsetSeconds', r.offset_seconds, 'channel', r.channel, 'message', r.message)
+                                  ORDER BY r.offset_seconds DESC)
+                 FROM reminders r WHERE r.event_id = m.id), '[]'::jsonb),
+       now()
+FROM moved m
+ON CONFLICT (id) DO UPDATE SET title        = EXCLUDED.title,
+                               start_time   = EXCLUDED.start_time,
+                               end_time     = EXCLUDED.end_time,
+                               user_id      = EXCLUDED.user_id,
+                               notify_delta = EXCLUDED.notify_delta,
+                               reminders    = EXCLUDED.reminders,
+                               archived_at  = EXCLUDED.archived_at`,
+		userIDs, befores, retention.Before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive events: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+func (s *Storage) SearchArchivedEvents(ctx context.Context, filter model.ArchiveFilter) (
+	[]*model.ArchivedEvent, error,
+) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultArchiveLimit
+	}
+	var from, to *time.Time
+	if !filter.From.IsZero() {
+		from = &filter.From
+	}
+	if !filter.To.IsZero() {
+		to = &filter.To
+	}
+
+	rows, err := s.Pool.Query(ctx, `
+SELECT id, title, start_time, end_time, user_id, notify_delta, reminders, archived_at
+FROM events_archive
+WHERE ($1 = '' OR user_id = $1)
+  AND ($2 = '' OR title ILIKE '%' || $2 || '%')
+  AND ($3::timestamptz IS NULL OR start_time >= $3)
+  AND ($4::timestamptz IS NULL OR start_time < $4)
+ORDER BY start_time DESC, id
+LIMIT $5`,
+		filter.UserID, escapeLike(filter.Title), from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search archived events: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]*model.ArchivedEvent, 0)
+	for rows.Next() {
+		event, err := scanArchivedEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// RestoreArchivedEvent moves the archived event back into the calendar.
+func (s *Storage) RestoreArchivedEvent(ctx context.Context, id string) (*model.Event, error) {
+	tx, err := s.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	archived, err := scanArchivedEvent(tx.QueryRow(ctx, `
+SELECT id, title, start_time, end_time, user_id, notify_delta, reminders, archived_at
+FROM events_archive WHERE id = $1 FOR UPDATE`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, model.ErrArchivedEventNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	event := &archived.Event
+	res, err := tx.Exec(ctx, `
+INSERT INTO events (id, title, start_time, end_time, user_id, notify_delta)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (id) DO NOTHING`,
+		event.ID, event.Title, event.StartTime, event.EndTime, event.UserID, event.NotifyDelta)
+	if err != nil {
+		return nil, err
+	}
+	if res.RowsAffected() == 0 {
+		// the ID has been taken by a new event since
+		return nil, model.ErrAlreadyExists
+	}
+	if err := insertReminders(ctx, tx, event); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM events_archive WHERE id = $1", id); err != nil {
+		return nil, err
+	}
+	if err := notifyEventChange(ctx, tx, event.ID); err != nil {
+		return nil, err
+	}
+	return event, tx.Commit(ctx)
+}
+
+func scanArchivedEvent(row pgx.Row) (*model.ArchivedEvent, error) {
+	event := &model.ArchivedEvent{}
+	var reminders []byte
+	if err := row.Scan(&event.ID, &event.Title, &event.StartTime, &event.EndTime, &event.UserID,
+		&event.NotifyDelta, &reminders, &event.ArchivedAt); err != nil {
+		return nil, err
+	}
+	var archivedReminders []archivedReminder
+	if err := json.Unmarshal(reminders, &archivedReminders); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal reminders: %w", err)
+	}
+	for _, r := range archivedReminders {
+		event.Reminders = append(event.Reminders, model.Reminder{
+			Offset:  time.Duration(r.OffsetSeconds) * time.Second,
+			Channel: r.Channel,
+			Message: r.Message,
+		})
+	}
+	return event, nil
+}
+
+// escapeLike escapes the wildcards of LIKE, so the pattern matches a plain substring.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -7,7 +7,7 @@ import (
 
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/require"
 )
 
@@ -108,7 +108,7 @@ func FilterEventsByMonthFixture() ([]*model.Event, time.Time) {
 }
 
 func InsertEvent(
-	conn *pgx.Conn, id string, title string, startTime time.Time, endTime time.Time, userID string, notifyDelta int,
+	conn *pgxpool.Pool, id string, title string, startTime time.Time, endTime time.Time, userID string, notifyDelta int,
 ) error {
 	_, err := conn.Exec(context.TODO(),
 		`INSERT INTO events (id, title, start_time, end_time, user_id, notify_delta) 
@@ -121,7 +121,7 @@ VALUES ($1, $2, $3, $4, $5, $6)`,
 func InsertEvents(t *testing.T, testData []*model.Event, s *Storage) {
 	t.Helper()
 	for _, event := range testData {
-		err := InsertEvent(s.Conn, event.ID, event.Title, event.StartTime, event.EndTime, event.UserID, event.NotifyDelta)
+		err := InsertEvent(s.Pool, event.ID, event.Title, event.StartTime, event.EndTime, event.UserID, event.NotifyDelta)
 		require.NoError(t, err)
 	}
 }
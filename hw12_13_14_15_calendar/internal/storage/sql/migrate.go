@@ -2,28 +2,64 @@ package sqlstorage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/assets"
 	"github.com/jackc/tern/v2/migrate"
 )
 
-const schemaVersionTable = "schema_version"
+const (
+	schemaVersionTable = "schema_version"
+	migrationSeparator = "---- create above / drop below ----"
+)
 
-func (s *Storage) Migrate(ctx context.Context, callBack func(_ int32, name, direction, sql string)) error {
-	migrator, err := migrate.NewMigrator(ctx, s.Conn, schemaVersionTable)
-	if err != nil {
-		return fmt.Errorf("failed to create migrator: %w", err)
-	}
+var (
+	ErrBadMigrationVersion = errors.New("bad migration version")
+	ErrIrreversible        = errors.New("migration cannot be reverted")
+	ErrInvalidMigration    = errors.New("invalid migration")
+
+	migrationFileRe = regexp.MustCompile(`^(\d+)_[a-z0-9_]+\.sql$`)
+	migrationNameRe = regexp.MustCompile(`^[a-z0-9_]+$`)
+)
+
+// Migration is a migration file, Version is its sequence number.
+type Migration struct {
+	Version int32
+	Name    string
+	UpSQL   string
+	DownSQL string
+}
+
+// MigrationStep is a migration applied in a direction, "up" or "down".
+type MigrationStep struct {
+	Version   int32
+	Name      string
+	Direction string
+	SQL       string
+}
+
+// EmbeddedMigrations returns the migrations built into the binary.
+func EmbeddedMigrations() (fs.FS, error) {
 	dir, err := fs.Sub(assets.Migrations, "migrations")
 	if err != nil {
-		return fmt.Errorf("failed to get migrations dir: %w", err)
+		return nil, fmt.Errorf("failed to get migrations dir: %w", err)
 	}
-	err = migrator.LoadMigrations(dir)
+	return dir, nil
+}
+
+func (s *Storage) Migrate(ctx context.Context, callBack func(_ int32, name, direction, sql string)) error {
+	migrator, release, err := s.newMigrator(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to load migrations: %w", err)
+		return err
 	}
+	defer release()
 	migrator.OnStart = callBack
 	err = migrator.Migrate(ctx)
 	if err != nil {
@@ -31,3 +67,179 @@ func (s *Storage) Migrate(ctx context.Context, callBack func(_ int32, name, dire
 	}
 	return err
 }
+
+// MigrateTo migrates up or down to the version, 0 reverts all migrations.
+func (s *Storage) MigrateTo(
+	ctx context.Context, version int32, callBack func(_ int32, name, direction, sql string),
+) error {
+	migrator, release, err := s.newMigrator(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	if _, err := plan(ctx, migrator, version); err != nil {
+		return err
+	}
+	migrator.OnStart = callBack
+	if err := migrator.MigrateTo(ctx, version); err != nil {
+		return fmt.Errorf("failed to migrate to version %d: %w", version, err)
+	}
+	return nil
+}
+
+// MigrationPlan returns the steps migrating to the version without running them.
+func (s *Storage) MigrationPlan(ctx context.Context, version int32) ([]MigrationStep, error) {
+	migrator, release, err := s.newMigrator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return plan(ctx, migrator, version)
+}
+
+// MigrationStatus returns the current version of the database and the available migrations.
+func (s *Storage) MigrationStatus(ctx context.Context) (int32, []Migration, error) {
+	migrator, release, err := s.newMigrator(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer release()
+	current, err := migrator.GetCurrentVersion(ctx)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to get current version: %w", err)
+	}
+	return current, migrations(migrator), nil
+}
+
+// ValidateMigrations loads the migrations in the directory and checks that every file
+// is named properly, has both parts and is reversible.
+func ValidateMigrations(ctx context.Context, dir fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(dir, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations dir: %w", err)
+	}
+	var problems []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".sql") && !migrationFileRe.MatchString(entry.Name()) {
+			problems = append(problems, fmt.Sprintf("%s: name must look like 001_create_table.sql", entry.Name()))
+		}
+	}
+
+	// a migrator without a connection only loads migrations
+	migrator, err := migrate.NewMigrator(ctx, nil, schemaVersionTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migrator: %w", err)
+	}
+	if err := migrator.LoadMigrations(dir); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidMigration, err)
+	}
+	for _, m := range migrator.Migrations {
+		body, err := fs.ReadFile(dir, m.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", m.Name, err)
+		}
+		if !strings.Contains(string(body), migrationSeparator) || strings.TrimSpace(m.DownSQL) == "" {
+			problems = append(problems, fmt.Sprintf("%s: no down migration below %q", m.Name, migrationSeparator))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%w:\n%s", ErrInvalidMigration, strings.Join(problems, "\n"))
+	}
+	return migrations(migrator), nil
+}
+
+// CreateMigration scaffolds the next migration file in the directory and returns its path.
+func CreateMigration(dir, name string) (string, error) {
+	if !migrationNameRe.MatchString(name) {
+		return "", fmt.Errorf("%w: name %q must consist of lowercase letters, digits and underscores",
+			ErrInvalidMigration, name)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read migrations dir: %w", err)
+	}
+	last := 0
+	for _, entry := range entries {
+		if m := migrationFileRe.FindStringSubmatch(entry.Name()); m != nil {
+			if n, _ := strconv.Atoi(m[1]); n > last {
+				last = n
+			}
+		}
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%03d_%s.sql", last+1, name))
+	body := "-- TODO: write the migration\n\n" + migrationSeparator + "\n\n-- TODO: revert the migration\n"
+	// O_EXCL keeps an existing migration from being overwritten
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644) //nolint:gosec // a source file
+	if err != nil {
+		return "", fmt.Errorf("failed to create migration: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(body); err != nil {
+		return "", fmt.Errorf("failed to write migration: %w", err)
+	}
+	return path, nil
+}
+
+// newMigrator creates a migrator on a connection acquired from the pool,
+// the connection is returned to the pool by release.
+func (s *Storage) newMigrator(ctx context.Context) (*migrate.Migrator, func(), error) {
+	conn, err := s.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	migrator, err := migrate.NewMigrator(ctx, conn.Conn(), schemaVersionTable)
+	if err != nil {
+		conn.Release()
+		return nil, nil, fmt.Errorf("failed to create migrator: %w", err)
+	}
+	dir, err := EmbeddedMigrations()
+	if err != nil {
+		conn.Release()
+		return nil, nil, err
+	}
+	err = migrator.LoadMigrations(dir)
+	if err != nil {
+		conn.Release()
+		return nil, nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	return migrator, conn.Release, nil
+}
+
+// plan walks from the current version to the target the same way the migrator does.
+func plan(ctx context.Context, migrator *migrate.Migrator, target int32) ([]MigrationStep, error) {
+	current, err := migrator.GetCurrentVersion(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current version: %w", err)
+	}
+	available := int32(len(migrator.Migrations))
+	if target < 0 || target > available {
+		return nil, fmt.Errorf("%w: %d is outside of 0 to %d", ErrBadMigrationVersion, target, available)
+	}
+	if current > available {
+		return nil, fmt.Errorf("%w: the database is at %d, but only %d migrations are known",
+			ErrBadMigrationVersion, current, available)
+	}
+
+	var steps []MigrationStep
+	for v := current; v < target; v++ {
+		m := migrator.Migrations[v]
+		steps = append(steps, MigrationStep{Version: m.Sequence, Name: m.Name, Direction: "up", SQL: m.UpSQL})
+	}
+	for v := current; v > target; v-- {
+		m := migrator.Migrations[v-1]
+		if m.DownSQL == "" {
+			return nil, fmt.Errorf("%w: %s", ErrIrreversible, m.Name)
+		}
+		steps = append(steps, MigrationStep{Version: m.Sequence, Name: m.Name, Direction: "down", SQL: m.DownSQL})
+	}
+	return steps, nil
+}
+
+func migrations(migrator *migrate.Migrator) []Migration {
+	res := make([]Migration, len(migrator.Migrations))
+	for i, m := range migrator.Migrations {
+		res[i] = Migration{Version: m.Sequence, Name: m.Name, UpSQL: m.UpSQL, DownSQL: m.DownSQL}
+	}
+	return res
+}
@@ -0,0 +1,44 @@
+package sqlstorage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateEmbeddedMigrations(t *testing.T) {
+	dir, err := EmbeddedMigrations()
+	require.NoError(t, err)
+
+	migrations, err := ValidateMigrations(context.Background(), dir)
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+	for i, m := range migrations {
+		require.Equal(t, int32(i+1), m.Version)
+	}
+}
+
+func TestCreateMigration(t *testing.T) {
+	dir := t.TempDir()
+	up := "CREATE TABLE t (id INT);\n\n" + migrationSeparator + "\n\ndrop table t;\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "001_create_t.sql"), []byte(up), 0o600))
+
+	path, err := CreateMigration(dir, "add_column")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "002_add_column.sql"), path)
+
+	// the scaffold has no SQL yet
+	_, err = ValidateMigrations(context.Background(), os.DirFS(dir))
+	require.ErrorIs(t, err, ErrInvalidMigration)
+
+	body := "ALTER TABLE t ADD COLUMN name TEXT;\n\n" + migrationSeparator + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(body), 0o600))
+	_, err = ValidateMigrations(context.Background(), os.DirFS(dir))
+	require.ErrorIs(t, err, ErrInvalidMigration, "the down migration is missing")
+
+	_, err = CreateMigration(dir, "Bad Name")
+	require.ErrorIs(t, err, ErrInvalidMigration)
+}
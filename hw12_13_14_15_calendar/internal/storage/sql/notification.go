@@ -0,0 +1,72 @@
+package sqlstorage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *Storage) SaveNotificationStatus(ctx context.Context, status *model.NotificationStatus) error {
+	updatedAt := status.UpdatedAt
+	if updatedAt.IsZero() {
+		updatedAt = time.Now()
+	}
+	_, err := s.Pool.Exec(ctx,
+		`
+INSERT INTO notification_statuses (notification_id, event_id, state, error, updated_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (notification_id) DO UPDATE
+SET state = EXCLUDED.state, error = EXCLUDED.error, updated_at = EXCLUDED.updated_at,
+    attempts = notification_statuses.attempts + 1`,
+		status.NotificationID, status.EventID, string(status.State), status.Error, updatedAt)
+	return err
+}
+
+func (s *Storage) GetNotificationStatus(ctx context.Context, notificationID string) (*model.NotificationStatus, error) {
+	row := s.Pool.QueryRow(ctx,
+		`
+SELECT notification_id, event_id, state, attempts, error, updated_at
+FROM notification_statuses WHERE notification_id = $1`,
+		notificationID)
+	status, err := scanNotificationStatus(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, model.ErrNotificationStatusNotFound
+	}
+	return status, err
+}
+
+func (s *Storage) ListNotificationStatuses(ctx context.Context, eventID string) ([]*model.NotificationStatus, error) {
+	rows, err := s.Pool.Query(ctx,
+		`
+SELECT notification_id, event_id, state, attempts, error, updated_at
+FROM notification_statuses WHERE event_id = $1 ORDER BY updated_at`,
+		eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	statuses := make([]*model.NotificationStatus, 0)
+	for rows.Next() {
+		status, err := scanNotificationStatus(rows)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, rows.Err()
+}
+
+func scanNotificationStatus(row pgx.Row) (*model.NotificationStatus, error) {
+	status := &model.NotificationStatus{}
+	var state string
+	err := row.Scan(&status.NotificationID, &status.EventID, &state, &status.Attempts, &status.Error, &status.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	status.State = model.NotificationState(state)
+	return status, nil
+}
@@ -0,0 +1,44 @@
+package sqlstorage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// EventChangesChannel is the NOTIFY channel carrying IDs of created, updated and removed events.
+const EventChangesChannel = "calendar_event_changes"
+
+// notifyEventChange signals listeners about the change. Inside a transaction
+// the notification is delivered on commit and dropped on rollback.
+func notifyEventChange(ctx context.Context, tx pgx.Tx, eventID string) error {
+	if _, err := tx.Exec(ctx, "SELECT pg_notify($1, $2)", EventChangesChannel, eventID); err != nil {
+		return fmt.Errorf("failed to notify event change: %w", err)
+	}
+	return nil
+}
+
+// ListenEventChanges calls the handler with IDs of changed events until ctx is done or the connection is lost.
+// It listens on a dedicated connection, as the pooled connections are used by queries.
+func (s *Storage) ListenEventChanges(ctx context.Context, handler func(eventID string)) error {
+	conn, err := pgx.Connect(ctx, s.dsn)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+EventChangesChannel); err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to wait for notification: %w", err)
+		}
+		handler(notification.Payload)
+	}
+}
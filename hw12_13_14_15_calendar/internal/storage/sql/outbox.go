@@ -0,0 +1,96 @@
+package sqlstorage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+)
+
+func (s *Storage) AddOutboxMessages(ctx context.Context, messages []*model.OutboxMessage) (int64, error) {
+	tx, err := s.Pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	var n int64
+	for _, m := range messages {
+		createdAt := m.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = time.Now()
+		}
+		res, err := tx.Exec(ctx,
+			`
+INSERT INTO outbox (id, idempotency_key, payload, created_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (idempotency_key) DO NOTHING`,
+			m.ID, m.IdempotencyKey, m.Payload, createdAt)
+		if err != nil {
+			return 0, fmt.Errorf("failed to insert outbox message: %w", err)
+		}
+		n += res.RowsAffected()
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return n, nil
+}
+
+func (s *Storage) FetchPendingOutboxMessages(ctx context.Context, limit int) ([]*model.OutboxMessage, error) {
+	rows, err := s.Pool.Query(ctx,
+		`
+SELECT id, idempotency_key, payload, created_at, attempts, last_error
+FROM outbox WHERE sent_at IS NULL ORDER BY created_at LIMIT $1`,
+		limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := make([]*model.OutboxMessage, 0)
+	for rows.Next() {
+		m := &model.OutboxMessage{}
+		if err := rows.Scan(&m.ID, &m.IdempotencyKey, &m.Payload, &m.CreatedAt, &m.Attempts, &m.LastError); err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+	return messages, rows.Err()
+}
+
+func (s *Storage) MarkOutboxMessageSent(ctx context.Context, id string, sentAt time.Time) error {
+	res, err := s.Pool.Exec(ctx,
+		`UPDATE outbox SET sent_at = $1, attempts = attempts + 1, last_error = '' WHERE id = $2`,
+		sentAt, id)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return model.ErrOutboxMessageNotFound
+	}
+	return nil
+}
+
+func (s *Storage) MarkOutboxMessageFailed(ctx context.Context, id string, reason string) error {
+	res, err := s.Pool.Exec(ctx,
+		`UPDATE outbox SET attempts = attempts + 1, last_error = $1 WHERE id = $2`,
+		reason, id)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return model.ErrOutboxMessageNotFound
+	}
+	return nil
+}
+
+func (s *Storage) DeleteSentOutboxMessagesOlderThan(ctx context.Context, threshold time.Time) (int64, error) {
+	res, err := s.Pool.Exec(ctx, `DELETE FROM outbox WHERE sent_at IS NOT NULL AND sent_at < $1`, threshold)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete sent outbox messages: %w", err)
+	}
+	return res.RowsAffected(), nil
+}
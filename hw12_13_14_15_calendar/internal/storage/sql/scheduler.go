@@ -0,0 +1,29 @@
+package sqlstorage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *Storage) GetSchedulerWatermark(ctx context.Context, name string) (time.Time, error) {
+	var watermark time.Time
+	err := s.Pool.QueryRow(ctx, "SELECT watermark FROM scheduler_state WHERE name = $1", name).Scan(&watermark)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, model.ErrWatermarkNotFound
+	}
+	return watermark, err
+}
+
+func (s *Storage) SaveSchedulerWatermark(ctx context.Context, name string, watermark time.Time) error {
+	_, err := s.Pool.Exec(ctx,
+		`
+INSERT INTO scheduler_state (name, watermark, updated_at)
+VALUES ($1, $2, now())
+ON CONFLICT (name) DO UPDATE SET watermark = EXCLUDED.watermark, updated_at = EXCLUDED.updated_at`,
+		name, watermark)
+	return err
+}
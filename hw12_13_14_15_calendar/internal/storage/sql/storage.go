@@ -2,36 +2,62 @@ package sqlstorage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var errNotConnected = errors.New("storage is not connected")
+
 func New(dsn string) *Storage {
 	return &Storage{
 		dsn: dsn,
 	}
 }
 
+// Storage keeps a pool of connections, so the API, the background workers and the health
+// probes can query it concurrently.
 type Storage struct {
 	dsn  string
-	Conn *pgx.Conn
+	Pool *pgxpool.Pool
 }
 
 func (s *Storage) CreateEvent(ctx context.Context, event *model.Event) error {
-	_, err := s.Conn.Exec(ctx,
+	tx, err := s.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	_, err = tx.Exec(ctx,
 		`
 INSERT INTO events (id, title, start_time, end_time, user_id, notify_delta) 
 VALUES ($1, $2, $3, $4, $5, $6)`,
 		event.ID, event.Title, event.StartTime, event.EndTime, event.UserID, event.NotifyDelta)
-	return err
+	if err != nil {
+		return err
+	}
+	if err := insertReminders(ctx, tx, event); err != nil {
+		return err
+	}
+	if err := notifyEventChange(ctx, tx, event.ID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
 func (s *Storage) UpdateEvent(ctx context.Context, event *model.Event) error {
-	// write an implementation for updating an event:
-	res, err := s.Conn.Exec(ctx,
+	tx, err := s.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	res, err := tx.Exec(ctx,
 		`
 UPDATE events SET title = $1, start_time = $2, end_time = $3, user_id = $4, notify_delta = $5 
 WHERE id = $6`,
@@ -42,11 +68,27 @@ WHERE id = $6`,
 	if res.RowsAffected() == 0 {
 		return model.ErrEventNotFound
 	}
-	return nil
+	// reminders are replaced as a whole
+	if _, err := tx.Exec(ctx, "DELETE FROM reminders WHERE event_id = $1", event.ID); err != nil {
+		return err
+	}
+	if err := insertReminders(ctx, tx, event); err != nil {
+		return err
+	}
+	if err := notifyEventChange(ctx, tx, event.ID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
 func (s *Storage) RemoveEvent(ctx context.Context, eventID string) error {
-	res, err := s.Conn.Exec(ctx,
+	tx, err := s.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	res, err := tx.Exec(ctx,
 		"DELETE FROM events WHERE id = $1",
 		eventID)
 	if err != nil {
@@ -55,22 +97,35 @@ func (s *Storage) RemoveEvent(ctx context.Context, eventID string) error {
 	if res.RowsAffected() == 0 {
 		return model.ErrEventNotFound
 	}
-	return nil
+	if err := notifyEventChange(ctx, tx, eventID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *Storage) GetEvent(ctx context.Context, eventID string) (*model.Event, error) {
+	events, err := s.queryEvents(ctx,
+		`
+SELECT id, title, start_time, end_time, user_id, notify_delta
+FROM events WHERE id = $1`,
+		eventID)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, model.ErrEventNotFound
+	}
+	return events[0], nil
 }
 
 func (s *Storage) FilterEventsByDay(ctx context.Context, date time.Time) ([]*model.Event, error) {
 	beginningOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
 	endOfDay := time.Date(date.Year(), date.Month(), date.Day(), 23, 59, 59, 0, time.Local)
-	rows, err := s.Conn.Query(ctx,
+	return s.queryEvents(ctx,
 		`
 SELECT id, title, start_time, end_time, user_id, notify_delta 
 FROM events WHERE start_time >= $1 AND start_time <= $2`,
 		beginningOfDay, endOfDay)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	return s.fetchRows(rows)
 }
 
 func (s *Storage) FilterEventsByWeek(ctx context.Context, weekStart time.Time) ([]*model.Event, error) {
@@ -81,21 +136,16 @@ func (s *Storage) FilterEventsByWeek(ctx context.Context, weekStart time.Time) (
 	endOfWeek := beginningOfWeek.AddDate(0, 0, 6)
 	endOfWeek = time.Date(endOfWeek.Year(), endOfWeek.Month(), endOfWeek.Day(), 23, 59, 59, 0, time.Local)
 
-	rows, err := s.Conn.Query(ctx,
+	return s.queryEvents(ctx,
 		`
 SELECT id, title, start_time, end_time, user_id, notify_delta 
 FROM events WHERE start_time >= $1 AND start_time <= $2`,
 		beginningOfWeek, endOfWeek)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	return s.fetchRows(rows)
 }
 
 func (s *Storage) DeleteEventsOlderThan(ctx context.Context, threshold time.Time) (int64, error) {
 	query := `DELETE FROM events WHERE start_time < $1`
-	result, err := s.Conn.Exec(ctx, query, threshold)
+	result, err := s.Pool.Exec(ctx, query, threshold)
 	if err != nil {
 		return 0, fmt.Errorf("failed to delete old events: %w", err)
 	}
@@ -110,16 +160,88 @@ func (s *Storage) FilterEventsByMonth(ctx context.Context, monthStart time.Time)
 	endOfMonth := beginningOfMonth.AddDate(0, 1, -1)
 	endOfMonth = time.Date(endOfMonth.Year(), endOfMonth.Month(), endOfMonth.Day(), 23, 59, 59, 0, time.Local)
 
-	rows, err := s.Conn.Query(ctx,
+	return s.queryEvents(ctx,
 		`
 SELECT id, title, start_time, end_time, user_id, notify_delta 
 FROM events WHERE start_time >= $1 AND start_time <= $2`,
 		beginningOfMonth, endOfMonth)
+}
+
+// FindEventsToNotify returns events having a reminder that fires within [from, to).
+// The events come with all their reminders, not only the due ones.
+func (s *Storage) FindEventsToNotify(ctx context.Context, from, to time.Time) ([]*model.Event, error) {
+	return s.queryEvents(ctx,
+		`
+SELECT id, title, start_time, end_time, user_id, notify_delta
+FROM events WHERE id IN (SELECT event_id FROM reminders WHERE notify_at >= $1 AND notify_at < $2)`,
+		from, to)
+}
+
+// queryEvents runs the query selecting events and loads their reminders.
+func (s *Storage) queryEvents(ctx context.Context, query string, args ...any) ([]*model.Event, error) {
+	rows, err := s.Pool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
+	events, err := s.fetchRows(rows)
+	// the connection is busy until the rows are closed
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadReminders(ctx, events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (s *Storage) loadReminders(ctx context.Context, events []*model.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	ids := make([]string, len(events))
+	byID := make(map[string]*model.Event, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
+		byID[event.ID] = event
+	}
+
+	rows, err := s.Pool.Query(ctx,
+		`
+SELECT event_id, offset_seconds, channel, message
+FROM reminders WHERE event_id = ANY($1) ORDER BY offset_seconds DESC`,
+		ids)
+	if err != nil {
+		return fmt.Errorf("failed to load reminders: %w", err)
+	}
 	defer rows.Close()
-	return s.fetchRows(rows)
+	for rows.Next() {
+		var eventID string
+		var offset int64
+		var reminder model.Reminder
+		if err := rows.Scan(&eventID, &offset, &reminder.Channel, &reminder.Message); err != nil {
+			return fmt.Errorf("failed to scan reminder: %w", err)
+		}
+		reminder.Offset = time.Duration(offset) * time.Second
+		if event, ok := byID[eventID]; ok {
+			event.Reminders = append(event.Reminders, reminder)
+		}
+	}
+	return rows.Err()
+}
+
+func insertReminders(ctx context.Context, tx pgx.Tx, event *model.Event) error {
+	for _, reminder := range event.Reminders {
+		_, err := tx.Exec(ctx,
+			`
+INSERT INTO reminders (event_id, event_start, offset_seconds, channel, message)
+VALUES ($1, $2, $3, $4, $5)`,
+			event.ID, event.StartTime, int64(reminder.Offset/time.Second), reminder.Channel, reminder.Message)
+		if err != nil {
+			return fmt.Errorf("failed to insert reminder: %w", err)
+		}
+	}
+	return nil
 }
 
 func (s *Storage) fetchRows(rows pgx.Rows) ([]*model.Event, error) {
@@ -141,24 +263,30 @@ func (s *Storage) fetchRows(rows pgx.Rows) ([]*model.Event, error) {
 }
 
 func (s *Storage) Connect(ctx context.Context) error {
-	conn, err := pgx.Connect(ctx, s.dsn)
+	pool, err := pgxpool.New(ctx, s.dsn)
 	if err != nil {
 		return err
 	}
-	if err = conn.Ping(ctx); err != nil {
+	if err = pool.Ping(ctx); err != nil {
+		pool.Close()
 		return err
 	}
-	s.Conn = conn
+	s.Pool = pool
 	return nil
 }
 
-func (s *Storage) Close(ctx context.Context) error {
-	if s.Conn != nil {
-		err := s.Conn.Close(ctx)
-		if err != nil {
-			return err
-		}
-		s.Conn = nil
+// Ping checks the connection to the database on a connection of its own from the pool.
+func (s *Storage) Ping(ctx context.Context) error {
+	if s.Pool == nil {
+		return errNotConnected
+	}
+	return s.Pool.Ping(ctx)
+}
+
+func (s *Storage) Close(_ context.Context) error {
+	if s.Pool != nil {
+		s.Pool.Close()
+		s.Pool = nil
 	}
 	return nil
 }
@@ -2,28 +2,20 @@ package sqlstorage
 
 import (
 	"context"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/pgtest"
 	"testing"
 	"time"
 
 	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/require"
-	"github.com/testcontainers/testcontainers-go"
-	"github.com/testcontainers/testcontainers-go/modules/postgres"
-	"github.com/testcontainers/testcontainers-go/wait"
-)
-
-const (
-	testDBName     = "test-db"
-	testDBUser     = "postgres"
-	testDBPassword = "postgres"
-	testDBImage    = "postgres:16"
 )
 
 func TestCreate(t *testing.T) {
 	ctx := context.Background()
-	connStr, err := createPostgresContainer(ctx, t)
+	connStr, err := pgtest.CreatePostgresContainer(ctx, t)
 	require.NoError(t, err)
 	s := createStorage(t, connStr)
 
@@ -40,7 +32,7 @@ func TestCreate(t *testing.T) {
 	err = s.CreateEvent(context.TODO(), &event)
 	require.NoError(t, err)
 	// check that event was created
-	row := s.Conn.QueryRow(
+	row := s.Pool.QueryRow(
 		context.TODO(),
 		"SELECT id, title, start_time, end_time, user_id, notify_delta FROM events WHERE id = $1",
 		event.ID,
@@ -51,7 +43,7 @@ func TestCreate(t *testing.T) {
 
 func TestUpdate(t *testing.T) {
 	ctx := context.Background()
-	connStr, err := createPostgresContainer(ctx, t)
+	connStr, err := pgtest.CreatePostgresContainer(ctx, t)
 	require.NoError(t, err)
 
 	s := createStorage(t, connStr)
@@ -60,7 +52,7 @@ func TestUpdate(t *testing.T) {
 	require.NoError(t, err)
 
 	// insert sample event
-	uid, err := createTestEvent(s.Conn)
+	uid, err := createTestEvent(s.Pool)
 	require.NoError(t, err)
 
 	// update event
@@ -76,7 +68,7 @@ func TestUpdate(t *testing.T) {
 	require.NoError(t, err)
 
 	// check that event was updated
-	row := s.Conn.QueryRow(
+	row := s.Pool.QueryRow(
 		context.TODO(),
 		`
 SELECT id, title, start_time, end_time, user_id, notify_delta FROM events
@@ -89,7 +81,7 @@ WHERE id = $1`,
 
 func TestRemove(t *testing.T) {
 	ctx := context.Background()
-	connStr, err := createPostgresContainer(ctx, t)
+	connStr, err := pgtest.CreatePostgresContainer(ctx, t)
 	require.NoError(t, err)
 	s := createStorage(t, connStr)
 
@@ -97,7 +89,7 @@ func TestRemove(t *testing.T) {
 	require.NoError(t, err, "migration failed")
 
 	// insert sample event
-	uid, err := createTestEvent(s.Conn)
+	uid, err := createTestEvent(s.Pool)
 	require.NoError(t, err)
 
 	// remove event
@@ -105,7 +97,7 @@ func TestRemove(t *testing.T) {
 	require.NoError(t, err)
 
 	// check that event was removed
-	row := s.Conn.QueryRow(
+	row := s.Pool.QueryRow(
 		context.TODO(),
 		"SELECT count(*) FROM events WHERE id = $1",
 		uid,
@@ -117,7 +109,7 @@ func TestRemove(t *testing.T) {
 
 func TestFilterEventsByDay(t *testing.T) {
 	ctx := context.Background()
-	connStr, err := createPostgresContainer(ctx, t)
+	connStr, err := pgtest.CreatePostgresContainer(ctx, t)
 	require.NoError(t, err)
 	s := createStorage(t, connStr)
 
@@ -137,7 +129,7 @@ func TestFilterEventsByDay(t *testing.T) {
 
 func TestFilterEventsByWeek(t *testing.T) {
 	ctx := context.Background()
-	connStr, err := createPostgresContainer(ctx, t)
+	connStr, err := pgtest.CreatePostgresContainer(ctx, t)
 	require.NoError(t, err)
 	s := createStorage(t, connStr)
 
@@ -158,7 +150,7 @@ func TestFilterEventsByWeek(t *testing.T) {
 
 func TestFilterEventsByMonth(t *testing.T) {
 	ctx := context.Background()
-	connStr, err := createPostgresContainer(ctx, t)
+	connStr, err := pgtest.CreatePostgresContainer(ctx, t)
 	require.NoError(t, err)
 	s := createStorage(t, connStr)
 	migrateDB(ctx, t, s)
@@ -167,7 +159,7 @@ func TestFilterEventsByMonth(t *testing.T) {
 	// define test data
 	testData, monthStart := FilterEventsByMonthFixture()
 	for _, event := range testData {
-		err = InsertEvent(s.Conn, event.ID, event.Title, event.StartTime, event.EndTime, event.UserID, event.NotifyDelta)
+		err = InsertEvent(s.Pool, event.ID, event.Title, event.StartTime, event.EndTime, event.UserID, event.NotifyDelta)
 		require.NoError(t, err)
 	}
 
@@ -180,6 +172,250 @@ func TestFilterEventsByMonth(t *testing.T) {
 	compareEvents(t, testData[:2], events)
 }
 
+func TestReminders(t *testing.T) {
+	ctx := context.Background()
+	connStr, err := pgtest.CreatePostgresContainer(ctx, t)
+	require.NoError(t, err)
+	s := createStorage(t, connStr)
+	migrateDB(ctx, t, s)
+
+	testData, date := FilterEventsByDateFixture()
+	event := testData[0]
+	event.Reminders = []model.Reminder{
+		{Offset: time.Hour, Channel: "email"},
+		{Offset: 10 * time.Minute, Channel: "webhook", Message: "starting soon"},
+	}
+	require.NoError(t, s.CreateEvent(ctx, event))
+
+	events, err := s.FilterEventsByDay(ctx, date)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, event.Reminders, events[0].Reminders)
+
+	// reminders are replaced on update
+	event.Reminders = event.Reminders[1:]
+	require.NoError(t, s.UpdateEvent(ctx, event))
+
+	events, err = s.FilterEventsByDay(ctx, date)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, event.Reminders, events[0].Reminders)
+}
+
+func TestFindEventsToNotify(t *testing.T) {
+	ctx := context.Background()
+	connStr, err := pgtest.CreatePostgresContainer(ctx, t)
+	require.NoError(t, err)
+	s := createStorage(t, connStr)
+	migrateDB(ctx, t, s)
+
+	midnight := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	events := []*model.Event{
+		// the reminder fires the day before the event
+		{
+			ID: uuid.NewString(), StartTime: midnight.Add(30 * time.Minute), EndTime: midnight.Add(time.Hour),
+			Reminders: []model.Reminder{{Offset: time.Hour}},
+		},
+		{
+			ID: uuid.NewString(), StartTime: midnight.Add(time.Hour), EndTime: midnight.Add(2 * time.Hour),
+			Reminders: []model.Reminder{{Offset: 10 * time.Minute}},
+		},
+	}
+	for _, event := range events {
+		require.NoError(t, s.CreateEvent(ctx, event))
+	}
+
+	found, err := s.FindEventsToNotify(ctx, midnight.Add(-time.Hour), midnight)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	require.Equal(t, events[0].ID, found[0].ID)
+
+	// notify time follows the start of the event
+	events[1].StartTime = midnight.Add(5 * time.Minute)
+	require.NoError(t, s.UpdateEvent(ctx, events[1]))
+	found, err = s.FindEventsToNotify(ctx, midnight.Add(-time.Hour), midnight)
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+}
+
+func TestListenEventChanges(t *testing.T) {
+	ctx := context.Background()
+	connStr, err := pgtest.CreatePostgresContainer(ctx, t)
+	require.NoError(t, err)
+	s := createStorage(t, connStr)
+	migrateDB(ctx, t, s)
+
+	listenCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	changes := make(chan string, 10)
+	go func() {
+		_ = s.ListenEventChanges(listenCtx, func(eventID string) {
+			changes <- eventID
+		})
+	}()
+
+	// the listener connects asynchronously, repeat the change until it is seen
+	testData, _ := FilterEventsByDateFixture()
+	event := testData[0]
+	require.NoError(t, s.CreateEvent(ctx, event))
+	require.Eventually(t, func() bool {
+		require.NoError(t, s.UpdateEvent(ctx, event))
+		select {
+		case eventID := <-changes:
+			return eventID == event.ID
+		case <-time.After(100 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestArchive(t *testing.T) {
+	ctx := context.Background()
+	connStr, err := pgtest.CreatePostgresContainer(ctx, t)
+	require.NoError(t, err)
+	s := createStorage(t, connStr)
+	migrateDB(ctx, t, s)
+
+	now := time.Now().Truncate(time.Second)
+	old := &model.Event{
+		ID: uuid.NewString(), Title: "Quarterly 100% review", UserID: "user-1",
+		StartTime: now.AddDate(0, 0, -40), EndTime: now.AddDate(0, 0, -40).Add(time.Hour),
+		Reminders: []model.Reminder{{Offset: time.Hour, Channel: "email", Message: "prepare"}},
+	}
+	recent := &model.Event{
+		ID: uuid.NewString(), Title: "Retro", UserID: "user-1",
+		StartTime: now.AddDate(0, 0, -5), EndTime: now.AddDate(0, 0, -5).Add(time.Hour),
+	}
+	kept := &model.Event{
+		ID: uuid.NewString(), Title: "Audit", UserID: "user-2",
+		StartTime: now.AddDate(0, 0, -400), EndTime: now.AddDate(0, 0, -400).Add(time.Hour),
+	}
+	for _, event := range []*model.Event{old, recent, kept} {
+		require.NoError(t, s.CreateEvent(ctx, event))
+	}
+
+	// user-2 keeps everything
+	archived, err := s.ArchiveEvents(ctx, model.Retention{
+		Before: now.AddDate(0, 0, -30),
+		Users:  map[string]time.Time{"user-2": {}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), archived)
+	var count int
+	require.NoError(t, s.Pool.QueryRow(ctx, "SELECT count(*) FROM reminders WHERE event_id = $1", old.ID).Scan(&count))
+	require.Zero(t, count)
+
+	found, err := s.SearchArchivedEvents(ctx, model.ArchiveFilter{UserID: "user-1", Title: "100% REVIEW"})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	require.Equal(t, old.ID, found[0].ID)
+	require.Equal(t, old.Reminders, found[0].Reminders)
+	found, err = s.SearchArchivedEvents(ctx, model.ArchiveFilter{Title: "100_"})
+	require.NoError(t, err)
+	require.Empty(t, found)
+
+	restored, err := s.RestoreArchivedEvent(ctx, old.ID)
+	require.NoError(t, err)
+	require.Equal(t, old.Reminders, restored.Reminders)
+	toNotify, err := s.FindEventsToNotify(ctx, old.StartTime.Add(-time.Hour), old.StartTime)
+	require.NoError(t, err)
+	require.Len(t, toNotify, 1)
+
+	_, err = s.RestoreArchivedEvent(ctx, old.ID)
+	require.ErrorIs(t, err, model.ErrArchivedEventNotFound)
+}
+
+func TestOutbox(t *testing.T) {
+	ctx := context.Background()
+	connStr, err := pgtest.CreatePostgresContainer(ctx, t)
+	require.NoError(t, err)
+	s := createStorage(t, connStr)
+	migrateDB(ctx, t, s)
+
+	key := uuid.NewString()
+	n, err := s.AddOutboxMessages(ctx, []*model.OutboxMessage{
+		{ID: uuid.NewString(), IdempotencyKey: key, Payload: []byte(`{}`)},
+		{ID: uuid.NewString(), IdempotencyKey: key, Payload: []byte(`{}`)},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), n)
+
+	pending, err := s.FetchPendingOutboxMessages(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, key, pending[0].IdempotencyKey)
+
+	err = s.MarkOutboxMessageSent(ctx, pending[0].ID, time.Now())
+	require.NoError(t, err)
+
+	pending, err = s.FetchPendingOutboxMessages(ctx, 10)
+	require.NoError(t, err)
+	require.Empty(t, pending)
+}
+
+func TestWebhooks(t *testing.T) {
+	ctx := context.Background()
+	connStr, err := pgtest.CreatePostgresContainer(ctx, t)
+	require.NoError(t, err)
+	s := createStorage(t, connStr)
+	migrateDB(ctx, t, s)
+
+	subscription := &model.WebhookSubscription{
+		ID:         uuid.NewString(),
+		UserID:     uuid.NewString(),
+		URL:        "http://example.com/hook",
+		Secret:     "s3cr3t",
+		EventTypes: []string{"event.created", "event.deleted"},
+	}
+	require.NoError(t, s.CreateWebhookSubscription(ctx, subscription))
+
+	subscriptions, err := s.ListWebhookSubscriptions(ctx, subscription.UserID)
+	require.NoError(t, err)
+	require.Len(t, subscriptions, 1)
+	require.Equal(t, subscription.EventTypes, subscriptions[0].EventTypes)
+
+	delivery := &model.WebhookDelivery{
+		ID:             uuid.NewString(),
+		SubscriptionID: subscription.ID,
+		EventType:      "event.created",
+		Payload:        []byte(`{}`),
+		State:          model.WebhookDeliveryPending,
+	}
+	require.NoError(t, s.SaveWebhookDelivery(ctx, delivery))
+	pending, err := s.ClaimPendingWebhookDeliveries(ctx, "a", time.Now().Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, delivery.ID, pending[0].ID)
+	require.Equal(t, "a", pending[0].ClaimedBy)
+	// a claimed delivery is left to its owner until the claim expires
+	pending, err = s.ClaimPendingWebhookDeliveries(ctx, "b", time.Now().Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Empty(t, pending)
+	err = s.RenewWebhookDeliveryClaim(ctx, delivery.ID, "b", time.Now().Add(time.Minute))
+	require.ErrorIs(t, err, model.ErrWebhookDeliveryNotClaimed)
+	require.NoError(t, s.RenewWebhookDeliveryClaim(ctx, delivery.ID, "a", time.Now().Add(-time.Second)))
+	pending, err = s.ClaimPendingWebhookDeliveries(ctx, "b", time.Now().Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	delivery.State = model.WebhookDeliveryDelivered
+	delivery.Attempts = 1
+	delivery.StatusCode = 200
+	require.NoError(t, s.SaveWebhookDelivery(ctx, delivery))
+
+	deliveries, err := s.ListWebhookDeliveries(ctx, subscription.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, model.WebhookDeliveryDelivered, deliveries[0].State)
+	require.Equal(t, 200, deliveries[0].StatusCode)
+	err = s.RenewWebhookDeliveryClaim(ctx, delivery.ID, "b", time.Now().Add(time.Minute))
+	require.ErrorIs(t, err, model.ErrWebhookDeliveryNotClaimed)
+
+	// deliveries are removed together with the subscription
+	require.NoError(t, s.DeleteWebhookSubscription(ctx, subscription.ID))
+	_, err = s.GetWebhookDelivery(ctx, delivery.ID)
+	require.ErrorIs(t, err, model.ErrWebhookDeliveryNotFound)
+}
+
 func compareEvents(t *testing.T, expected []*model.Event, actual []*model.Event) {
 	t.Helper()
 	require.Len(t, actual, len(expected))
@@ -193,7 +429,7 @@ func compareEvents(t *testing.T, expected []*model.Event, actual []*model.Event)
 	}
 }
 
-func createTestEvent(conn *pgx.Conn) (string, error) {
+func createTestEvent(conn *pgxpool.Pool) (string, error) {
 	uid := uuid.NewString()
 	err := InsertEvent(conn, uid, "Kickoff meeting", time.Now(), time.Now().Add(time.Hour), uuid.NewString(), 10)
 	return uid, err
@@ -221,33 +457,6 @@ func migrateDB(ctx context.Context, t *testing.T, s *Storage) {
 	require.NoError(t, err)
 }
 
-func createPostgresContainer(ctx context.Context, t *testing.T) (string, error) {
-	t.Helper()
-	pgContainer, err := postgres.Run(ctx,
-		testDBImage,
-		postgres.WithDatabase(testDBName),
-		postgres.WithUsername(testDBUser),
-		postgres.WithPassword(testDBPassword),
-		testcontainers.WithWaitStrategy(
-			wait.ForLog("database system is ready to accept connections").
-				WithOccurrence(2).WithStartupTimeout(5*time.Second)),
-	)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	t.Cleanup(func() {
-		if err := pgContainer.Terminate(ctx); err != nil {
-			t.Fatalf("failed to terminate pgContainer: %s", err)
-		}
-	})
-	connStr, err := pgContainer.ConnectionString(ctx, "sslmode=disable")
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
-	return connStr, err
-}
-
 func createStorage(t *testing.T, connStr string) *Storage {
 	t.Helper()
 	s := New(connStr)
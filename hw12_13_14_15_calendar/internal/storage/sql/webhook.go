@@ -0,0 +1,202 @@
+package sqlstorage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *Storage) CreateWebhookSubscription(ctx context.Context, subscription *model.WebhookSubscription) error {
+	createdAt := subscription.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	eventTypes := subscription.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+	_, err := s.Pool.Exec(ctx,
+		`
+INSERT INTO webhook_subscriptions (id, user_id, url, secret, event_types, created_at)
+VALUES ($1, $2, $3, $4, $5, $6)`,
+		subscription.ID, subscription.UserID, subscription.URL, subscription.Secret, eventTypes, createdAt)
+	return err
+}
+
+func (s *Storage) GetWebhookSubscription(ctx context.Context, id string) (*model.WebhookSubscription, error) {
+	row := s.Pool.QueryRow(ctx,
+		`
+SELECT id, user_id, url, secret, event_types, created_at
+FROM webhook_subscriptions WHERE id = $1`,
+		id)
+	subscription, err := scanWebhookSubscription(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, model.ErrWebhookNotFound
+	}
+	return subscription, err
+}
+
+func (s *Storage) ListWebhookSubscriptions(ctx context.Context, userID string) ([]*model.WebhookSubscription, error) {
+	rows, err := s.Pool.Query(ctx,
+		`
+SELECT id, user_id, url, secret, event_types, created_at
+FROM webhook_subscriptions WHERE $1 = '' OR user_id = $1 ORDER BY created_at`,
+		userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := make([]*model.WebhookSubscription, 0)
+	for rows.Next() {
+		subscription, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	return subscriptions, rows.Err()
+}
+
+func (s *Storage) DeleteWebhookSubscription(ctx context.Context, id string) error {
+	res, err := s.Pool.Exec(ctx, "DELETE FROM webhook_subscriptions WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return model.ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (s *Storage) SaveWebhookDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	createdAt := delivery.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	_, err := s.Pool.Exec(ctx,
+		`
+INSERT INTO webhook_deliveries
+    (id, subscription_id, event_type, payload, state, attempts, status_code, error, created_at, updated_at,
+     claimed_by, claimed_until)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, now(), $10, $11)
+ON CONFLICT (id) DO UPDATE
+SET state = EXCLUDED.state, attempts = EXCLUDED.attempts, status_code = EXCLUDED.status_code,
+    error = EXCLUDED.error, updated_at = EXCLUDED.updated_at,
+    claimed_by = EXCLUDED.claimed_by, claimed_until = EXCLUDED.claimed_until`,
+		delivery.ID, delivery.SubscriptionID, delivery.EventType, delivery.Payload, string(delivery.State),
+		delivery.Attempts, delivery.StatusCode, delivery.Error, createdAt, delivery.ClaimedBy, delivery.ClaimedUntil)
+	return err
+}
+
+func (s *Storage) GetWebhookDelivery(ctx context.Context, id string) (*model.WebhookDelivery, error) {
+	row := s.Pool.QueryRow(ctx,
+		`
+SELECT id, subscription_id, event_type, payload, state, attempts, status_code, error, created_at, updated_at,
+       claimed_by, claimed_until
+FROM webhook_deliveries WHERE id = $1`,
+		id)
+	delivery, err := scanWebhookDelivery(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, model.ErrWebhookDeliveryNotFound
+	}
+	return delivery, err
+}
+
+func (s *Storage) ListWebhookDeliveries(
+	ctx context.Context, subscriptionID string, limit int,
+) ([]*model.WebhookDelivery, error) {
+	rows, err := s.Pool.Query(ctx,
+		`
+SELECT id, subscription_id, event_type, payload, state, attempts, status_code, error, created_at, updated_at,
+       claimed_by, claimed_until
+FROM webhook_deliveries WHERE subscription_id = $1 ORDER BY created_at DESC LIMIT $2`,
+		subscriptionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]*model.WebhookDelivery, 0)
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// ClaimPendingWebhookDeliveries skips the deliveries locked by a concurrent claim,
+// so dispatchers claiming at once get different deliveries.
+func (s *Storage) ClaimPendingWebhookDeliveries(
+	ctx context.Context, owner string, until time.Time, limit int,
+) ([]*model.WebhookDelivery, error) {
+	rows, err := s.Pool.Query(ctx,
+		`
+WITH claimed AS (
+    UPDATE webhook_deliveries SET claimed_by = $1, claimed_until = $2
+    WHERE id IN (SELECT id FROM webhook_deliveries
+                 WHERE state = $3 AND claimed_until <= now()
+                 ORDER BY created_at
+                 LIMIT $4 FOR UPDATE SKIP LOCKED)
+    RETURNING id, subscription_id, event_type, payload, state, attempts, status_code, error, created_at, updated_at,
+              claimed_by, claimed_until
+)
+SELECT * FROM claimed ORDER BY created_at`,
+		owner, until, string(model.WebhookDeliveryPending), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]*model.WebhookDelivery, 0)
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+func (s *Storage) RenewWebhookDeliveryClaim(ctx context.Context, id, owner string, until time.Time) error {
+	res, err := s.Pool.Exec(ctx,
+		"UPDATE webhook_deliveries SET claimed_until = $3 WHERE id = $1 AND claimed_by = $2 AND state = $4",
+		id, owner, until, string(model.WebhookDeliveryPending))
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return model.ErrWebhookDeliveryNotClaimed
+	}
+	return nil
+}
+
+func scanWebhookSubscription(row pgx.Row) (*model.WebhookSubscription, error) {
+	subscription := &model.WebhookSubscription{}
+	err := row.Scan(&subscription.ID, &subscription.UserID, &subscription.URL, &subscription.Secret,
+		&subscription.EventTypes, &subscription.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func scanWebhookDelivery(row pgx.Row) (*model.WebhookDelivery, error) {
+	delivery := &model.WebhookDelivery{}
+	var state string
+	err := row.Scan(&delivery.ID, &delivery.SubscriptionID, &delivery.EventType, &delivery.Payload, &state,
+		&delivery.Attempts, &delivery.StatusCode, &delivery.Error, &delivery.CreatedAt, &delivery.UpdatedAt,
+		&delivery.ClaimedBy, &delivery.ClaimedUntil)
+	if err != nil {
+		return nil, err
+	}
+	delivery.State = model.WebhookDeliveryState(state)
+	return delivery, nil
+}
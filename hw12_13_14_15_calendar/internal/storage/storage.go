@@ -17,23 +17,68 @@ type Storage interface {
 	CreateEvent(ctx context.Context, event *model.Event) error
 	UpdateEvent(ctx context.Context, event *model.Event) error
 	RemoveEvent(ctx context.Context, eventID string) error
+	GetEvent(ctx context.Context, eventID string) (*model.Event, error)
 	FilterEventsByDay(ctx context.Context, date time.Time) ([]*model.Event, error)
 	FilterEventsByWeek(ctx context.Context, weekStart time.Time) ([]*model.Event, error)
 	FilterEventsByMonth(ctx context.Context, monthStart time.Time) ([]*model.Event, error)
 	DeleteEventsOlderThan(ctx context.Context, threshold time.Time) (int64, error)
+	FindEventsToNotify(ctx context.Context, from, to time.Time) ([]*model.Event, error)
+	ListenEventChanges(ctx context.Context, handler func(eventID string)) error
+	// Ping checks that the storage is reachable, it is used by the readiness checks.
+	Ping(ctx context.Context) error
+
+	// archive
+	ArchiveEvents(ctx context.Context, retention model.Retention) (int64, error)
+	SearchArchivedEvents(ctx context.Context, filter model.ArchiveFilter) ([]*model.ArchivedEvent, error)
+	RestoreArchivedEvent(ctx context.Context, id string) (*model.Event, error)
+
+	// outbox
+	AddOutboxMessages(ctx context.Context, messages []*model.OutboxMessage) (int64, error)
+	FetchPendingOutboxMessages(ctx context.Context, limit int) ([]*model.OutboxMessage, error)
+	MarkOutboxMessageSent(ctx context.Context, id string, sentAt time.Time) error
+	MarkOutboxMessageFailed(ctx context.Context, id string, reason string) error
+	DeleteSentOutboxMessagesOlderThan(ctx context.Context, threshold time.Time) (int64, error)
+
+	// notification statuses
+	SaveNotificationStatus(ctx context.Context, status *model.NotificationStatus) error
+	GetNotificationStatus(ctx context.Context, notificationID string) (*model.NotificationStatus, error)
+	ListNotificationStatuses(ctx context.Context, eventID string) ([]*model.NotificationStatus, error)
+
+	// scheduler state
+	GetSchedulerWatermark(ctx context.Context, name string) (time.Time, error)
+	SaveSchedulerWatermark(ctx context.Context, name string, watermark time.Time) error
+
+	// webhooks
+	CreateWebhookSubscription(ctx context.Context, subscription *model.WebhookSubscription) error
+	GetWebhookSubscription(ctx context.Context, id string) (*model.WebhookSubscription, error)
+	ListWebhookSubscriptions(ctx context.Context, userID string) ([]*model.WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, id string) error
+	SaveWebhookDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+	GetWebhookDelivery(ctx context.Context, id string) (*model.WebhookDelivery, error)
+	ListWebhookDeliveries(ctx context.Context, subscriptionID string, limit int) ([]*model.WebhookDelivery, error)
+	// ClaimPendingWebhookDeliveries claims up to limit pending deliveries, whose claim has expired,
+	// for the owner until the time and returns them, the oldest first.
+	ClaimPendingWebhookDeliveries(
+		ctx context.Context, owner string, until time.Time, limit int,
+	) ([]*model.WebhookDelivery, error)
+	// RenewWebhookDeliveryClaim extends the claim of the owner, it fails with
+	// model.ErrWebhookDeliveryNotClaimed if the delivery is no longer pending or another owner has claimed it.
+	RenewWebhookDeliveryClaim(ctx context.Context, id, owner string, until time.Time) error
 }
 
+// NewFromConfig creates the storage selected by conf.Type, its operations are traced
+// and recorded in the storage metrics.
 func NewFromConfig(conf *conf.StorageConf) (Storage, func(ctx context.Context) error, error) {
 	switch conf.Type {
 	case "inmemory":
-		return memorystorage.New(), nil, nil
+		return instrumented{memorystorage.New()}, nil, nil
 	case "sql":
 		timeout := context.Background()
 		pgStorage := sqlstorage.New(conf.DSN)
 		if err := pgStorage.Connect(timeout); err != nil {
 			return nil, nil, err
 		}
-		return pgStorage, pgStorage.Close, nil
+		return instrumented{pgStorage}, pgStorage.Close, nil
 	default:
 		return nil, nil, ErrUnknownStorageType
 	}
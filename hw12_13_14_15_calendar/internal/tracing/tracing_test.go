@@ -0,0 +1,50 @@
+package tracing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestPropagation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.json")
+	shutdown, err := Setup(context.Background(), &conf.TracingConf{
+		Exporter: "file", Path: path, ServiceName: "test", SampleRatio: 1,
+	})
+	require.NoError(t, err)
+
+	ctx, span := otel.Tracer("test").Start(context.Background(), "scheduler.publish")
+	headers := Inject(ctx)
+	require.Contains(t, headers, "traceparent")
+	span.End()
+
+	// the consumer continues the trace of the publisher
+	consumerCtx := Extract(context.Background(), headers)
+	_, child := otel.Tracer("test").Start(consumerCtx, "sender.handle")
+	require.Equal(t, span.SpanContext().TraceID(), child.SpanContext().TraceID())
+	End(child, nil)
+
+	require.NoError(t, shutdown(context.Background()))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "scheduler.publish")
+	require.Contains(t, string(data), "sender.handle")
+
+	require.Nil(t, Inject(context.Background()))
+	require.False(t, trace.SpanContextFromContext(Extract(context.Background(), nil)).IsValid())
+}
+
+func TestSetupErrors(t *testing.T) {
+	_, err := Setup(context.Background(), &conf.TracingConf{Exporter: "zipkin"})
+	require.ErrorIs(t, err, ErrUnknownExporter)
+
+	shutdown, err := Setup(context.Background(), &conf.TracingConf{Exporter: "none"})
+	require.NoError(t, err)
+	require.NoError(t, shutdown(context.Background()))
+}
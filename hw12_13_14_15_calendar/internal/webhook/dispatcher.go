@@ -0,0 +1,418 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/signature"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+	"github.com/google/uuid"
+)
+
+const (
+	DeliveryHeader = "X-Calendar-Delivery"
+	EventHeader    = "X-Calendar-Event"
+
+	defaultWorkers        = 4
+	defaultMaxAttempts    = 5
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = time.Minute
+	defaultTimeout        = 10 * time.Second
+	queueSize             = 1000
+	saveTimeout           = 10 * time.Second
+	resumeInterval        = time.Minute
+	// claimLease is how long a dispatcher owns a pending delivery without renewing the claim,
+	// after that the resume pass of any dispatcher may take it over
+	claimLease = 5 * time.Minute
+)
+
+type Logger interface {
+	InfoContext(ctx context.Context, msg string, args ...any)
+	ErrorContext(ctx context.Context, msg string, args ...any)
+}
+
+type job struct {
+	delivery     *model.WebhookDelivery
+	subscription *model.WebhookSubscription
+}
+
+// Dispatcher delivers payloads to matching subscriptions in the background.
+// Every delivery is recorded in the storage before it is sent, failed attempts are retried
+// with exponential backoff until MaxAttempts is reached, then the delivery is marked failed
+// and can be replayed. Deliveries left pending by a restart or an overflow of the queue
+// are resumed from the log. The API and every scheduler run a dispatcher, a pending delivery
+// is claimed in the storage by the one delivering it, so the others do not resume it.
+type Dispatcher struct {
+	// id is the owner of the claims of the dispatcher
+	id             string
+	storage        storage.Storage
+	logger         Logger
+	client         *http.Client
+	workers        int
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+
+	queue chan job
+	// resume wakes up the resume pass, which queues the pending deliveries of the log
+	resume chan struct{}
+	// inflight holds the IDs of the queued deliveries and of the ones being delivered
+	mu       sync.Mutex
+	inflight map[string]struct{}
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewDispatcher(s storage.Storage, logger Logger, config *conf.WebhooksConf) *Dispatcher {
+	d := &Dispatcher{
+		id:             uuid.NewString(),
+		storage:        s,
+		logger:         logger,
+		client:         &http.Client{Timeout: seconds(config.Timeout, defaultTimeout)},
+		workers:        config.Workers,
+		maxAttempts:    config.MaxAttempts,
+		initialBackoff: seconds(config.InitialBackoff, defaultInitialBackoff),
+		maxBackoff:     seconds(config.MaxBackoff, defaultMaxBackoff),
+		queue:          make(chan job, queueSize),
+		resume:         make(chan struct{}, 1),
+		inflight:       make(map[string]struct{}),
+	}
+	if d.workers <= 0 {
+		d.workers = defaultWorkers
+	}
+	if d.maxAttempts <= 0 {
+		d.maxAttempts = defaultMaxAttempts
+	}
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+	return d
+}
+
+func seconds(value int, fallback time.Duration) time.Duration {
+	if value <= 0 {
+		return fallback
+	}
+	return time.Duration(value) * time.Second
+}
+
+// Start runs the delivery workers and the resume pass until Stop is called.
+func (d *Dispatcher) Start() {
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		d.resumeLoop()
+	}()
+	for i := 0; i < d.workers; i++ {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			for {
+				select {
+				case <-d.ctx.Done():
+					return
+				case j := <-d.queue:
+					d.deliver(j)
+				}
+			}
+		}()
+	}
+}
+
+// Stop interrupts the workers and waits for them to exit.
+// Deliveries that were not finished stay pending in the log.
+func (d *Dispatcher) Stop() {
+	d.cancel()
+	d.wg.Wait()
+}
+
+// Publish sends the payload to every subscription that matches the type and the owner of the change.
+// It is safe to call on a nil Dispatcher, which means webhooks are disabled.
+// Errors are only logged, as the change itself has already been made.
+func (d *Dispatcher) Publish(ctx context.Context, eventType, userID string, data any) {
+	if d == nil {
+		return
+	}
+	subscriptions, err := d.storage.ListWebhookSubscriptions(ctx, "")
+	if err != nil {
+		d.logger.ErrorContext(ctx, "failed to list webhook subscriptions", "error", err)
+		return
+	}
+
+	var body []byte
+	for _, subscription := range subscriptions {
+		if !matches(subscription, eventType, userID) {
+			continue
+		}
+		if body == nil {
+			body, err = json.Marshal(&Payload{
+				ID:         uuid.NewString(),
+				Type:       eventType,
+				OccurredAt: time.Now(),
+				Data:       data,
+			})
+			if err != nil {
+				d.logger.ErrorContext(ctx, "failed to marshal webhook payload", "event_type", eventType, "error", err)
+				return
+			}
+		}
+		delivery := &model.WebhookDelivery{
+			ID:             uuid.NewString(),
+			SubscriptionID: subscription.ID,
+			EventType:      eventType,
+			Payload:        body,
+			State:          model.WebhookDeliveryPending,
+			CreatedAt:      time.Now(),
+			ClaimedBy:      d.id,
+			ClaimedUntil:   time.Now().Add(claimLease),
+		}
+		if err := d.storage.SaveWebhookDelivery(ctx, delivery); err != nil {
+			d.logger.ErrorContext(ctx, "failed to save webhook delivery",
+				"subscription_id", subscription.ID, "event_type", eventType, "error", err)
+			continue
+		}
+		d.enqueue(ctx, delivery, subscription)
+	}
+}
+
+// Replay sends the logged delivery once more, with a fresh set of attempts.
+func (d *Dispatcher) Replay(ctx context.Context, deliveryID string) (*model.WebhookDelivery, error) {
+	delivery, err := d.storage.GetWebhookDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	subscription, err := d.storage.GetWebhookSubscription(ctx, delivery.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	delivery.State = model.WebhookDeliveryPending
+	delivery.Error = ""
+	delivery.ClaimedBy = d.id
+	delivery.ClaimedUntil = time.Now().Add(claimLease)
+	if err := d.storage.SaveWebhookDelivery(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("failed to save webhook delivery: %w", err)
+	}
+	res := *delivery
+	d.enqueue(ctx, delivery, subscription)
+	return &res, nil
+}
+
+// enqueue queues the delivery unless it is queued already. When the queue is full
+// the claim is released and the delivery is left pending to the resume pass.
+func (d *Dispatcher) enqueue(ctx context.Context, delivery *model.WebhookDelivery, subscription *model.WebhookSubscription) {
+	if !d.track(delivery.ID) {
+		return
+	}
+	select {
+	case d.queue <- job{delivery: delivery, subscription: subscription}:
+	default:
+		d.untrack(delivery.ID)
+		d.logger.ErrorContext(ctx, "webhook delivery queue is full, the delivery is left pending", "delivery_id", delivery.ID)
+		delivery.ClaimedBy, delivery.ClaimedUntil = "", time.Time{}
+		d.save(delivery)
+		select {
+		case d.resume <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// resumeLoop queues the pending deliveries of the log on start, after an overflow of the queue
+// and every resumeInterval.
+func (d *Dispatcher) resumeLoop() {
+	ticker := time.NewTicker(resumeInterval)
+	defer ticker.Stop()
+	for {
+		d.resumePending()
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-d.resume:
+		case <-ticker.C:
+		}
+	}
+}
+
+// resumePending claims the pending deliveries nobody delivers and queues them,
+// waiting for room in the queue.
+func (d *Dispatcher) resumePending() {
+	deliveries, err := d.storage.ClaimPendingWebhookDeliveries(d.ctx, d.id, time.Now().Add(claimLease), queueSize)
+	if err != nil {
+		if d.ctx.Err() == nil {
+			d.logger.ErrorContext(d.ctx, "failed to list pending webhook deliveries", "error", err)
+		}
+		return
+	}
+	subscriptions := make(map[string]*model.WebhookSubscription)
+	for _, delivery := range deliveries {
+		if !d.track(delivery.ID) {
+			continue
+		}
+		subscription, ok := subscriptions[delivery.SubscriptionID]
+		if !ok {
+			subscription, err = d.storage.GetWebhookSubscription(d.ctx, delivery.SubscriptionID)
+			if err != nil {
+				d.untrack(delivery.ID)
+				d.logger.ErrorContext(d.ctx, "failed to resume webhook delivery", "delivery_id", delivery.ID, "error", err)
+				continue
+			}
+			subscriptions[delivery.SubscriptionID] = subscription
+		}
+		select {
+		case d.queue <- job{delivery: delivery, subscription: subscription}:
+		case <-d.ctx.Done():
+			d.untrack(delivery.ID)
+			return
+		}
+	}
+}
+
+// track marks the delivery in flight, it returns false if it is in flight already.
+func (d *Dispatcher) track(deliveryID string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.inflight[deliveryID]; ok {
+		return false
+	}
+	d.inflight[deliveryID] = struct{}{}
+	return true
+}
+
+func (d *Dispatcher) untrack(deliveryID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.inflight, deliveryID)
+}
+
+func matches(subscription *model.WebhookSubscription, eventType, userID string) bool {
+	if subscription.UserID != "" && subscription.UserID != userID {
+		return false
+	}
+	if len(subscription.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range subscription.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// deliver makes up to maxAttempts attempts, waiting backoff(attempt) between them.
+// Every attempt renews the claim, the delivery is dropped if another dispatcher has taken it over.
+func (d *Dispatcher) deliver(j job) {
+	delivery := j.delivery
+	defer d.untrack(delivery.ID)
+	for attempt := 1; ; attempt++ {
+		if !d.renew(delivery) {
+			return
+		}
+		statusCode, err := d.send(j.subscription, delivery)
+		delivery.Attempts++
+		delivery.StatusCode = statusCode
+		switch {
+		case err == nil:
+			delivery.State = model.WebhookDeliveryDelivered
+			delivery.Error = ""
+		case attempt >= d.maxAttempts:
+			delivery.State = model.WebhookDeliveryFailed
+			delivery.Error = err.Error()
+		default:
+			delivery.Error = err.Error()
+			// the claim covers the pause before the next attempt
+			delivery.ClaimedUntil = time.Now().Add(d.backoff(attempt) + claimLease)
+		}
+		d.save(delivery)
+		if delivery.State != model.WebhookDeliveryPending {
+			if err != nil {
+				d.logger.ErrorContext(d.ctx, "webhook delivery failed", "delivery_id", delivery.ID,
+					"subscription_id", delivery.SubscriptionID, "attempts", delivery.Attempts, "error", err)
+			}
+			return
+		}
+
+		timer := time.NewTimer(d.backoff(attempt))
+		select {
+		case <-d.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// renew extends the claim to cover the next attempt, it reports whether the dispatcher still owns the delivery.
+func (d *Dispatcher) renew(delivery *model.WebhookDelivery) bool {
+	until := time.Now().Add(d.client.Timeout + claimLease)
+	err := d.storage.RenewWebhookDeliveryClaim(d.ctx, delivery.ID, d.id, until)
+	switch {
+	case err == nil:
+		delivery.ClaimedUntil = until
+		return true
+	case errors.Is(err, model.ErrWebhookDeliveryNotClaimed):
+		d.logger.InfoContext(d.ctx, "webhook delivery is claimed by another dispatcher", "delivery_id", delivery.ID)
+	case d.ctx.Err() == nil:
+		// the delivery is resumed once the claim expires
+		d.logger.ErrorContext(d.ctx, "failed to renew webhook delivery claim", "delivery_id", delivery.ID, "error", err)
+	}
+	return false
+}
+
+// save records the result of an attempt, it must outlive Stop to not lose the last result.
+func (d *Dispatcher) save(delivery *model.WebhookDelivery) {
+	ctx, cancel := context.WithTimeout(context.Background(), saveTimeout)
+	defer cancel()
+	if err := d.storage.SaveWebhookDelivery(ctx, delivery); err != nil {
+		d.logger.ErrorContext(ctx, "failed to save webhook delivery", "delivery_id", delivery.ID, "error", err)
+	}
+}
+
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	backoff := d.initialBackoff
+	for i := 1; i < attempt && backoff < d.maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > d.maxBackoff {
+		backoff = d.maxBackoff
+	}
+	return backoff
+}
+
+func (d *Dispatcher) send(subscription *model.WebhookSubscription, delivery *model.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(signature.TimestampHeader, timestamp)
+	if subscription.Secret != "" {
+		req.Header.Set(signature.Header, signature.Sign(subscription.Secret, timestamp, delivery.Payload))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	// drain the body to reuse the connection
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
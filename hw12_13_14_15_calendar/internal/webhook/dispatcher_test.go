@@ -0,0 +1,266 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/conf"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/logger"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/signature"
+	memorystorage "github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDispatcher(t *testing.T, s *memorystorage.Storage, maxAttempts int) *Dispatcher {
+	t.Helper()
+	logg, err := logger.New("ERROR")
+	require.NoError(t, err)
+	d := NewDispatcher(s, logg, &conf.WebhooksConf{Workers: 1, MaxAttempts: maxAttempts})
+	d.initialBackoff = 10 * time.Millisecond
+	d.maxBackoff = 20 * time.Millisecond
+	d.Start()
+	t.Cleanup(d.Stop)
+	return d
+}
+
+func waitDelivery(t *testing.T, s *memorystorage.Storage, subscriptionID string) *model.WebhookDelivery {
+	t.Helper()
+	var delivery *model.WebhookDelivery
+	require.Eventually(t, func() bool {
+		deliveries, err := s.ListWebhookDeliveries(context.Background(), subscriptionID, 1)
+		if err != nil || len(deliveries) == 0 || deliveries[0].State == model.WebhookDeliveryPending {
+			return false
+		}
+		delivery = deliveries[0]
+		return true
+	}, time.Second, 5*time.Millisecond)
+	return delivery
+}
+
+func TestDispatcherRetries(t *testing.T) {
+	const secret = "s3cr3t"
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, EventCreated, r.Header.Get(EventHeader))
+		assert.NotEmpty(t, r.Header.Get(DeliveryHeader))
+		timestamp := r.Header.Get(signature.TimestampHeader)
+		assert.True(t, signature.Verify(secret, timestamp, body, r.Header.Get(signature.Header)))
+
+		payload := struct {
+			Type string `json:"type"`
+			Data Event  `json:"data"`
+		}{}
+		assert.NoError(t, json.Unmarshal(body, &payload))
+		assert.Equal(t, EventCreated, payload.Type)
+		assert.Equal(t, "event-1", payload.Data.ID)
+
+		// the first two attempts fail
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	s := memorystorage.New()
+	ctx := context.Background()
+	require.NoError(t, s.CreateWebhookSubscription(ctx, &model.WebhookSubscription{
+		ID: "admin", URL: server.URL, Secret: secret,
+	}))
+	d := newTestDispatcher(t, s, 5)
+
+	d.Publish(ctx, EventCreated, "user-1", &Event{ID: "event-1"})
+
+	delivery := waitDelivery(t, s, "admin")
+	require.Equal(t, model.WebhookDeliveryDelivered, delivery.State)
+	require.Equal(t, 3, delivery.Attempts)
+	require.Equal(t, http.StatusOK, delivery.StatusCode)
+}
+
+func TestDispatcherMatchesSubscriptions(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	s := memorystorage.New()
+	ctx := context.Background()
+	for _, subscription := range []*model.WebhookSubscription{
+		{ID: "owner", UserID: "user-1", URL: server.URL},
+		{ID: "other-user", UserID: "user-2", URL: server.URL},
+		{ID: "reminders-only", URL: server.URL, EventTypes: []string{ReminderFired}},
+	} {
+		require.NoError(t, s.CreateWebhookSubscription(ctx, subscription))
+	}
+	d := newTestDispatcher(t, s, 1)
+
+	d.Publish(ctx, EventUpdated, "user-1", &Event{ID: "event-1"})
+
+	waitDelivery(t, s, "owner")
+	for _, id := range []string{"other-user", "reminders-only"} {
+		deliveries, err := s.ListWebhookDeliveries(ctx, id, 10)
+		require.NoError(t, err)
+		require.Empty(t, deliveries, id)
+	}
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestDispatcherReplay(t *testing.T) {
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	s := memorystorage.New()
+	ctx := context.Background()
+	require.NoError(t, s.CreateWebhookSubscription(ctx, &model.WebhookSubscription{ID: "admin", URL: server.URL}))
+	d := newTestDispatcher(t, s, 2)
+
+	d.Publish(ctx, EventDeleted, "", &Event{ID: "event-1"})
+	delivery := waitDelivery(t, s, "admin")
+	require.Equal(t, model.WebhookDeliveryFailed, delivery.State)
+	require.Equal(t, 2, delivery.Attempts)
+	require.Equal(t, http.StatusInternalServerError, delivery.StatusCode)
+
+	healthy.Store(true)
+	_, err := d.Replay(ctx, delivery.ID)
+	require.NoError(t, err)
+
+	delivery = waitDelivery(t, s, "admin")
+	require.Equal(t, model.WebhookDeliveryDelivered, delivery.State)
+	require.Equal(t, 3, delivery.Attempts)
+}
+
+func TestNilDispatcherIsNoop(_ *testing.T) {
+	var d *Dispatcher
+	d.Publish(context.Background(), EventCreated, "user-1", &Event{ID: "event-1"})
+}
+
+func TestDispatcherResumesPendingDeliveries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	s := memorystorage.New()
+	ctx := context.Background()
+	require.NoError(t, s.CreateWebhookSubscription(ctx, &model.WebhookSubscription{ID: "admin", URL: server.URL}))
+	// the delivery is left pending by a previous run
+	require.NoError(t, s.SaveWebhookDelivery(ctx, &model.WebhookDelivery{
+		ID:             "delivery-1",
+		SubscriptionID: "admin",
+		EventType:      EventCreated,
+		Payload:        []byte(`{}`),
+		State:          model.WebhookDeliveryPending,
+		Attempts:       1,
+	}))
+	newTestDispatcher(t, s, 1)
+
+	delivery := waitDelivery(t, s, "admin")
+	require.Equal(t, model.WebhookDeliveryDelivered, delivery.State)
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestDispatcherResumesOverflow(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+		calls.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	s := memorystorage.New()
+	ctx := context.Background()
+	require.NoError(t, s.CreateWebhookSubscription(ctx, &model.WebhookSubscription{ID: "admin", URL: server.URL}))
+	logg, err := logger.New("ERROR")
+	require.NoError(t, err)
+	d := NewDispatcher(s, logg, &conf.WebhooksConf{Workers: 1, MaxAttempts: 1})
+	// without room in the queue the deliveries overflow while the worker is busy
+	d.queue = make(chan job)
+	d.Start()
+	t.Cleanup(d.Stop)
+
+	for i := 0; i < 3; i++ {
+		d.Publish(ctx, EventCreated, "user-1", &Event{ID: "event-1"})
+	}
+	close(release)
+
+	require.Eventually(t, func() bool {
+		deliveries, err := s.ListWebhookDeliveries(ctx, "admin", 10)
+		if err != nil || len(deliveries) != 3 {
+			return false
+		}
+		for _, delivery := range deliveries {
+			if delivery.State == model.WebhookDeliveryPending {
+				return false
+			}
+		}
+		return true
+	}, 2*time.Second, 5*time.Millisecond)
+	require.Equal(t, int32(3), calls.Load())
+}
+
+func TestDispatcherLeavesDeliveriesClaimedByOthers(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	s := memorystorage.New()
+	ctx := context.Background()
+	require.NoError(t, s.CreateWebhookSubscription(ctx, &model.WebhookSubscription{ID: "admin", URL: server.URL}))
+	// the first delivery is in the backoff of another process, the claim on the second has expired
+	for id, claimedUntil := range map[string]time.Time{
+		"delivery-1": time.Now().Add(time.Hour),
+		"delivery-2": time.Now().Add(-time.Second),
+	} {
+		require.NoError(t, s.SaveWebhookDelivery(ctx, &model.WebhookDelivery{
+			ID:             id,
+			SubscriptionID: "admin",
+			EventType:      EventCreated,
+			Payload:        []byte(`{}`),
+			State:          model.WebhookDeliveryPending,
+			ClaimedBy:      "other",
+			ClaimedUntil:   claimedUntil,
+		}))
+	}
+	d := newTestDispatcher(t, s, 1)
+
+	require.Eventually(t, func() bool {
+		delivery, err := s.GetWebhookDelivery(ctx, "delivery-2")
+		return err == nil && delivery.State == model.WebhookDeliveryDelivered
+	}, time.Second, 5*time.Millisecond)
+	delivery, err := s.GetWebhookDelivery(ctx, "delivery-1")
+	require.NoError(t, err)
+	require.Equal(t, model.WebhookDeliveryPending, delivery.State)
+	require.Equal(t, int32(1), calls.Load())
+
+	// a delivery taken over by another process is not sent
+	taken := &model.WebhookDelivery{ID: "delivery-1", SubscriptionID: "admin", State: model.WebhookDeliveryPending}
+	d.deliver(job{delivery: taken, subscription: &model.WebhookSubscription{ID: "admin", URL: server.URL}})
+	require.Equal(t, int32(1), calls.Load())
+}
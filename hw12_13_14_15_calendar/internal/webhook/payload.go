@@ -0,0 +1,70 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/Azimkhan/hw-golang/hw12_13_14_15_calendar/internal/storage/model"
+)
+
+// Types of the payloads sent to subscribers.
+const (
+	EventCreated  = "event.created"
+	EventUpdated  = "event.updated"
+	EventDeleted  = "event.deleted"
+	ReminderFired = "reminder.fired"
+)
+
+// EventTypes lists all supported types, a subscription may only use these.
+var EventTypes = []string{EventCreated, EventUpdated, EventDeleted, ReminderFired}
+
+// Payload is the JSON body of a webhook request.
+type Payload struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurredAt"`
+	Data       any       `json:"data"`
+}
+
+type Event struct {
+	ID        string     `json:"id"`
+	Title     string     `json:"title,omitempty"`
+	StartTime time.Time  `json:"startTime"`
+	EndTime   time.Time  `json:"endTime"`
+	UserID    string     `json:"userId,omitempty"`
+	Reminders []Reminder `json:"reminders,omitempty"`
+}
+
+type Reminder struct {
+	OffsetSeconds int64  `json:"offsetSeconds"`
+	Channel       string `json:"channel,omitempty"`
+	Message       string `json:"message,omitempty"`
+}
+
+// NewEvent converts the event to the payload data.
+func NewEvent(event *model.Event) *Event {
+	res := &Event{
+		ID:        event.ID,
+		Title:     event.Title,
+		StartTime: event.StartTime,
+		EndTime:   event.EndTime,
+		UserID:    event.UserID,
+	}
+	for _, r := range event.Reminders {
+		res.Reminders = append(res.Reminders, Reminder{
+			OffsetSeconds: int64(r.Offset / time.Second),
+			Channel:       r.Channel,
+			Message:       r.Message,
+		})
+	}
+	return res
+}
+
+// IsSupported reports whether the type is one of EventTypes.
+func IsSupported(eventType string) bool {
+	for _, t := range EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}